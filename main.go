@@ -19,6 +19,7 @@ package main
 import (
 	"flag"
 	"os"
+	"strings"
 
 	rufio "github.com/tinkerbell/rufio/api/v1alpha1"
 	tinkv1alpha1 "github.com/tinkerbell/tink/pkg/apis/core/v1alpha1"
@@ -37,6 +38,10 @@ import (
 
 	seederv1alpha1 "github.com/harvester/seeder/pkg/api/v1alpha1"
 	"github.com/harvester/seeder/pkg/controllers"
+	"github.com/harvester/seeder/pkg/ipam"
+	"github.com/harvester/seeder/pkg/metrics"
+	"github.com/harvester/seeder/pkg/provisioner"
+	"github.com/harvester/seeder/pkg/util"
 	//+kubebuilder:scaffold:imports
 )
 
@@ -63,6 +68,13 @@ func main() {
 	var enableLeaderElection bool
 	var probeAddr string
 	var leaderElectionNamespace string
+	var eventReceiverAddr string
+	var eventReceiverURL string
+	var eventReceiverCertDir string
+	var hardwareLabelPrefix string
+	var hardwareLabelKeys string
+	var virtualMediaAddr string
+	var virtualMediaURL string
 
 	ns, ok := os.LookupEnv("LEADER_ELECTION_NAMESPACE")
 	if !ok {
@@ -75,6 +87,23 @@ func main() {
 	flag.BoolVar(&enableLeaderElection, "leader-elect", true,
 		"Enable leader election for controller manager. "+
 			"Enabling this will ensure there is only one active controller manager.")
+	flag.StringVar(&eventReceiverAddr, "redfish-event-bind-address", "",
+		"The address the redfish event receiver binds to. The receiver is disabled when empty.")
+	flag.StringVar(&eventReceiverURL, "redfish-event-url", "",
+		"The URL of the redfish event receiver reachable from the BMCs, such as https://seeder.example.com:9445. "+
+			"Inventories are subscribed to BMC alerts when it is set.")
+	flag.StringVar(&eventReceiverCertDir, "redfish-event-cert-dir", "",
+		"The directory containing the tls.crt and tls.key served by the redfish event receiver. "+
+			"A self signed certificate is generated when empty.")
+	flag.StringVar(&virtualMediaAddr, "virtual-media-bind-address", "",
+		"The address the virtual media server serving install config ISOs binds to. The server is disabled when empty.")
+	flag.StringVar(&virtualMediaURL, "virtual-media-url", "",
+		"The URL of the virtual media server reachable from the BMCs, such as http://seeder.example.com:9446. "+
+			"Required to boot the Harvester ISO from virtual media.")
+	flag.StringVar(&hardwareLabelPrefix, "hardware-label-prefix", util.DefaultHardwareLabelPrefix,
+		"The prefix of the labels describing the hardware of inventories and their nodes.")
+	flag.StringVar(&hardwareLabelKeys, "hardware-labels", strings.Join(util.DefaultHardwareLabelKeys, ","),
+		"Comma separated hardware labels added to inventories and their nodes, from manufacturer, model, serialNumber and health.")
 	opts := zap.Options{
 		Development: false,
 	}
@@ -99,52 +128,103 @@ func main() {
 
 	ctx := ctrl.SetupSignalHandler()
 
+	hardwareLabels := util.HardwareLabels{Prefix: hardwareLabelPrefix}
+	if hardwareLabelKeys != "" {
+		hardwareLabels.Keys = strings.Split(hardwareLabelKeys, ",")
+	}
+
 	if err = (&controllers.ClusterReconciler{
-		Client: mgr.GetClient(),
-		Scheme: mgr.GetScheme(),
-		Logger: log.FromContext(ctx).WithName("cluster-controller"),
+		Client:       mgr.GetClient(),
+		Scheme:       mgr.GetScheme(),
+		Logger:       log.FromContext(ctx).WithName("cluster-controller"),
+		Provisioners: provisioner.NewDefaultProvisioners(mgr.GetClient(), mgr.GetScheme()),
 	}).SetupWithManager(mgr); err != nil {
 		setupLog.Error(err, "unable to create controller", "controller", "Cluster")
 		os.Exit(1)
 	}
 	if err = (&controllers.InventoryReconciler{
-		Client: mgr.GetClient(),
-		Scheme: mgr.GetScheme(),
-		Logger: log.FromContext(ctx).WithName("inventory-controller"),
+		Client:          mgr.GetClient(),
+		Scheme:          mgr.GetScheme(),
+		Logger:          log.FromContext(ctx).WithName("inventory-controller"),
+		VirtualMediaURL: virtualMediaURL,
 	}).SetupWithManager(mgr); err != nil {
 		setupLog.Error(err, "unable to create controller", "controller", "Inventory")
 		os.Exit(1)
 	}
 
 	if err = (&controllers.AddressPoolReconciler{
-		Client: mgr.GetClient(),
-		Scheme: mgr.GetScheme(),
-		Logger: log.FromContext(ctx).WithName("addresspool-controller"),
+		Client:        mgr.GetClient(),
+		Scheme:        mgr.GetScheme(),
+		Logger:        log.FromContext(ctx).WithName("addresspool-controller"),
+		EventRecorder: mgr.GetEventRecorderFor("seeder"),
 	}).SetupWithManager(mgr); err != nil {
 		setupLog.Error(err, "unable to create controller", "controller", "AddressPool")
 		os.Exit(1)
 	}
 
+	if err = (&controllers.IPClaimReconciler{
+		Client:    mgr.GetClient(),
+		Scheme:    mgr.GetScheme(),
+		Logger:    log.FromContext(ctx).WithName("ipclaim-controller"),
+		Providers: ipam.NewDefaultProviders(mgr.GetClient()),
+	}).SetupWithManager(mgr); err != nil {
+		setupLog.Error(err, "unable to create controller", "controller", "IPClaim")
+		os.Exit(1)
+	}
+
 	if err = (&controllers.InventoryEventReconciller{
-		Client: mgr.GetClient(),
-		Scheme: mgr.GetScheme(),
-		Logger: log.FromContext(ctx).WithName("inventory-	event-controller"),
-		EventRecorder: mgr.GetEventRecorderFor("seeder"),
+		Client:           mgr.GetClient(),
+		Scheme:           mgr.GetScheme(),
+		Logger:           log.FromContext(ctx).WithName("inventory-	event-controller"),
+		EventRecorder:    mgr.GetEventRecorderFor("seeder"),
+		EventReceiverURL: eventReceiverURL,
+		HardwareLabels:   hardwareLabels,
 	}).SetupWithManager(mgr); err != nil {
 		setupLog.Error(err, "unable to create controller", "controller", "InventoryEvent")
 		os.Exit(1)
 	}
 
 	if err = (&controllers.ClusterEventReconciler{
-		Client:        mgr.GetClient(),
-		Scheme:        mgr.GetScheme(),
-		Logger:        log.FromContext(ctx).WithName("cluster-event-controller"),
-		EventRecorder: mgr.GetEventRecorderFor("seeder"),
+		Client:         mgr.GetClient(),
+		Scheme:         mgr.GetScheme(),
+		Logger:         log.FromContext(ctx).WithName("cluster-event-controller"),
+		EventRecorder:  mgr.GetEventRecorderFor("seeder"),
+		HardwareLabels: hardwareLabels,
+		Provisioners:   provisioner.NewDefaultProvisioners(mgr.GetClient(), mgr.GetScheme()),
 	}).SetupWithManager(mgr); err != nil {
 		setupLog.Error(err, "unable to create controller", "controller", "InventoryEvent")
 		os.Exit(1)
 	}
 
+	if eventReceiverAddr != "" {
+		if err = mgr.Add(&controllers.RedfishEventReceiver{
+			Client:        mgr.GetClient(),
+			Logger:        log.FromContext(ctx).WithName("redfish-event-receiver"),
+			EventRecorder: mgr.GetEventRecorderFor("seeder"),
+			BindAddress:   eventReceiverAddr,
+			CertDir:       eventReceiverCertDir,
+		}); err != nil {
+			setupLog.Error(err, "unable to add redfish event receiver")
+			os.Exit(1)
+		}
+	}
+
+	if virtualMediaAddr != "" {
+		if err = mgr.Add(&controllers.VirtualMediaServer{
+			Client:      mgr.GetClient(),
+			Logger:      log.FromContext(ctx).WithName("virtual-media-server"),
+			BindAddress: virtualMediaAddr,
+		}); err != nil {
+			setupLog.Error(err, "unable to add virtual media server")
+			os.Exit(1)
+		}
+	}
+
+	if err = metrics.RegisterCollector(mgr.GetClient()); err != nil {
+		setupLog.Error(err, "unable to register metrics collector")
+		os.Exit(1)
+	}
+
 	//+kubebuilder:scaffold:builder
 	if err := mgr.AddHealthzCheck("healthz", healthz.Ping); err != nil {
 		setupLog.Error(err, "unable to set up health check")
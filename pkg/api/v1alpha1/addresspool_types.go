@@ -13,6 +13,8 @@ const (
 
 const (
 	AddressPoolFinalizer = "finalizer.addresspool.harvesterhci.io"
+	// AllocationsMigratedAnnotation is set once the allocations in the pool status have been migrated to IPClaims
+	AllocationsMigratedAnnotation = "addresspool.harvesterhci.io/allocations-migrated"
 )
 
 //+kubebuilder:object:root=true
@@ -35,6 +37,70 @@ type AddressSpec struct {
 	CIDR    string `json:"cidr"`
 	Netmask string `json:"netmask,omitempty"`
 	Gateway string `json:"gateway"`
+	// StartAddress and EndAddress limit allocation to a range of the CIDR. They default to the first and
+	// last usable address of the CIDR
+	StartAddress string `json:"startAddress,omitempty"`
+	EndAddress   string `json:"endAddress,omitempty"`
+	// ExcludedAddresses are never allocated. Each entry is an address, an address range such as
+	// 192.168.1.10-192.168.1.20, or a CIDR
+	ExcludedAddresses []string `json:"excludedAddresses,omitempty"`
+	// Reservations are addresses held for devices which are not managed by seeder, such as switches,
+	// BMCs or existing servers
+	Reservations []AddressReservation `json:"reservations,omitempty"`
+	// OrphanedAllocationPolicy controls what happens to addresses whose claim or consumer no longer exists, or
+	// whose consumer no longer uses the address. Orphaned addresses are flagged by default
+	// +kubebuilder:validation:Enum=Flag;Release
+	OrphanedAllocationPolicy OrphanedAllocationPolicy `json:"orphanedAllocationPolicy,omitempty"`
+	// AddressRetention is how long the address last held by an inventory is retained for it after being released,
+	// such as 168h. Retained addresses are handed back to the inventory when it needs an address again, and are
+	// only allocated to other objects once the rest of the pool is exhausted. Addresses are not retained if unset
+	AddressRetention string `json:"addressRetention,omitempty"`
+	// Provider allocates addresses from the pool. Pools use the built-in allocator by default
+	// +kubebuilder:validation:Enum=addresspool;http
+	Provider IPAMProvider `json:"provider,omitempty"`
+	// HTTP configures the external IPAM API used by pools with the http provider
+	HTTP *HTTPIPAMSpec `json:"http,omitempty"`
+}
+
+type IPAMProvider string
+
+const (
+	// IPAMProviderAddressPool allocates addresses using the pool spec and status
+	IPAMProviderAddressPool IPAMProvider = "addresspool"
+	// IPAMProviderHTTP allocates addresses using an external IPAM API
+	IPAMProviderHTTP IPAMProvider = "http"
+)
+
+type HTTPIPAMSpec struct {
+	// URL of the IPAM API
+	URL string `json:"url"`
+	// Pool is the name of the pool in the external IPAM. Defaults to the name of the AddressPool
+	Pool string `json:"pool,omitempty"`
+	// TokenSecret references a secret containing a bearer token for the IPAM API in the token key
+	TokenSecret *ObjectReference `json:"tokenSecret,omitempty"`
+}
+
+type OrphanedAllocationPolicy string
+
+const (
+	// OrphanedAllocationFlag reports orphaned addresses without releasing them
+	OrphanedAllocationFlag OrphanedAllocationPolicy = "Flag"
+	// OrphanedAllocationRelease releases orphaned addresses back to the pool
+	OrphanedAllocationRelease OrphanedAllocationPolicy = "Release"
+)
+
+type AddressReservation struct {
+	Name    string `json:"name"`
+	Address string `json:"address"`
+	// Inventory pins the reserved address to an inventory. The address is only allocated to that inventory,
+	// whether or not it is part of a cluster
+	Inventory *ObjectReference `json:"inventory,omitempty"`
+}
+
+// RetainedAddress is an address released by an inventory, which is preferred when it needs an address again
+type RetainedAddress struct {
+	Inventory  ObjectReference `json:"inventory"`
+	ReleasedAt metav1.Time     `json:"releasedAt"`
 }
 
 type AddressStatus struct {
@@ -44,8 +110,17 @@ type AddressStatus struct {
 	AvailableAddresses int                                `json:"availableAddresses"`
 	AddressAllocation  map[string]ObjectReferenceWithKind `json:"addressAllocation"`
 	Netmask            string                             `json:"netmask"`
+	Conditions         []Conditions                       `json:"conditions,omitempty"`
+	RetainedAddresses  map[string]RetainedAddress         `json:"retainedAddresses,omitempty"`
 }
 
+const (
+	// AddressPoolUpdateBlocked is set when a change to the pool spec cannot be applied
+	AddressPoolUpdateBlocked ConditionType = "addressPoolUpdateBlocked"
+	// AddressPoolOrphanedAllocations lists the orphaned addresses found by the last audit of the pool
+	AddressPoolOrphanedAllocations ConditionType = "addressPoolOrphanedAllocations"
+)
+
 type ObjectReferenceWithKind struct {
 	ObjectReference `json:",inline"`
 	Kind            string `json:"kind"`
@@ -34,11 +34,117 @@ type ClusterSpec struct {
 	Nodes            []NodeConfig `json:"nodes"`
 	VIPConfig        `json:"vipConfig"`
 	ClusterConfig    `json:"clusterConfig,omitempty"`
+	// DeletionPolicy is applied to nodes when they are removed from the cluster or the cluster is deleted
+	// +kubebuilder:default:=PowerOff
+	DeletionPolicy DeletionPolicy `json:"deletionPolicy,omitempty"`
+	// Provisioner is the backend used to install nodes in the cluster
+	// +kubebuilder:default:=tinkerbell
+	Provisioner string `json:"provisioner,omitempty"`
+	// BootMethod is used to boot nodes into the Harvester installer
+	// +kubebuilder:default:=pxe
+	BootMethod BootMethod `json:"bootMethod,omitempty"`
+	// ManagementNetwork configures the management network of the installed nodes
+	ManagementNetwork ManagementNetwork `json:"managementNetwork,omitempty"`
+	// NodeHealthPolicy configures how the hardware health of inventories with events enabled is applied to
+	// their nodes
+	NodeHealthPolicy NodeHealthPolicy `json:"nodeHealthPolicy,omitempty"`
+	// NodePropagationPolicy selects the inventory labels and annotations added to the nodes of the cluster, and the
+	// hardware facts added to the nodes of inventories with events enabled
+	NodePropagationPolicy NodePropagationPolicy `json:"nodePropagationPolicy,omitempty"`
+	// MissingNodePolicy configures how seeder reacts to provisioned nodes which are missing from the running cluster
+	MissingNodePolicy MissingNodePolicy `json:"missingNodePolicy,omitempty"`
+}
+
+// MissingNodePolicy configures how seeder reacts to provisioned inventories whose node is not found in the
+// running cluster, such as nodes removed from the cluster by an admin
+type MissingNodePolicy struct {
+	// GracePeriod is how long the node of a provisioned inventory can be missing before the action is applied.
+	// Defaults to 10m
+	GracePeriod string `json:"gracePeriod,omitempty"`
+	// Action applied to inventories whose node is missing beyond the grace period. Defaults to None, which only
+	// reports the missing nodes in the cluster and inventory conditions
+	Action MissingNodeAction `json:"action,omitempty"`
+}
+
+// MissingNodeAction is applied to inventories whose node is missing from the running cluster
+// +kubebuilder:validation:Enum=None;Reprovision;Release
+type MissingNodeAction string
+
+const (
+	// MissingNodeActionNone only reports the missing node
+	MissingNodeActionNone MissingNodeAction = "None"
+	// MissingNodeActionReprovision reinstalls the node, which joins the cluster again
+	MissingNodeActionReprovision MissingNodeAction = "Reprovision"
+	// MissingNodeActionRelease removes the inventory from the nodes in the cluster spec, which releases it using the
+	// deletion policy. Tools applying the cluster manifest add the inventory back unless it is removed from the manifest
+	MissingNodeActionRelease MissingNodeAction = "Release"
+)
+
+type NodeHealthPolicy struct {
+	// TaintDegradedNodes adds a NoSchedule taint to nodes while the hardware health of their inventory is
+	// Warning or Critical, and removes it once the health is OK
+	TaintDegradedNodes bool `json:"taintDegradedNodes,omitempty"`
+}
+
+// NodePropagationPolicy selects what is propagated from an inventory to its node. Propagated labels and annotations
+// are prefixed with seeder.harvesterhci.io/, and are removed from the node once their source no longer exists
+type NodePropagationPolicy struct {
+	// Labels are the keys of the inventory labels added to the node
+	Labels []string `json:"labels,omitempty"`
+	// LabelPrefixes select the inventory labels added to the node by the prefix of their key
+	LabelPrefixes []string `json:"labelPrefixes,omitempty"`
+	// Annotations are the keys of the inventory annotations added to the node
+	Annotations []string `json:"annotations,omitempty"`
+	// AnnotationPrefixes select the inventory annotations added to the node by the prefix of their key
+	AnnotationPrefixes []string `json:"annotationPrefixes,omitempty"`
+	// HardwareFacts are read from the BMC and added as labels to the node
+	HardwareFacts []HardwareFact `json:"hardwareFacts,omitempty"`
+}
+
+// HardwareFact is a fact about the hardware of an inventory read from the BMC
+// +kubebuilder:validation:Enum=cpuModel;memory;nicSpeed;gpu;rack
+type HardwareFact string
+
+const (
+	// HardwareFactCPUModel is the model of the processors
+	HardwareFactCPUModel HardwareFact = "cpuModel"
+	// HardwareFactMemory is the total system memory in GiB
+	HardwareFactMemory HardwareFact = "memory"
+	// HardwareFactNICSpeed is the fastest link speed of the Ethernet interfaces in Mbps
+	HardwareFactNICSpeed HardwareFact = "nicSpeed"
+	// HardwareFactGPU is true when the node has a GPU
+	HardwareFactGPU HardwareFact = "gpu"
+	// HardwareFactRack is the rack of the chassis
+	HardwareFactRack HardwareFact = "rack"
+)
+
+type ManagementNetwork struct {
+	// Method is used to configure the management interface of the installed nodes. Static configures the
+	// address allocated to the node, so the node does not depend on DHCP once installed
+	// +kubebuilder:default:=dhcp
+	Method NetworkMethod `json:"method,omitempty"`
+	// Interfaces are the names of additional inventory interfaces bonded with the management interface
+	// of the inventory
+	Interfaces []string `json:"interfaces,omitempty"`
+	// BondMode is the mode of the management bond. Defaults to balance-tlb
+	// +kubebuilder:validation:Enum=balance-rr;active-backup;balance-xor;broadcast;"802.3ad";balance-tlb;balance-alb
+	BondMode string `json:"bondMode,omitempty"`
+	// MTU of the management network
+	// +kubebuilder:validation:Minimum=576
+	// +kubebuilder:validation:Maximum=9216
+	MTU int `json:"mtu,omitempty"`
+	// VLANID of the management network on the installed nodes
+	// +kubebuilder:validation:Minimum=1
+	// +kubebuilder:validation:Maximum=4094
+	VLANID int `json:"vlanID,omitempty"`
 }
 
 type VIPConfig struct {
 	AddressPoolReference ObjectReference `json:"addressPoolReference"`
 	StaticAddress        string          `json:"staticAddress,omitempty"`
+	// IPv6AddressPoolReference is an optional IPv6 address pool used to allocate a second VIP for dual-stack clusters
+	IPv6AddressPoolReference *ObjectReference `json:"ipv6AddressPoolReference,omitempty"`
+	StaticIPv6Address        string           `json:"staticIPv6Address,omitempty"`
 }
 
 type ClusterConfig struct {
@@ -51,6 +157,12 @@ type NodeConfig struct {
 	InventoryReference   ObjectReference `json:"inventoryReference"`
 	AddressPoolReference ObjectReference `json:"addressPoolReference"`
 	StaticAddress        string          `json:"staticAddress,omitempty"`
+	// IPv6AddressPoolReference is an optional IPv6 address pool used to allocate a second management address
+	// for dual-stack nodes
+	IPv6AddressPoolReference *ObjectReference `json:"ipv6AddressPoolReference,omitempty"`
+	StaticIPv6Address        string           `json:"staticIPv6Address,omitempty"`
+	// ReprovisionToken triggers a reinstall of the node each time it is changed
+	ReprovisionToken string `json:"reprovisionToken,omitempty"`
 }
 
 type ObjectReference struct {
@@ -60,9 +172,13 @@ type ObjectReference struct {
 
 // ClusterStatus defines the observed state of Cluster
 type ClusterStatus struct {
-	ClusterToken   string                `json:"token,omitempty"`
-	Status         ClusterWorkflowStatus `json:"status,omitempty"`
-	ClusterAddress string                `json:"clusterAddress,omitempty"`
+	ClusterToken       string                `json:"token,omitempty"`
+	Status             ClusterWorkflowStatus `json:"status,omitempty"`
+	ClusterAddress     string                `json:"clusterAddress,omitempty"`
+	ClusterIPv6Address string                `json:"clusterIPv6Address,omitempty"`
+	HarvesterVersion   string                `json:"harvesterVersion,omitempty"`
+	UpgradeVersion     string                `json:"upgradeVersion,omitempty"`
+	Conditions         []Conditions          `json:"conditions,omitempty"`
 }
 
 type ClusterWorkflowStatus string
@@ -74,11 +190,22 @@ const (
 	ClusterRunning               ClusterWorkflowStatus = "clusterRunning"
 )
 
+const (
+	ClusterUpgradeBlocked   ConditionType = "clusterUpgradeBlocked"
+	ClusterUpgradeSubmitted ConditionType = "clusterUpgradeSubmitted"
+	ClusterUpgradeCompleted ConditionType = "clusterUpgradeCompleted"
+	ClusterUpgradeFailed    ConditionType = "clusterUpgradeFailed"
+	ClusterReprovisioning   ConditionType = "clusterReprovisioning"
+	// ClusterNodesMissing lists the provisioned inventories whose node is missing from the running cluster
+	ClusterNodesMissing ConditionType = "clusterNodesMissing"
+)
+
 //+kubebuilder:object:root=true
 //+kubebuilder:subresource:status
 //+kubebuilder:printcolumn:name="ClusterStatus",type="string",JSONPath=`.status.status`
 //+kubebuilder:printcolumn:name="ClusterToken",type="string",JSONPath=`.status.token`
 //+kubebuilder:printcolumn:name="ClusterAddress",type="string",JSONPath=`.status.clusterAddress`
+//+kubebuilder:printcolumn:name="HarvesterVersion",type="string",JSONPath=`.status.harvesterVersion`
 
 // Cluster is the Schema for the clusters API
 type Cluster struct {
@@ -7,8 +7,68 @@ const (
 	DefaultAPIPort           = "9345"
 	OverrideAPIPortLabel     = "clusterPort.harvesterhci.io"
 	OverrideRedfishPortLabel = "redfishPort.harvesterhci.io"
+	ReprovisionAnnotation    = "reprovision.harvesterhci.io"
+	// NodeInventoryAnnotation references the inventory of a cluster node as namespace/name
+	NodeInventoryAnnotation = "inventory.harvesterhci.io"
+	// NodeBMCAddressAnnotation is the BMC address of the inventory of a cluster node
+	NodeBMCAddressAnnotation = "bmcAddress.harvesterhci.io"
+	// HardwareDegradedTaint is the NoSchedule taint added to cluster nodes whose hardware health is degraded
+	HardwareDegradedTaint = "hardwareDegraded.harvesterhci.io"
+	// PropagationPrefix is the prefix of the labels and annotations propagated from an inventory to its node
+	PropagationPrefix = "seeder.harvesterhci.io/"
 )
 
 var (
 	DefaultAPIPrefix = "rke2"
 )
+
+// DeletionPolicy defines what happens to a node when it is released from a cluster
+// +kubebuilder:validation:Enum=Retain;PowerOff;Wipe
+type DeletionPolicy string
+
+const (
+	// DeletionPolicyRetain leaves the node running
+	DeletionPolicyRetain DeletionPolicy = "Retain"
+	// DeletionPolicyPowerOff powers off the node
+	DeletionPolicyPowerOff DeletionPolicy = "PowerOff"
+	// DeletionPolicyWipe erases the disks of the node before it is made available again
+	DeletionPolicyWipe DeletionPolicy = "Wipe"
+)
+
+// BootMethod defines how a node is booted into the Harvester installer
+// +kubebuilder:validation:Enum=pxe;virtualMedia
+type BootMethod string
+
+const (
+	// BootMethodPXE boots the node from the network using tinkerbell
+	BootMethodPXE BootMethod = "pxe"
+	// BootMethodVirtualMedia boots the node from an ISO inserted using Redfish VirtualMedia
+	BootMethodVirtualMedia BootMethod = "virtualMedia"
+)
+
+// BootMode defines the firmware boot mode of a node
+// +kubebuilder:validation:Enum=uefi;legacy
+type BootMode string
+
+const (
+	BootModeUEFI   BootMode = "uefi"
+	BootModeLegacy BootMode = "legacy"
+)
+
+// Architecture defines the CPU architecture of a node
+// +kubebuilder:validation:Enum=amd64;arm64
+type Architecture string
+
+const (
+	ArchitectureAMD64 Architecture = "amd64"
+	ArchitectureARM64 Architecture = "arm64"
+)
+
+// NetworkMethod defines how the management interface of an installed node is configured
+// +kubebuilder:validation:Enum=dhcp;static
+type NetworkMethod string
+
+const (
+	NetworkMethodDHCP   NetworkMethod = "dhcp"
+	NetworkMethodStatic NetworkMethod = "static"
+)
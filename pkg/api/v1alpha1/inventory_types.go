@@ -35,6 +35,8 @@ const (
 )
 const (
 	InventoryFinalizer = "finalizer.inventory.harvesterhci.io"
+	// InventoryEventFinalizer ensures the redfish event subscription of the inventory is removed from the BMC
+	InventoryEventFinalizer = "finalizer.inventoryevent.harvesterhci.io"
 )
 
 const (
@@ -42,15 +44,43 @@ const (
 )
 
 const (
-	BMCObjectCreated            ConditionType = "bmcObjectCreated"
-	BMCJobSubmitted             ConditionType = "bmcJobSubmitted"
-	BMCJobComplete              ConditionType = "bmcJobCompleted"
-	BMCJobError                 ConditionType = "bmcJobErrorr"
-	TinkWorkflowCreated         ConditionType = "tinkWorkflowCreated"
-	InventoryAllocatedToCluster ConditionType = "inventoryAllocatedToCluster"
-	InventoryFreed              ConditionType = "inventoryFreed"
-	HarvesterCreateNode         ConditionType = "harvesterCreateNode"
-	HarvesterJoinNode           ConditionType = "harvesterJoinNode"
+	BMCObjectCreated      ConditionType = "bmcObjectCreated"
+	BMCJobSubmitted       ConditionType = "bmcJobSubmitted"
+	BMCJobComplete        ConditionType = "bmcJobCompleted"
+	BMCJobError           ConditionType = "bmcJobErrorr"
+	TinkHardwareCreated   ConditionType = "tinkHardwareCreated"
+	TinkWorkflowCreated   ConditionType = "tinkWorkflowCreated"
+	TinkWorkflowCompleted ConditionType = "tinkWorkflowCompleted"
+	TinkWorkflowFailed    ConditionType = "tinkWorkflowFailed"
+	// TinkPostInstallWorkflowCreated is set once the post-install workflow has been submitted
+	TinkPostInstallWorkflowCreated ConditionType = "tinkPostInstallWorkflowCreated"
+	// TinkPostInstallWorkflowCompleted is set once the post-install workflow has succeeded
+	TinkPostInstallWorkflowCompleted ConditionType = "tinkPostInstallWorkflowCompleted"
+	// TinkPostInstallWorkflowFailed holds the message of a failed post-install workflow
+	TinkPostInstallWorkflowFailed ConditionType = "tinkPostInstallWorkflowFailed"
+	InventoryAllocatedToCluster   ConditionType = "inventoryAllocatedToCluster"
+	InventoryFreed                ConditionType = "inventoryFreed"
+	HarvesterCreateNode           ConditionType = "harvesterCreateNode"
+	HarvesterJoinNode             ConditionType = "harvesterJoinNode"
+	InventoryReprovisioning       ConditionType = "inventoryReprovisioning"
+	InventoryReprovisionPending   ConditionType = "inventoryReprovisionPending"
+	InventoryReprovisionRequired  ConditionType = "inventoryReprovisionRequired"
+	InventoryWiping               ConditionType = "inventoryWiping"
+	InventoryWipeFailed           ConditionType = "inventoryWipeFailed"
+	InventoryProvisioningFailed   ConditionType = "inventoryProvisioningFailed"
+	InventoryProvisioned          ConditionType = "inventoryProvisioned"
+	VirtualMediaInserted          ConditionType = "virtualMediaInserted"
+	VirtualMediaEjected           ConditionType = "virtualMediaEjected"
+	VirtualMediaError             ConditionType = "virtualMediaError"
+	// InventoryBMCAlert holds the last warning or critical alert delivered by the BMC
+	InventoryBMCAlert ConditionType = "inventoryBMCAlert"
+	// InventoryEventSubscriptionFailed is set when seeder is unable to subscribe to events from the BMC
+	InventoryEventSubscriptionFailed ConditionType = "inventoryEventSubscriptionFailed"
+	// InventoryHealthDegraded is set while the BMC reports the hardware health is not OK
+	InventoryHealthDegraded ConditionType = "inventoryHealthDegraded"
+	// InventoryNodeMissing is set while the node of a provisioned inventory is not found in the running cluster.
+	// The start time of the condition is when the node was first found to be missing
+	InventoryNodeMissing ConditionType = "inventoryNodeMissing"
 )
 
 // InventorySpec defines the desired state of Inventory
@@ -59,6 +89,40 @@ type InventorySpec struct {
 	ManagementInterfaceMacAddress string `json:"managementInterfaceMacAddress"`
 	rufio.BaseboardManagementSpec `json:"baseboardSpec"`
 	Events                        `json:"events"`
+	// DeletionPolicy overrides the deletionPolicy of the cluster when the inventory is released
+	DeletionPolicy DeletionPolicy `json:"deletionPolicy,omitempty"`
+	// WorkflowTemplate is a tinkerbell template run on the node before Harvester is installed
+	WorkflowTemplate *WorkflowTemplateReference `json:"workflowTemplate,omitempty"`
+	// PostInstallWorkflowTemplate is a tinkerbell template run on the node once it has joined the cluster.
+	// The node is PXE booted into the tinkerbell OSIE to run the workflow
+	PostInstallWorkflowTemplate *WorkflowTemplateReference `json:"postInstallWorkflowTemplate,omitempty"`
+	// BootMethod overrides the bootMethod of the cluster
+	BootMethod BootMethod `json:"bootMethod,omitempty"`
+	// VirtualMediaImage is the ISO inserted when booting from virtual media, such as an ISO
+	// containing the install configuration for the node. Defaults to the Harvester ISO for the cluster version
+	VirtualMediaImage string `json:"virtualMediaImage,omitempty"`
+	// BootMode is the firmware boot mode used to boot the node
+	// +kubebuilder:default:=uefi
+	BootMode BootMode `json:"bootMode,omitempty"`
+	// Arch is the CPU architecture of the node, used to select the installer artifacts
+	// +kubebuilder:default:=amd64
+	Arch Architecture `json:"arch,omitempty"`
+	// Interfaces are additional network interfaces of the node, which can be bonded with the management
+	// interface using the cluster managementNetwork
+	Interfaces []NetworkInterface `json:"interfaces,omitempty"`
+}
+
+type NetworkInterface struct {
+	Name       string `json:"name"`
+	MacAddress string `json:"macAddress"`
+}
+
+// WorkflowTemplateReference references a tinkerbell template in a template library
+type WorkflowTemplateReference struct {
+	// Library is a ConfigMap containing tinkerbell templates keyed by name
+	Library ObjectReference `json:"library"`
+	// Name of the template in the library
+	Name string `json:"name"`
 }
 
 type BMCSecretReference struct {
@@ -71,6 +135,10 @@ type PXEBootInterface struct {
 	Gateway     string   `json:"gateway,omitempty"`
 	Netmask     string   `json:"netmask,omitempty"`
 	NameServers []string `json:"nameServers,omitempty"`
+	// IPv6 management address of dual-stack nodes
+	IPv6Address string `json:"ipv6Address,omitempty"`
+	IPv6Gateway string `json:"ipv6Gateway,omitempty"`
+	IPv6Netmask string `json:"ipv6Netmask,omitempty"`
 }
 
 // InventoryStatus defines the observed state of Inventory
@@ -81,6 +149,60 @@ type InventoryStatus struct {
 	Conditions        []Conditions            `json:"conditions,omitempty"`
 	PXEBootInterface  `json:"pxeBootConfig,omitempty"`
 	Cluster           ObjectReference `json:"ownerCluster,omitempty"`
+	ReprovisionToken  string          `json:"reprovisionToken,omitempty"`
+	// InstalledConfigHash is a hash of the install configuration used when the node was last provisioned
+	InstalledConfigHash string `json:"installedConfigHash,omitempty"`
+	// ReleasePolicy is the deletion policy applied when the inventory was last released from a cluster
+	ReleasePolicy DeletionPolicy `json:"releasePolicy,omitempty"`
+	// VirtualMediaToken authorises the BMC to download the install config ISO generated for the inventory
+	// while virtual media is inserted
+	VirtualMediaToken string `json:"virtualMediaToken,omitempty"`
+	// EventSubscription is the redfish event subscription registered on the BMC
+	EventSubscription *EventSubscription `json:"eventSubscription,omitempty"`
+	// Health is the hardware health last reported by the BMC
+	Health *HardwareHealth `json:"health,omitempty"`
+	// LogCursors identify the newest entry recorded from each BMC log service, keyed by the URI of the log service
+	LogCursors map[string]LogCursor `json:"logCursors,omitempty"`
+}
+
+// HealthState is the health of hardware reported by the BMC
+// +kubebuilder:validation:Enum=OK;Warning;Critical
+type HealthState string
+
+const (
+	HealthOK       HealthState = "OK"
+	HealthWarning  HealthState = "Warning"
+	HealthCritical HealthState = "Critical"
+)
+
+// HardwareHealth is the health of the node and its subsystems. Subsystems which are not reported by the BMC are
+// omitted
+type HardwareHealth struct {
+	// Status is the worst health of the chassis and its subsystems
+	Status     HealthState `json:"status,omitempty"`
+	Processors HealthState `json:"processors,omitempty"`
+	Memory     HealthState `json:"memory,omitempty"`
+	Storage    HealthState `json:"storage,omitempty"`
+	Power      HealthState `json:"power,omitempty"`
+	Fans       HealthState `json:"fans,omitempty"`
+}
+
+// LogCursor identifies the newest entries recorded from a BMC log service
+type LogCursor struct {
+	// Created is the creation time of the newest entry
+	Created metav1.Time `json:"created"`
+	// EntryIDs are the IDs of the entries created at Created
+	EntryIDs []string `json:"entryIDs,omitempty"`
+}
+
+// EventSubscription is a redfish EventService subscription delivering alerts to the seeder event receiver
+type EventSubscription struct {
+	// URI of the subscription on the BMC
+	URI string `json:"uri"`
+	// Destination is the receiver URL the BMC delivers events to
+	Destination string `json:"destination"`
+	// Context is sent by the BMC with each event, and is used to authenticate events
+	Context string `json:"context"`
 }
 
 type Conditions struct {
@@ -95,6 +217,12 @@ type Events struct {
 	Enabled bool `json:"enabled"`
 	// +kubebuilder:default:="1h"
 	PollingInterval string `json:"pollingInterval,omitempty"`
+	// Telemetry enables reading the temperatures, fan speeds, power supply status and power consumption
+	// from the BMC on each poll. The readings are exported as metrics
+	Telemetry bool `json:"telemetry,omitempty"`
+	// LogServices are the IDs of the BMC log services, such as SEL, whose new entries are recorded as events on
+	// each poll. IDs are matched ignoring case
+	LogServices []string `json:"logServices,omitempty"`
 }
 
 //+kubebuilder:object:root=true
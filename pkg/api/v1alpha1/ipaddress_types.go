@@ -0,0 +1,45 @@
+package v1alpha1
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const (
+	// AddressPoolLabel is the name of the pool an IPAddress is allocated from
+	AddressPoolLabel = "metal.harvesterhci.io/address-pool"
+)
+
+// IPAddressSpec records the allocation of an address from an AddressPool
+type IPAddressSpec struct {
+	Address        string                  `json:"address"`
+	PoolReference  ObjectReference         `json:"poolReference"`
+	ClaimReference ObjectReference         `json:"claimReference"`
+	Consumer       ObjectReferenceWithKind `json:"consumer"`
+}
+
+//+kubebuilder:object:root=true
+//+kubebuilder:printcolumn:name="Address",type="string",JSONPath=`.spec.address`
+//+kubebuilder:printcolumn:name="Pool",type="string",JSONPath=`.spec.poolReference.name`
+//+kubebuilder:printcolumn:name="Claim",type="string",JSONPath=`.spec.claimReference.name`
+
+// IPAddress is an address allocated from an AddressPool. IPAddresses are created in the namespace of the pool,
+// and are named after the pool and address, so an address can only be allocated once
+type IPAddress struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec IPAddressSpec `json:"spec,omitempty"`
+}
+
+//+kubebuilder:object:root=true
+
+// IPAddressList contains a list of IPAddress
+type IPAddressList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []IPAddress `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&IPAddress{}, &IPAddressList{})
+}
@@ -0,0 +1,63 @@
+package v1alpha1
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const (
+	IPClaimFinalizer = "finalizer.ipclaim.harvesterhci.io"
+	KindIPClaim      = "ipclaim"
+)
+
+// IPClaimSpec requests an address from an AddressPool
+type IPClaimSpec struct {
+	// PoolReference is the address pool the address is allocated from
+	PoolReference ObjectReference `json:"poolReference"`
+	// StaticAddress requests a specific address from the pool
+	StaticAddress string `json:"staticAddress,omitempty"`
+	// Consumer is the object recorded against the address in the pool status. Defaults to the claim
+	Consumer *ObjectReferenceWithKind `json:"consumer,omitempty"`
+}
+
+// IPClaimStatus contains the address bound to the claim
+type IPClaimStatus struct {
+	Address string `json:"address,omitempty"`
+	Netmask string `json:"netmask,omitempty"`
+	Gateway string `json:"gateway,omitempty"`
+	// AddressReference is the IPAddress bound to the claim
+	AddressReference *ObjectReference `json:"addressReference,omitempty"`
+	Conditions       []Conditions     `json:"conditions,omitempty"`
+}
+
+const (
+	IPClaimBound  ConditionType = "ipClaimBound"
+	IPClaimFailed ConditionType = "ipClaimFailed"
+)
+
+//+kubebuilder:object:root=true
+//+kubebuilder:subresource:status
+//+kubebuilder:printcolumn:name="Pool",type="string",JSONPath=`.spec.poolReference.name`
+//+kubebuilder:printcolumn:name="Address",type="string",JSONPath=`.status.address`
+
+// IPClaim is a request for an address from an AddressPool. Claims are owned by the object using the address,
+// and the address is released when the claim is deleted
+type IPClaim struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   IPClaimSpec   `json:"spec,omitempty"`
+	Status IPClaimStatus `json:"status,omitempty"`
+}
+
+//+kubebuilder:object:root=true
+
+// IPClaimList contains a list of IPClaim
+type IPClaimList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []IPClaim `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&IPClaim{}, &IPClaimList{})
+}
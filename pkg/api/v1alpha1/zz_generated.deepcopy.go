@@ -30,7 +30,7 @@ func (in *AddressPool) DeepCopyInto(out *AddressPool) {
 	*out = *in
 	out.TypeMeta = in.TypeMeta
 	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
-	out.Spec = in.Spec
+	in.Spec.DeepCopyInto(&out.Spec)
 	in.Status.DeepCopyInto(&out.Status)
 }
 
@@ -84,9 +84,46 @@ func (in *AddressPoolList) DeepCopyObject() runtime.Object {
 	return nil
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *AddressReservation) DeepCopyInto(out *AddressReservation) {
+	*out = *in
+	if in.Inventory != nil {
+		in, out := &in.Inventory, &out.Inventory
+		*out = new(ObjectReference)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new AddressReservation.
+func (in *AddressReservation) DeepCopy() *AddressReservation {
+	if in == nil {
+		return nil
+	}
+	out := new(AddressReservation)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *AddressSpec) DeepCopyInto(out *AddressSpec) {
 	*out = *in
+	if in.ExcludedAddresses != nil {
+		in, out := &in.ExcludedAddresses, &out.ExcludedAddresses
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.Reservations != nil {
+		in, out := &in.Reservations, &out.Reservations
+		*out = make([]AddressReservation, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.HTTP != nil {
+		in, out := &in.HTTP, &out.HTTP
+		*out = new(HTTPIPAMSpec)
+		(*in).DeepCopyInto(*out)
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new AddressSpec.
@@ -109,6 +146,20 @@ func (in *AddressStatus) DeepCopyInto(out *AddressStatus) {
 			(*out)[key] = val
 		}
 	}
+	if in.Conditions != nil {
+		in, out := &in.Conditions, &out.Conditions
+		*out = make([]Conditions, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.RetainedAddresses != nil {
+		in, out := &in.RetainedAddresses, &out.RetainedAddresses
+		*out = make(map[string]RetainedAddress, len(*in))
+		for key, val := range *in {
+			(*out)[key] = *val.DeepCopy()
+		}
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new AddressStatus.
@@ -142,7 +193,7 @@ func (in *Cluster) DeepCopyInto(out *Cluster) {
 	out.TypeMeta = in.TypeMeta
 	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
 	in.Spec.DeepCopyInto(&out.Spec)
-	out.Status = in.Status
+	in.Status.DeepCopyInto(&out.Status)
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new Cluster.
@@ -226,10 +277,16 @@ func (in *ClusterSpec) DeepCopyInto(out *ClusterSpec) {
 	if in.Nodes != nil {
 		in, out := &in.Nodes, &out.Nodes
 		*out = make([]NodeConfig, len(*in))
-		copy(*out, *in)
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
 	}
-	out.VIPConfig = in.VIPConfig
+	in.VIPConfig.DeepCopyInto(&out.VIPConfig)
 	in.ClusterConfig.DeepCopyInto(&out.ClusterConfig)
+	in.ManagementNetwork.DeepCopyInto(&out.ManagementNetwork)
+	out.NodeHealthPolicy = in.NodeHealthPolicy
+	in.NodePropagationPolicy.DeepCopyInto(&out.NodePropagationPolicy)
+	out.MissingNodePolicy = in.MissingNodePolicy
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ClusterSpec.
@@ -245,6 +302,13 @@ func (in *ClusterSpec) DeepCopy() *ClusterSpec {
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *ClusterStatus) DeepCopyInto(out *ClusterStatus) {
 	*out = *in
+	if in.Conditions != nil {
+		in, out := &in.Conditions, &out.Conditions
+		*out = make([]Conditions, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ClusterStatus.
@@ -274,9 +338,29 @@ func (in *Conditions) DeepCopy() *Conditions {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *EventSubscription) DeepCopyInto(out *EventSubscription) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new EventSubscription.
+func (in *EventSubscription) DeepCopy() *EventSubscription {
+	if in == nil {
+		return nil
+	}
+	out := new(EventSubscription)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *Events) DeepCopyInto(out *Events) {
 	*out = *in
+	if in.LogServices != nil {
+		in, out := &in.LogServices, &out.LogServices
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new Events.
@@ -290,11 +374,229 @@ func (in *Events) DeepCopy() *Events {
 }
 
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *Inventory) DeepCopyInto(out *Inventory) {
+func (in *HTTPIPAMSpec) DeepCopyInto(out *HTTPIPAMSpec) {
+	*out = *in
+	if in.TokenSecret != nil {
+		in, out := &in.TokenSecret, &out.TokenSecret
+		*out = new(ObjectReference)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new HTTPIPAMSpec.
+func (in *HTTPIPAMSpec) DeepCopy() *HTTPIPAMSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(HTTPIPAMSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *HardwareHealth) DeepCopyInto(out *HardwareHealth) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new HardwareHealth.
+func (in *HardwareHealth) DeepCopy() *HardwareHealth {
+	if in == nil {
+		return nil
+	}
+	out := new(HardwareHealth)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *IPAddress) DeepCopyInto(out *IPAddress) {
 	*out = *in
 	out.TypeMeta = in.TypeMeta
 	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
 	out.Spec = in.Spec
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new IPAddress.
+func (in *IPAddress) DeepCopy() *IPAddress {
+	if in == nil {
+		return nil
+	}
+	out := new(IPAddress)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *IPAddress) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *IPAddressList) DeepCopyInto(out *IPAddressList) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ListMeta.DeepCopyInto(&out.ListMeta)
+	if in.Items != nil {
+		in, out := &in.Items, &out.Items
+		*out = make([]IPAddress, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new IPAddressList.
+func (in *IPAddressList) DeepCopy() *IPAddressList {
+	if in == nil {
+		return nil
+	}
+	out := new(IPAddressList)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *IPAddressList) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *IPAddressSpec) DeepCopyInto(out *IPAddressSpec) {
+	*out = *in
+	out.PoolReference = in.PoolReference
+	out.ClaimReference = in.ClaimReference
+	out.Consumer = in.Consumer
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new IPAddressSpec.
+func (in *IPAddressSpec) DeepCopy() *IPAddressSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(IPAddressSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *IPClaim) DeepCopyInto(out *IPClaim) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	in.Spec.DeepCopyInto(&out.Spec)
+	in.Status.DeepCopyInto(&out.Status)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new IPClaim.
+func (in *IPClaim) DeepCopy() *IPClaim {
+	if in == nil {
+		return nil
+	}
+	out := new(IPClaim)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *IPClaim) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *IPClaimList) DeepCopyInto(out *IPClaimList) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ListMeta.DeepCopyInto(&out.ListMeta)
+	if in.Items != nil {
+		in, out := &in.Items, &out.Items
+		*out = make([]IPClaim, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new IPClaimList.
+func (in *IPClaimList) DeepCopy() *IPClaimList {
+	if in == nil {
+		return nil
+	}
+	out := new(IPClaimList)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *IPClaimList) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *IPClaimSpec) DeepCopyInto(out *IPClaimSpec) {
+	*out = *in
+	out.PoolReference = in.PoolReference
+	if in.Consumer != nil {
+		in, out := &in.Consumer, &out.Consumer
+		*out = new(ObjectReferenceWithKind)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new IPClaimSpec.
+func (in *IPClaimSpec) DeepCopy() *IPClaimSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(IPClaimSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *IPClaimStatus) DeepCopyInto(out *IPClaimStatus) {
+	*out = *in
+	if in.AddressReference != nil {
+		in, out := &in.AddressReference, &out.AddressReference
+		*out = new(ObjectReference)
+		**out = **in
+	}
+	if in.Conditions != nil {
+		in, out := &in.Conditions, &out.Conditions
+		*out = make([]Conditions, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new IPClaimStatus.
+func (in *IPClaimStatus) DeepCopy() *IPClaimStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(IPClaimStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *Inventory) DeepCopyInto(out *Inventory) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	in.Spec.DeepCopyInto(&out.Spec)
 	in.Status.DeepCopyInto(&out.Status)
 }
 
@@ -352,7 +654,22 @@ func (in *InventoryList) DeepCopyObject() runtime.Object {
 func (in *InventorySpec) DeepCopyInto(out *InventorySpec) {
 	*out = *in
 	out.BaseboardManagementSpec = in.BaseboardManagementSpec
-	out.Events = in.Events
+	in.Events.DeepCopyInto(&out.Events)
+	if in.WorkflowTemplate != nil {
+		in, out := &in.WorkflowTemplate, &out.WorkflowTemplate
+		*out = new(WorkflowTemplateReference)
+		**out = **in
+	}
+	if in.PostInstallWorkflowTemplate != nil {
+		in, out := &in.PostInstallWorkflowTemplate, &out.PostInstallWorkflowTemplate
+		*out = new(WorkflowTemplateReference)
+		**out = **in
+	}
+	if in.Interfaces != nil {
+		in, out := &in.Interfaces, &out.Interfaces
+		*out = make([]NetworkInterface, len(*in))
+		copy(*out, *in)
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new InventorySpec.
@@ -377,6 +694,23 @@ func (in *InventoryStatus) DeepCopyInto(out *InventoryStatus) {
 	}
 	in.PXEBootInterface.DeepCopyInto(&out.PXEBootInterface)
 	out.Cluster = in.Cluster
+	if in.EventSubscription != nil {
+		in, out := &in.EventSubscription, &out.EventSubscription
+		*out = new(EventSubscription)
+		**out = **in
+	}
+	if in.Health != nil {
+		in, out := &in.Health, &out.Health
+		*out = new(HardwareHealth)
+		**out = **in
+	}
+	if in.LogCursors != nil {
+		in, out := &in.LogCursors, &out.LogCursors
+		*out = make(map[string]LogCursor, len(*in))
+		for key, val := range *in {
+			(*out)[key] = *val.DeepCopy()
+		}
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new InventoryStatus.
@@ -389,11 +723,87 @@ func (in *InventoryStatus) DeepCopy() *InventoryStatus {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *LogCursor) DeepCopyInto(out *LogCursor) {
+	*out = *in
+	in.Created.DeepCopyInto(&out.Created)
+	if in.EntryIDs != nil {
+		in, out := &in.EntryIDs, &out.EntryIDs
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new LogCursor.
+func (in *LogCursor) DeepCopy() *LogCursor {
+	if in == nil {
+		return nil
+	}
+	out := new(LogCursor)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ManagementNetwork) DeepCopyInto(out *ManagementNetwork) {
+	*out = *in
+	if in.Interfaces != nil {
+		in, out := &in.Interfaces, &out.Interfaces
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ManagementNetwork.
+func (in *ManagementNetwork) DeepCopy() *ManagementNetwork {
+	if in == nil {
+		return nil
+	}
+	out := new(ManagementNetwork)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *MissingNodePolicy) DeepCopyInto(out *MissingNodePolicy) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new MissingNodePolicy.
+func (in *MissingNodePolicy) DeepCopy() *MissingNodePolicy {
+	if in == nil {
+		return nil
+	}
+	out := new(MissingNodePolicy)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *NetworkInterface) DeepCopyInto(out *NetworkInterface) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new NetworkInterface.
+func (in *NetworkInterface) DeepCopy() *NetworkInterface {
+	if in == nil {
+		return nil
+	}
+	out := new(NetworkInterface)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *NodeConfig) DeepCopyInto(out *NodeConfig) {
 	*out = *in
 	out.InventoryReference = in.InventoryReference
 	out.AddressPoolReference = in.AddressPoolReference
+	if in.IPv6AddressPoolReference != nil {
+		in, out := &in.IPv6AddressPoolReference, &out.IPv6AddressPoolReference
+		*out = new(ObjectReference)
+		**out = **in
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new NodeConfig.
@@ -406,6 +816,61 @@ func (in *NodeConfig) DeepCopy() *NodeConfig {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *NodeHealthPolicy) DeepCopyInto(out *NodeHealthPolicy) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new NodeHealthPolicy.
+func (in *NodeHealthPolicy) DeepCopy() *NodeHealthPolicy {
+	if in == nil {
+		return nil
+	}
+	out := new(NodeHealthPolicy)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *NodePropagationPolicy) DeepCopyInto(out *NodePropagationPolicy) {
+	*out = *in
+	if in.Labels != nil {
+		in, out := &in.Labels, &out.Labels
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.LabelPrefixes != nil {
+		in, out := &in.LabelPrefixes, &out.LabelPrefixes
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.Annotations != nil {
+		in, out := &in.Annotations, &out.Annotations
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.AnnotationPrefixes != nil {
+		in, out := &in.AnnotationPrefixes, &out.AnnotationPrefixes
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.HardwareFacts != nil {
+		in, out := &in.HardwareFacts, &out.HardwareFacts
+		*out = make([]HardwareFact, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new NodePropagationPolicy.
+func (in *NodePropagationPolicy) DeepCopy() *NodePropagationPolicy {
+	if in == nil {
+		return nil
+	}
+	out := new(NodePropagationPolicy)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *ObjectReference) DeepCopyInto(out *ObjectReference) {
 	*out = *in
@@ -457,10 +922,32 @@ func (in *PXEBootInterface) DeepCopy() *PXEBootInterface {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *RetainedAddress) DeepCopyInto(out *RetainedAddress) {
+	*out = *in
+	out.Inventory = in.Inventory
+	in.ReleasedAt.DeepCopyInto(&out.ReleasedAt)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new RetainedAddress.
+func (in *RetainedAddress) DeepCopy() *RetainedAddress {
+	if in == nil {
+		return nil
+	}
+	out := new(RetainedAddress)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *VIPConfig) DeepCopyInto(out *VIPConfig) {
 	*out = *in
 	out.AddressPoolReference = in.AddressPoolReference
+	if in.IPv6AddressPoolReference != nil {
+		in, out := &in.IPv6AddressPoolReference, &out.IPv6AddressPoolReference
+		*out = new(ObjectReference)
+		**out = **in
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new VIPConfig.
@@ -472,3 +959,19 @@ func (in *VIPConfig) DeepCopy() *VIPConfig {
 	in.DeepCopyInto(out)
 	return out
 }
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *WorkflowTemplateReference) DeepCopyInto(out *WorkflowTemplateReference) {
+	*out = *in
+	out.Library = in.Library
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new WorkflowTemplateReference.
+func (in *WorkflowTemplateReference) DeepCopy() *WorkflowTemplateReference {
+	if in == nil {
+		return nil
+	}
+	out := new(WorkflowTemplateReference)
+	in.DeepCopyInto(out)
+	return out
+}
@@ -4,13 +4,18 @@ import (
 	"context"
 	"fmt"
 	"reflect"
+	"strings"
+	"time"
 
 	"github.com/go-logr/logr"
 	seederv1alpha1 "github.com/harvester/seeder/pkg/api/v1alpha1"
+	"github.com/harvester/seeder/pkg/metrics"
 	"github.com/harvester/seeder/pkg/util"
 	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/record"
 	ctrl "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
@@ -21,8 +26,16 @@ type AddressPoolReconciler struct {
 	client.Client
 	Scheme *runtime.Scheme
 	logr.Logger
+	record.EventRecorder
+	// AuditInterval is how often pools are checked for orphaned addresses. Addresses allocated more recently
+	// than the interval are not audited, to give consumers time to record them
+	AuditInterval time.Duration
 }
 
+const (
+	defaultAuditInterval = 10 * time.Minute
+)
+
 type addressPoolReconciler func(context.Context, *seederv1alpha1.AddressPool) error
 
 //+kubebuilder:rbac:groups=metal.harvesterhci.io,resources=inventories,verbs=get;list;watch;create;update;patch;delete
@@ -32,6 +45,8 @@ type addressPoolReconciler func(context.Context, *seederv1alpha1.AddressPool) er
 //+kubebuilder:rbac:groups=metal.harvesterhci.io,resources=addresspools/status,verbs=get;update;patch
 //+kubebuilder:rbac:groups=metal.harvesterhci.io,resources=addresspools/finalizers,verbs=update
 //+kubebuilder:rbac:groups=tinkerbell.org,resources=hardware,verbs=get;list;watch;create;update;patch;delete
+//+kubebuilder:rbac:groups=metal.harvesterhci.io,resources=ipaddresses,verbs=get;list;watch;create;update;patch;delete
+//+kubebuilder:rbac:groups=metal.harvesterhci.io,resources=ipclaims,verbs=get;list;watch;create;update;patch;delete
 
 // Reconcile is part of the main kubernetes reconciliation loop which aims to
 // move the current state of the cluster closer to the desired state.
@@ -56,7 +71,10 @@ func (r *AddressPoolReconciler) Reconcile(ctx context.Context, req ctrl.Request)
 	}
 
 	reconcileList := []addressPoolReconciler{
+		r.reconcileAllocations,
 		r.reconcilePoolCapacity,
+		r.reconcilePoolSpec,
+		r.auditAllocations,
 	}
 
 	deletionReconcileList := []addressPoolReconciler{
@@ -69,6 +87,8 @@ func (r *AddressPoolReconciler) Reconcile(ctx context.Context, req ctrl.Request)
 				return ctrl.Result{}, err
 			}
 		}
+		// requeue to periodically audit the pool for orphaned addresses
+		return ctrl.Result{RequeueAfter: r.auditInterval()}, nil
 	} else {
 		for _, reconciler := range deletionReconcileList {
 			if err := reconciler(ctx, pool); err != nil {
@@ -103,12 +123,17 @@ func (r *AddressPoolReconciler) reconcilePoolCapacity(ctx context.Context, pool
 
 	// reconcile capacity and update status for pool
 
-	if pool.Status.Status == seederv1alpha1.PoolReady && len(pool.Status.AddressAllocation) == pool.Status.AvailableAddresses {
+	allocated, err := util.AllocatedAddresses(pool)
+	if err != nil {
+		return err
+	}
+
+	if pool.Status.Status == seederv1alpha1.PoolReady && allocated >= pool.Status.AvailableAddresses {
 		pool.Status.Status = seederv1alpha1.PoolExhausted
 		return r.Client.Status().Update(ctx, pool)
 	}
 
-	if pool.Status.Status == seederv1alpha1.PoolExhausted && len(pool.Status.AddressAllocation) < pool.Status.AvailableAddresses {
+	if pool.Status.Status == seederv1alpha1.PoolExhausted && allocated < pool.Status.AvailableAddresses {
 		pool.Status.Status = seederv1alpha1.PoolReady
 		return r.Client.Status().Update(ctx, pool)
 	}
@@ -116,11 +141,305 @@ func (r *AddressPoolReconciler) reconcilePoolCapacity(ctx context.Context, pool
 	return nil
 }
 
+// reconcilePoolSpec applies changes to the pool spec to the pool status. Growing the pool is applied immediately,
+// while changes which leave allocated addresses outside of the pool are blocked until the addresses are released.
+// Allocation always uses the current spec, so no new addresses are allocated outside of the updated pool
+func (r *AddressPoolReconciler) reconcilePoolSpec(ctx context.Context, pool *seederv1alpha1.AddressPool) error {
+	// wait for initial reconcile
+	if pool.Status.Status == "" {
+		return nil
+	}
+
+	status := pool.Status.DeepCopy()
+	var blockedMessage string
+	generated, err := util.GenerateAddressPoolStatus(pool)
+	if err != nil {
+		blockedMessage = fmt.Sprintf("invalid pool spec: %v", err)
+	} else {
+		outside, err := util.AllocationsOutsidePool(pool)
+		if err != nil {
+			return err
+		}
+		if len(outside) != 0 {
+			blockedMessage = fmt.Sprintf("allocated addresses are outside of the updated pool: %s", strings.Join(outside, ", "))
+		} else {
+			status.StartAddress = generated.StartAddress
+			status.LastAddress = generated.LastAddress
+			status.AvailableAddresses = generated.AvailableAddresses
+			status.Netmask = generated.Netmask
+		}
+	}
+
+	if blockedMessage != "" {
+		if util.ConditionMessage(status.Conditions, seederv1alpha1.AddressPoolUpdateBlocked) != blockedMessage {
+			status.Conditions = util.CreateOrUpdateCondition(status.Conditions, seederv1alpha1.AddressPoolUpdateBlocked, blockedMessage)
+		}
+	} else {
+		status.Conditions = util.RemoveCondition(status.Conditions, seederv1alpha1.AddressPoolUpdateBlocked)
+	}
+
+	if reflect.DeepEqual(status, &pool.Status) {
+		return nil
+	}
+
+	pool.Status = *status
+	return r.Status().Update(ctx, pool)
+}
+
+// reconcileAllocations derives the address allocation of the pool from the IPAddresses allocated from it
+func (r *AddressPoolReconciler) reconcileAllocations(ctx context.Context, pool *seederv1alpha1.AddressPool) error {
+	// wait for initial reconcile
+	if pool.Status.Status == "" {
+		return nil
+	}
+
+	addresses, err := util.ListPoolAddresses(ctx, r.Client, pool)
+	if err != nil {
+		return err
+	}
+	allocation := util.AddressAllocation(addresses)
+
+	// allocations written directly to the pool status are migrated to claims once, so they can be released
+	// the same way as addresses allocated using claims
+	if _, ok := pool.Annotations[seederv1alpha1.AllocationsMigratedAnnotation]; !ok {
+		for address, ref := range pool.Status.AddressAllocation {
+			if _, ok := allocation[address]; ok || reflect.DeepEqual(ref, seederv1alpha1.ObjectReferenceWithKind{}) {
+				continue
+			}
+			if err := r.migrateAllocation(ctx, pool, address, ref); err != nil {
+				return fmt.Errorf("error migrating allocation of address %s in pool %s: %v", address, pool.Name, err)
+			}
+		}
+
+		if pool.Annotations == nil {
+			pool.Annotations = make(map[string]string)
+		}
+		pool.Annotations[seederv1alpha1.AllocationsMigratedAnnotation] = "true"
+		return r.Update(ctx, pool)
+	}
+
+	retained, err := util.RetainAddresses(pool, allocation, time.Now())
+	if err != nil {
+		return err
+	}
+
+	if reflect.DeepEqual(allocation, pool.Status.AddressAllocation) && reflect.DeepEqual(retained, pool.Status.RetainedAddresses) {
+		return nil
+	}
+
+	pool.Status.AddressAllocation = allocation
+	pool.Status.RetainedAddresses = retained
+	return r.Status().Update(ctx, pool)
+}
+
+// auditAllocations finds addresses whose claim or consumer no longer exists, or whose consumer no longer uses
+// the address. Depending on the pool policy, orphaned addresses are either released or flagged in the pool status
+func (r *AddressPoolReconciler) auditAllocations(ctx context.Context, pool *seederv1alpha1.AddressPool) error {
+	// wait for initial reconcile
+	if pool.Status.Status == "" {
+		return nil
+	}
+
+	addresses, err := util.ListPoolAddresses(ctx, r.Client, pool)
+	if err != nil {
+		return err
+	}
+
+	var orphaned []string
+	for _, v := range addresses {
+		if !v.DeletionTimestamp.IsZero() || time.Since(v.CreationTimestamp.Time) < r.auditInterval() {
+			continue
+		}
+
+		reason, claim, err := r.orphanReason(ctx, &v)
+		if err != nil {
+			return err
+		}
+
+		if reason == "" {
+			continue
+		}
+
+		message := fmt.Sprintf("%s (%s %s/%s): %s", v.Spec.Address, v.Spec.Consumer.Kind, v.Spec.Consumer.Namespace,
+			v.Spec.Consumer.Name, reason)
+
+		if pool.Spec.OrphanedAllocationPolicy == seederv1alpha1.OrphanedAllocationRelease {
+			if err := r.releaseOrphan(ctx, &v, claim); err != nil {
+				return fmt.Errorf("error releasing orphaned address %s in pool %s: %v", v.Spec.Address, pool.Name, err)
+			}
+			metrics.ReleasedOrphanedAllocations.WithLabelValues(pool.Namespace, pool.Name).Inc()
+			r.Eventf(pool, "Normal", "OrphanedAllocationReleased", "released orphaned address %s", message)
+			continue
+		}
+
+		// only newly orphaned addresses generate an event
+		if !strings.Contains(util.ConditionMessage(pool.Status.Conditions, seederv1alpha1.AddressPoolOrphanedAllocations), message) {
+			r.Eventf(pool, "Warning", "OrphanedAllocation", "orphaned address %s", message)
+		}
+		orphaned = append(orphaned, message)
+	}
+
+	metrics.OrphanedAllocations.WithLabelValues(pool.Namespace, pool.Name).Set(float64(len(orphaned)))
+
+	conditions := pool.Status.Conditions
+	if len(orphaned) != 0 {
+		message := fmt.Sprintf("orphaned addresses: %s", strings.Join(orphaned, ", "))
+		if util.ConditionMessage(conditions, seederv1alpha1.AddressPoolOrphanedAllocations) == message {
+			return nil
+		}
+		conditions = util.CreateOrUpdateCondition(conditions, seederv1alpha1.AddressPoolOrphanedAllocations, message)
+	} else {
+		if !util.ConditionExists(conditions, seederv1alpha1.AddressPoolOrphanedAllocations) {
+			return nil
+		}
+		conditions = util.RemoveCondition(conditions, seederv1alpha1.AddressPoolOrphanedAllocations)
+	}
+
+	pool.Status.Conditions = conditions
+	return r.Status().Update(ctx, pool)
+}
+
+// orphanReason returns why an address is orphaned, or an empty string if the address is still in use. The claim
+// is returned if it still exists
+func (r *AddressPoolReconciler) orphanReason(ctx context.Context, address *seederv1alpha1.IPAddress) (string, *seederv1alpha1.IPClaim, error) {
+	claim := &seederv1alpha1.IPClaim{}
+	err := r.Get(ctx, types.NamespacedName{Namespace: address.Spec.ClaimReference.Namespace,
+		Name: address.Spec.ClaimReference.Name}, claim)
+	if err != nil {
+		if apierrors.IsNotFound(err) {
+			return "claim not found", nil, nil
+		}
+		return "", nil, err
+	}
+
+	// addresses of deleted claims are released by the claim controller
+	if !claim.DeletionTimestamp.IsZero() {
+		return "", nil, nil
+	}
+
+	// the claim controller adopts the address if the claim status has not been updated yet
+	if claim.Status.Address != "" && claim.Status.Address != address.Spec.Address {
+		return fmt.Sprintf("claim is bound to address %s", claim.Status.Address), nil, nil
+	}
+
+	var inUse bool
+	switch address.Spec.Consumer.Kind {
+	case seederv1alpha1.KindCluster:
+		inUse, err = r.lookupClusterVIP(ctx, address.Spec.Consumer.ObjectReference, address.Spec.Address)
+	case seederv1alpha1.KindInventory:
+		inUse, err = r.lookupInventoryAddress(ctx, address.Spec.Consumer.ObjectReference, address.Spec.Address)
+	default:
+		// addresses claimed by other controllers are in use as long as the claim exists
+		inUse = true
+	}
+
+	if err != nil {
+		return "", nil, err
+	}
+
+	if !inUse {
+		return fmt.Sprintf("%s no longer exists or does not use the address", address.Spec.Consumer.Kind), claim, nil
+	}
+
+	return "", nil, nil
+}
+
+// releaseOrphan deletes the claim bound to the orphaned address, which releases the address, or the address
+// itself if the claim no longer holds it
+func (r *AddressPoolReconciler) releaseOrphan(ctx context.Context, address *seederv1alpha1.IPAddress, claim *seederv1alpha1.IPClaim) error {
+	var obj client.Object = address
+	if claim != nil {
+		obj = claim
+	}
+
+	if err := r.Delete(ctx, obj); err != nil && !apierrors.IsNotFound(err) {
+		return err
+	}
+	return nil
+}
+
+func (r *AddressPoolReconciler) auditInterval() time.Duration {
+	if r.AuditInterval == 0 {
+		return defaultAuditInterval
+	}
+	return r.AuditInterval
+}
+
+// migrateAllocation creates the IPClaim and IPAddress for an address allocated in the pool status. The claim is
+// named the same way as the claims of inventory and clusters, so it is released along with them
+func (r *AddressPoolReconciler) migrateAllocation(ctx context.Context, pool *seederv1alpha1.AddressPool, address string, ref seederv1alpha1.ObjectReferenceWithKind) error {
+	ipv6, err := util.IsIPv6Pool(pool)
+	if err != nil {
+		return err
+	}
+
+	claim := &seederv1alpha1.IPClaim{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      util.ClaimName(ref.Kind, ref.Name, ipv6),
+			Namespace: ref.Namespace,
+		},
+		Spec: seederv1alpha1.IPClaimSpec{
+			PoolReference: seederv1alpha1.ObjectReference{
+				Name:      pool.Name,
+				Namespace: pool.Namespace,
+			},
+			StaticAddress: address,
+			Consumer:      ref.DeepCopy(),
+		},
+	}
+
+	var owner client.Object
+	switch ref.Kind {
+	case seederv1alpha1.KindInventory:
+		owner = &seederv1alpha1.Inventory{}
+	case seederv1alpha1.KindCluster:
+		owner = &seederv1alpha1.Cluster{}
+	}
+
+	if owner != nil {
+		err := r.Get(ctx, types.NamespacedName{Namespace: ref.Namespace, Name: ref.Name}, owner)
+		if err != nil && !apierrors.IsNotFound(err) {
+			return err
+		}
+		if err == nil {
+			if err := controllerutil.SetOwnerReference(owner, claim, r.Scheme); err != nil {
+				return err
+			}
+		}
+	}
+
+	if err := r.Create(ctx, claim); err != nil && !apierrors.IsAlreadyExists(err) {
+		return err
+	}
+
+	ipAddress, err := util.GenerateIPAddress(pool, claim, address)
+	if err != nil {
+		return err
+	}
+
+	if err := controllerutil.SetControllerReference(pool, ipAddress, r.Scheme); err != nil {
+		return err
+	}
+
+	if err := r.Create(ctx, ipAddress); err != nil && !apierrors.IsAlreadyExists(err) {
+		return err
+	}
+	return nil
+}
+
 // deleteAddressPool will ensure that none of the IP's is in use before removing finalizer
 func (r *AddressPoolReconciler) deleteAddressPool(ctx context.Context, pool *seederv1alpha1.AddressPool) error {
 	if !pool.DeletionTimestamp.IsZero() && controllerutil.ContainsFinalizer(pool, seederv1alpha1.AddressPoolFinalizer) {
+		addresses, err := util.ListPoolAddresses(ctx, r.Client, pool)
+		if err != nil {
+			return err
+		}
+
+		if len(addresses) != 0 {
+			return fmt.Errorf("addresspool %s has %d addresses allocated to claims, requeuing", pool.Name, len(addresses))
+		}
+
 		var addressInUse bool
-		var err error
 		for address, ref := range pool.Status.AddressAllocation {
 			if reflect.DeepEqual(ref, seederv1alpha1.ObjectReferenceWithKind{}) {
 				delete(pool.Status.AddressAllocation, address)
@@ -140,6 +459,8 @@ func (r *AddressPoolReconciler) deleteAddressPool(ctx context.Context, pool *see
 		if addressInUse {
 			return fmt.Errorf("one of the address in addresspool %s is still in use, requeuing", pool.Name)
 		}
+		metrics.OrphanedAllocations.DeleteLabelValues(pool.Namespace, pool.Name)
+		metrics.ReleasedOrphanedAllocations.DeleteLabelValues(pool.Namespace, pool.Name)
 		controllerutil.RemoveFinalizer(pool, seederv1alpha1.AddressPoolFinalizer)
 		return r.Client.Update(ctx, pool)
 	}
@@ -151,6 +472,7 @@ func (r *AddressPoolReconciler) deleteAddressPool(ctx context.Context, pool *see
 func (r *AddressPoolReconciler) SetupWithManager(mgr ctrl.Manager) error {
 	return ctrl.NewControllerManagedBy(mgr).
 		For(&seederv1alpha1.AddressPool{}).
+		Owns(&seederv1alpha1.IPAddress{}).
 		Complete(r)
 }
 
@@ -164,7 +486,7 @@ func (r *AddressPoolReconciler) lookupClusterVIP(ctx context.Context, obj seeder
 		return false, err
 	}
 
-	if c.Status.ClusterAddress == address {
+	if c.Status.ClusterAddress == address || c.Status.ClusterIPv6Address == address {
 		return true, nil
 	}
 
@@ -181,7 +503,7 @@ func (r *AddressPoolReconciler) lookupInventoryAddress(ctx context.Context, obj
 		return false, err
 	}
 
-	if c.Status.PXEBootInterface.Address == address {
+	if c.Status.PXEBootInterface.Address == address || c.Status.PXEBootInterface.IPv6Address == address {
 		return true, nil
 	}
 
@@ -2,9 +2,13 @@ package controllers
 
 import (
 	"fmt"
+	"strings"
+
 	seederv1alpha1 "github.com/harvester/seeder/pkg/api/v1alpha1"
+	"github.com/harvester/seeder/pkg/util"
 	. "github.com/onsi/ginkgo/v2"
 	. "github.com/onsi/gomega"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/types"
 )
@@ -51,3 +55,245 @@ var _ = Describe("AddressPool controller tests", func() {
 		}).ShouldNot(HaveOccurred())
 	})
 })
+
+var _ = Describe("AddressPool resize tests", func() {
+	var a *seederv1alpha1.AddressPool
+	var claim *seederv1alpha1.IPClaim
+
+	BeforeEach(func() {
+		a = &seederv1alpha1.AddressPool{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "resize",
+				Namespace: "default",
+			},
+			Spec: seederv1alpha1.AddressSpec{
+				CIDR:    "192.168.20.0/29",
+				Gateway: "192.168.20.1",
+			},
+		}
+
+		claim = &seederv1alpha1.IPClaim{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "resize",
+				Namespace: "default",
+			},
+			Spec: seederv1alpha1.IPClaimSpec{
+				PoolReference: seederv1alpha1.ObjectReference{
+					Name:      "resize",
+					Namespace: "default",
+				},
+				StaticAddress: "192.168.20.6",
+			},
+		}
+
+		Eventually(func() error {
+			return k8sClient.Create(ctx, a)
+		}, "30s", "5s").ShouldNot(HaveOccurred())
+
+		Eventually(func() error {
+			return k8sClient.Create(ctx, claim)
+		}, "30s", "5s").ShouldNot(HaveOccurred())
+
+		Eventually(func() error {
+			obj := &seederv1alpha1.AddressPool{}
+			if err := k8sClient.Get(ctx, types.NamespacedName{Namespace: a.Namespace, Name: a.Name}, obj); err != nil {
+				return err
+			}
+			if len(obj.Status.AddressAllocation) != 1 {
+				return fmt.Errorf("waiting for claim to be allocated")
+			}
+			return nil
+		}, "30s", "5s").ShouldNot(HaveOccurred())
+	})
+
+	It("grow pool and block shrinking while addresses are allocated outside the pool", func() {
+		Eventually(func() error {
+			obj := &seederv1alpha1.AddressPool{}
+			if err := k8sClient.Get(ctx, types.NamespacedName{Namespace: a.Namespace, Name: a.Name}, obj); err != nil {
+				return err
+			}
+			obj.Spec.CIDR = "192.168.20.0/28"
+			return k8sClient.Update(ctx, obj)
+		}, "30s", "5s").ShouldNot(HaveOccurred())
+
+		Eventually(func() error {
+			obj := &seederv1alpha1.AddressPool{}
+			if err := k8sClient.Get(ctx, types.NamespacedName{Namespace: a.Namespace, Name: a.Name}, obj); err != nil {
+				return err
+			}
+			if obj.Status.LastAddress != "192.168.20.14" || obj.Status.AvailableAddresses != 13 {
+				return fmt.Errorf("waiting for pool to grow: %v", obj.Status)
+			}
+			return nil
+		}, "30s", "5s").ShouldNot(HaveOccurred())
+
+		Eventually(func() error {
+			obj := &seederv1alpha1.AddressPool{}
+			if err := k8sClient.Get(ctx, types.NamespacedName{Namespace: a.Namespace, Name: a.Name}, obj); err != nil {
+				return err
+			}
+			obj.Spec.CIDR = "192.168.20.0/30"
+			return k8sClient.Update(ctx, obj)
+		}, "30s", "5s").ShouldNot(HaveOccurred())
+
+		Eventually(func() error {
+			obj := &seederv1alpha1.AddressPool{}
+			if err := k8sClient.Get(ctx, types.NamespacedName{Namespace: a.Namespace, Name: a.Name}, obj); err != nil {
+				return err
+			}
+			message := util.ConditionMessage(obj.Status.Conditions, seederv1alpha1.AddressPoolUpdateBlocked)
+			if !strings.Contains(message, "192.168.20.6 (ipclaim default/resize)") {
+				return fmt.Errorf("expected blocked condition to name the allocation, got %q", message)
+			}
+			if obj.Status.LastAddress != "192.168.20.14" {
+				return fmt.Errorf("expected blocked change to not be applied: %v", obj.Status)
+			}
+			return nil
+		}, "30s", "5s").ShouldNot(HaveOccurred())
+
+		// releasing the address allows the pool to shrink
+		Eventually(func() error {
+			return k8sClient.Delete(ctx, claim)
+		}, "30s", "5s").ShouldNot(HaveOccurred())
+
+		Eventually(func() error {
+			obj := &seederv1alpha1.AddressPool{}
+			if err := k8sClient.Get(ctx, types.NamespacedName{Namespace: a.Namespace, Name: a.Name}, obj); err != nil {
+				return err
+			}
+			if util.ConditionExists(obj.Status.Conditions, seederv1alpha1.AddressPoolUpdateBlocked) {
+				return fmt.Errorf("waiting for blocked condition to be removed")
+			}
+			if obj.Status.LastAddress != "192.168.20.2" {
+				return fmt.Errorf("waiting for pool to shrink: %v", obj.Status)
+			}
+			return nil
+		}, "60s", "5s").ShouldNot(HaveOccurred())
+	})
+
+	AfterEach(func() {
+		Eventually(func() error {
+			err := k8sClient.Delete(ctx, claim)
+			if apierrors.IsNotFound(err) {
+				return nil
+			}
+			return err
+		}, "30s", "5s").ShouldNot(HaveOccurred())
+
+		Eventually(func() error {
+			return k8sClient.Delete(ctx, a)
+		}, "30s", "5s").ShouldNot(HaveOccurred())
+	})
+})
+
+var _ = Describe("AddressPool orphaned allocation audit tests", func() {
+	var a *seederv1alpha1.AddressPool
+	var address *seederv1alpha1.IPAddress
+
+	BeforeEach(func() {
+		a = &seederv1alpha1.AddressPool{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "orphan",
+				Namespace: "default",
+			},
+			Spec: seederv1alpha1.AddressSpec{
+				CIDR:    "192.168.30.0/29",
+				Gateway: "192.168.30.1",
+			},
+		}
+
+		// address allocated to a claim and inventory which no longer exist
+		address = &seederv1alpha1.IPAddress{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "orphan-192.168.30.2",
+				Namespace: "default",
+				Labels: map[string]string{
+					seederv1alpha1.AddressPoolLabel: "orphan",
+				},
+			},
+			Spec: seederv1alpha1.IPAddressSpec{
+				Address: "192.168.30.2",
+				PoolReference: seederv1alpha1.ObjectReference{
+					Name:      "orphan",
+					Namespace: "default",
+				},
+				ClaimReference: seederv1alpha1.ObjectReference{
+					Name:      "inventory-missing-ipv4",
+					Namespace: "default",
+				},
+				Consumer: seederv1alpha1.ObjectReferenceWithKind{
+					ObjectReference: seederv1alpha1.ObjectReference{
+						Name:      "missing",
+						Namespace: "default",
+					},
+					Kind: seederv1alpha1.KindInventory,
+				},
+			},
+		}
+
+		Eventually(func() error {
+			return k8sClient.Create(ctx, a)
+		}, "30s", "5s").ShouldNot(HaveOccurred())
+
+		Eventually(func() error {
+			return k8sClient.Create(ctx, address)
+		}, "30s", "5s").ShouldNot(HaveOccurred())
+	})
+
+	It("flag orphaned address and release it once policy is changed", func() {
+		Eventually(func() error {
+			obj := &seederv1alpha1.AddressPool{}
+			if err := k8sClient.Get(ctx, types.NamespacedName{Namespace: a.Namespace, Name: a.Name}, obj); err != nil {
+				return err
+			}
+			message := util.ConditionMessage(obj.Status.Conditions, seederv1alpha1.AddressPoolOrphanedAllocations)
+			if !strings.Contains(message, "192.168.30.2 (inventory default/missing): claim not found") {
+				return fmt.Errorf("expected orphaned address to be flagged, got %q", message)
+			}
+			return nil
+		}, "60s", "5s").ShouldNot(HaveOccurred())
+
+		Eventually(func() error {
+			obj := &seederv1alpha1.AddressPool{}
+			if err := k8sClient.Get(ctx, types.NamespacedName{Namespace: a.Namespace, Name: a.Name}, obj); err != nil {
+				return err
+			}
+			obj.Spec.OrphanedAllocationPolicy = seederv1alpha1.OrphanedAllocationRelease
+			return k8sClient.Update(ctx, obj)
+		}, "30s", "5s").ShouldNot(HaveOccurred())
+
+		Eventually(func() error {
+			obj := &seederv1alpha1.IPAddress{}
+			err := k8sClient.Get(ctx, types.NamespacedName{Namespace: address.Namespace, Name: address.Name}, obj)
+			if err == nil {
+				return fmt.Errorf("waiting for orphaned address to be released")
+			}
+			if !apierrors.IsNotFound(err) {
+				return err
+			}
+
+			pool := &seederv1alpha1.AddressPool{}
+			if err := k8sClient.Get(ctx, types.NamespacedName{Namespace: a.Namespace, Name: a.Name}, pool); err != nil {
+				return err
+			}
+			if util.ConditionExists(pool.Status.Conditions, seederv1alpha1.AddressPoolOrphanedAllocations) {
+				return fmt.Errorf("waiting for orphaned allocation condition to be removed")
+			}
+			return nil
+		}, "60s", "5s").ShouldNot(HaveOccurred())
+	})
+
+	AfterEach(func() {
+		Eventually(func() error {
+			err := k8sClient.Delete(ctx, address)
+			if apierrors.IsNotFound(err) {
+				return nil
+			}
+			return err
+		}, "30s", "5s").ShouldNot(HaveOccurred())
+
+		Eventually(func() error {
+			return k8sClient.Delete(ctx, a)
+		}, "30s", "5s").ShouldNot(HaveOccurred())
+	})
+})
@@ -19,17 +19,22 @@ package controllers
 import (
 	"context"
 	"fmt"
+	"time"
 
 	"github.com/go-logr/logr"
 	seederv1alpha1 "github.com/harvester/seeder/pkg/api/v1alpha1"
+	"github.com/harvester/seeder/pkg/metrics"
+	"github.com/harvester/seeder/pkg/provisioner"
 	"github.com/harvester/seeder/pkg/tink"
 	"github.com/harvester/seeder/pkg/util"
-	tinkv1alpha1 "github.com/tinkerbell/tink/pkg/apis/core/v1alpha1"
+	rufio "github.com/tinkerbell/rufio/api/v1alpha1"
 	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/dynamic"
 	typedCore "k8s.io/client-go/kubernetes/typed/core/v1"
+	"k8s.io/client-go/rest"
 	"k8s.io/client-go/tools/clientcmd"
 	ctrl "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/client"
@@ -44,6 +49,8 @@ type ClusterReconciler struct {
 	client.Client
 	Scheme *runtime.Scheme
 	logr.Logger
+	// Provisioners are the backends available to install nodes, keyed by name
+	Provisioners map[string]provisioner.Provisioner
 }
 
 type clusterReconciler func(context.Context, *seederv1alpha1.Cluster) error
@@ -51,6 +58,8 @@ type clusterReconciler func(context.Context, *seederv1alpha1.Cluster) error
 //+kubebuilder:rbac:groups=metal.harvesterhci.io,resources=clusters,verbs=get;list;watch;create;update;patch;delete
 //+kubebuilder:rbac:groups=metal.harvesterhci.io,resources=clusters/status,verbs=get;update;patch
 //+kubebuilder:rbac:groups=metal.harvesterhci.io,resources=clusters/finalizers,verbs=update
+//+kubebuilder:rbac:groups="",resources=configmaps,verbs=get;list;watch
+//+kubebuilder:rbac:groups=metal.harvesterhci.io,resources=ipclaims,verbs=get;list;watch;create;update;patch;delete
 
 // Reconcile is part of the main kubernetes reconciliation loop which aims to
 // move the current state of the cluster closer to the desired state.
@@ -78,9 +87,11 @@ func (r *ClusterReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ct
 	reconcileList := []clusterReconciler{
 		r.generateClusterConfig,
 		r.patchNodesAndPools,
-		r.createTinkerbellHardware,
+		r.reconcileReprovision,
+		r.provisionNodes,
 		r.reconcileNodes,
 		r.markClusterReady,
+		r.reconcileUpgrade,
 	}
 	deletionReconcileList := []clusterReconciler{
 		r.cleanupClusterDeps,
@@ -106,43 +117,25 @@ func (r *ClusterReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ct
 // generateClusterConfig will generate the clusterConfig
 func (r *ClusterReconciler) generateClusterConfig(ctx context.Context, c *seederv1alpha1.Cluster) error {
 	if c.Status.Status == "" {
-		vipPool := &seederv1alpha1.AddressPool{}
-		err := r.Get(ctx, types.NamespacedName{Namespace: c.Spec.VIPConfig.AddressPoolReference.Namespace,
-			Name: c.Spec.VIPConfig.AddressPoolReference.Name}, vipPool)
-		if err != nil {
-			return err
-		}
-
-		if vipPool.Status.Status != seederv1alpha1.PoolReady {
-			return fmt.Errorf("waiting for address pool %s to be ready", vipPool.Name)
+		if c.Status.ClusterAddress == "" {
+			claim, err := r.claimAddress(ctx, c, util.ClaimName(seederv1alpha1.KindCluster, c.Name, false),
+				c.Spec.VIPConfig.AddressPoolReference, c.Spec.VIPConfig.StaticAddress, clusterOwner(c))
+			if err != nil {
+				return err
+			}
+			c.Status.ClusterAddress = claim.Status.Address
 		}
 
-		if c.Status.ClusterAddress == "" {
-			var addressFound bool
-			for address, v := range vipPool.Status.AddressAllocation {
-				if v.Kind == seederv1alpha1.KindCluster && v.Name == c.Name && v.Namespace == c.Namespace {
-					addressFound = true
-					c.Status.ClusterAddress = address
-				}
+		if c.Spec.VIPConfig.IPv6AddressPoolReference != nil && c.Status.ClusterIPv6Address == "" {
+			if err := r.checkIPv6Pool(ctx, *c.Spec.VIPConfig.IPv6AddressPoolReference); err != nil {
+				return err
 			}
-			if !addressFound {
-				vip, err := util.AllocateAddress(vipPool.Status.DeepCopy(), c.Spec.VIPConfig.StaticAddress)
-				if err != nil {
-					return err
-				}
-				c.Status.ClusterAddress = vip
-				// update address allocation
-				vipPool.Status.AddressAllocation[vip] = seederv1alpha1.ObjectReferenceWithKind{
-					Kind: seederv1alpha1.KindCluster,
-					ObjectReference: seederv1alpha1.ObjectReference{
-						Name:      c.Name,
-						Namespace: c.Namespace,
-					},
-				}
-				if err := r.Status().Update(ctx, vipPool); err != nil {
-					return fmt.Errorf("error updating address pool with cluster vip: %v", err)
-				}
+			claim, err := r.claimAddress(ctx, c, util.ClaimName(seederv1alpha1.KindCluster, c.Name, true),
+				*c.Spec.VIPConfig.IPv6AddressPoolReference, c.Spec.VIPConfig.StaticIPv6Address, clusterOwner(c))
+			if err != nil {
+				return err
 			}
+			c.Status.ClusterIPv6Address = claim.Status.Address
 		}
 
 		c.Status.ClusterToken = util.GenerateRand()
@@ -157,15 +150,8 @@ func (r *ClusterReconciler) generateClusterConfig(ctx context.Context, c *seeder
 func (r *ClusterReconciler) patchNodesAndPools(ctx context.Context, c *seederv1alpha1.Cluster) error {
 	if c.Status.Status == seederv1alpha1.ClusterConfigReady && len(c.Spec.Nodes) > 0 {
 		for n, nc := range c.Spec.Nodes {
-			pool := &seederv1alpha1.AddressPool{}
-			err := r.Get(ctx, types.NamespacedName{Namespace: nc.AddressPoolReference.Namespace,
-				Name: nc.AddressPoolReference.Name}, pool)
-			if err != nil {
-				return fmt.Errorf("error during address pool lookup while configuring nodes: %v", err)
-			}
-
 			i := &seederv1alpha1.Inventory{}
-			err = r.Get(ctx, types.NamespacedName{Namespace: nc.InventoryReference.Namespace,
+			err := r.Get(ctx, types.NamespacedName{Namespace: nc.InventoryReference.Namespace,
 				Name: nc.InventoryReference.Name}, i)
 			if err != nil {
 				return err
@@ -180,32 +166,38 @@ func (r *ClusterReconciler) patchNodesAndPools(ctx context.Context, c *seederv1a
 				continue
 			}
 
-			var found bool
-			var nodeAddress string
-			for address, nodeDetails := range pool.Status.AddressAllocation {
-				if nodeDetails.Name == i.Name && nodeDetails.Namespace == i.Namespace {
-					found = true
-					nodeAddress = address
-				}
-			}
-
-			if !found {
-				if pool.Status.Status != seederv1alpha1.PoolReady {
-					return fmt.Errorf("waiting for address pool %s to be ready", pool.Name)
-				}
-				nodeAddress, err = util.AllocateAddress(pool.Status.DeepCopy(), nc.StaticAddress)
+			// inventory being wiped is only available once the wipe is confirmed
+			if util.ConditionExists(i.Status.Conditions, seederv1alpha1.InventoryWiping) {
+				return fmt.Errorf("waiting for inventory %s in namespace %s to be wiped", i.Name, i.Namespace)
 			}
 
+			claim, err := r.claimAddress(ctx, i, util.ClaimName(seederv1alpha1.KindInventory, i.Name, false),
+				nc.AddressPoolReference, nc.StaticAddress, inventoryOwner(nc.InventoryReference))
 			if err != nil {
 				return err
 			}
 
-			i.Status.PXEBootInterface.Address = nodeAddress
-			i.Status.PXEBootInterface.Gateway = pool.Spec.Gateway
-			i.Status.PXEBootInterface.Netmask = pool.Status.Netmask
+			i.Status.PXEBootInterface.Address = claim.Status.Address
+			i.Status.PXEBootInterface.Gateway = claim.Status.Gateway
+			i.Status.PXEBootInterface.Netmask = claim.Status.Netmask
+
+			if nc.IPv6AddressPoolReference != nil {
+				if err := r.checkIPv6Pool(ctx, *nc.IPv6AddressPoolReference); err != nil {
+					return err
+				}
+				claim, err := r.claimAddress(ctx, i, util.ClaimName(seederv1alpha1.KindInventory, i.Name, true),
+					*nc.IPv6AddressPoolReference, nc.StaticIPv6Address, inventoryOwner(nc.InventoryReference))
+				if err != nil {
+					return err
+				}
+				i.Status.PXEBootInterface.IPv6Address = claim.Status.Address
+				i.Status.PXEBootInterface.IPv6Gateway = claim.Status.Gateway
+				i.Status.PXEBootInterface.IPv6Netmask = claim.Status.Netmask
+			}
 
 			// node password and conditions
 			i.Status.GeneratedPassword = util.GenerateRand()
+			i.Status.ReprovisionToken = nc.ReprovisionToken
 			i.Status.Cluster.Namespace = c.Namespace
 			i.Status.Cluster.Name = c.Name
 			i.Status.Conditions = util.CreateOrUpdateCondition(i.Status.Conditions, seederv1alpha1.InventoryAllocatedToCluster,
@@ -222,20 +214,6 @@ func (r *ClusterReconciler) patchNodesAndPools(ctx context.Context, c *seederv1a
 			if err != nil {
 				return err
 			}
-			// update pool with node allocation if not already done
-			if !found {
-				pool.Status.AddressAllocation[nodeAddress] = seederv1alpha1.ObjectReferenceWithKind{
-					ObjectReference: seederv1alpha1.ObjectReference{
-						Namespace: i.Namespace,
-						Name:      i.Name,
-					},
-					Kind: seederv1alpha1.KindInventory,
-				}
-				err = r.Status().Update(ctx, pool)
-				if err != nil {
-					return err
-				}
-			}
 		}
 
 		c.Status.Status = seederv1alpha1.ClusterNodesPatched
@@ -253,11 +231,10 @@ func (r *ClusterReconciler) patchNodesAndPools(ctx context.Context, c *seederv1a
 	return nil
 }
 
-// createTinkerbellHardware will create hardware objects for all nodes in the cluster
-func (r *ClusterReconciler) createTinkerbellHardware(ctx context.Context, c *seederv1alpha1.Cluster) error {
+// provisionNodes will use the cluster provisioner to create the objects needed to install all nodes in the cluster
+func (r *ClusterReconciler) provisionNodes(ctx context.Context, c *seederv1alpha1.Cluster) error {
 	if c.Status.Status == seederv1alpha1.ClusterNodesPatched || c.Status.Status == seederv1alpha1.ClusterTinkHardwareSubmitted || c.Status.Status == seederv1alpha1.ClusterRunning {
 		for _, i := range c.Spec.Nodes {
-			var hardwareUpdated bool
 			inventory := &seederv1alpha1.Inventory{}
 			err := r.Get(ctx, types.NamespacedName{Namespace: i.InventoryReference.Namespace, Name: i.InventoryReference.Name}, inventory)
 			if err != nil {
@@ -272,41 +249,55 @@ func (r *ClusterReconciler) createTinkerbellHardware(ctx context.Context, c *see
 				continue
 			}
 
-			hw, err := tink.GenerateHWRequest(inventory, c)
+			p, err := r.provisioner(c)
 			if err != nil {
 				return err
 			}
-			// create hardware object
-			err = controllerutil.SetOwnerReference(c, hw, r.Scheme)
+
+			// create / update provisioning objects if they already exist
+			hardwareUpdated, err := p.Apply(ctx, inventory, c)
 			if err != nil {
 				return err
 			}
 
-			// create / update hardware object if one already exists
-			lookupHw := &tinkv1alpha1.Hardware{}
-			err = r.Get(ctx, types.NamespacedName{Namespace: hw.Namespace, Name: hw.Name}, lookupHw)
+			statusChanged := r.reconcileConfigDrift(inventory, c, hardwareUpdated)
+			if hardwareUpdated {
+				inventory.Status.Conditions = util.CreateOrUpdateCondition(inventory.Status.Conditions, seederv1alpha1.TinkHardwareCreated,
+					fmt.Sprintf("%s provisioning objects created", provisioner.Name(c)))
+			}
 
+			progress, err := p.Progress(ctx, inventory)
 			if err != nil {
-				if apierrors.IsNotFound(err) {
-					if err := r.Create(ctx, hw); err != nil {
-						return err
-					}
-					hardwareUpdated = true
-				} else {
-					return err
-				}
-			} /*else {
-				if !reflect.DeepEqual(lookupHw.Spec, hw.Spec) {
-					lookupHw.Spec = hw.Spec
-					if err := r.Update(ctx, lookupHw); err != nil {
-						return err
-					}
-					hardwareUpdated = true
-				}
-			}*/
+				return err
+			}
 
-			if hardwareUpdated {
-				inventory.Status.Conditions = util.CreateOrUpdateCondition(inventory.Status.Conditions, seederv1alpha1.TinkWorkflowCreated, "tink workflow created")
+			failed := util.ConditionExists(inventory.Status.Conditions, seederv1alpha1.InventoryProvisioningFailed)
+			if progress.Phase == provisioner.PhaseFailed && !failed {
+				inventory.Status.Conditions = util.CreateOrUpdateCondition(inventory.Status.Conditions, seederv1alpha1.InventoryProvisioningFailed, progress.Message)
+				statusChanged = true
+			}
+
+			if progress.Phase != provisioner.PhaseFailed && failed {
+				inventory.Status.Conditions = util.RemoveCondition(inventory.Status.Conditions, seederv1alpha1.InventoryProvisioningFailed)
+				statusChanged = true
+			}
+
+			// inventories installed by provisioners which do not report completion are marked provisioned by the
+			// cluster event controller once their node joins the cluster
+			provisioned := util.ConditionExists(inventory.Status.Conditions, seederv1alpha1.InventoryProvisioned)
+			if progress.Phase == provisioner.PhaseCompleted && !provisioned {
+				observeNodeProvisioned(inventory, provisioner.Name(c))
+				inventory.Status.Conditions = util.CreateOrUpdateCondition(inventory.Status.Conditions, seederv1alpha1.InventoryProvisioned, "")
+				statusChanged = true
+			}
+
+			// the node is being provisioned again, such as during reprovisioning
+			if progress.Phase != provisioner.PhaseCompleted && provisioned && p.ReportsCompletion(inventory) {
+				inventory.Status.Conditions = util.RemoveCondition(inventory.Status.Conditions, seederv1alpha1.InventoryProvisioned)
+				statusChanged = true
+			}
+
+			if hardwareUpdated || statusChanged {
 				if err := r.Status().Update(ctx, inventory); err != nil {
 					return err
 				}
@@ -323,6 +314,226 @@ func (r *ClusterReconciler) createTinkerbellHardware(ctx context.Context, c *see
 	return nil
 }
 
+// observeNodeProvisioned records the time from the allocation of the inventory to the cluster until it was provisioned.
+// Inventories of provisioners which do not report install completion are provisioned once their node joins the cluster
+func observeNodeProvisioned(i *seederv1alpha1.Inventory, provisionerName string) {
+	for _, v := range i.Status.Conditions {
+		if v.Type == seederv1alpha1.InventoryAllocatedToCluster {
+			metrics.NodeProvisioningDuration.WithLabelValues(provisionerName).Observe(time.Since(v.StartTime.Time).Seconds())
+		}
+	}
+}
+
+// provisioner returns the provisioning backend used by the cluster
+func (r *ClusterReconciler) provisioner(c *seederv1alpha1.Cluster) (provisioner.Provisioner, error) {
+	name := provisioner.Name(c)
+	p, ok := r.Provisioners[name]
+	if !ok {
+		return nil, fmt.Errorf("unknown provisioner %s for cluster %s", name, c.Name)
+	}
+	return p, nil
+}
+
+// reconcileConfigDrift records the install configuration of a newly provisioned node, and flags nodes
+// whose installed configuration no longer matches the cluster spec. Returns true if the inventory status changed
+func (r *ClusterReconciler) reconcileConfigDrift(i *seederv1alpha1.Inventory, c *seederv1alpha1.Cluster, provisioned bool) bool {
+	hash := tink.GenerateInstallConfigHash(i, c)
+	// nodes provisioned before the hash was tracked are assumed to match the current spec
+	if provisioned || i.Status.InstalledConfigHash == "" {
+		i.Status.InstalledConfigHash = hash
+		i.Status.Conditions = util.RemoveCondition(i.Status.Conditions, seederv1alpha1.InventoryReprovisionRequired)
+		return true
+	}
+
+	drifted := i.Status.InstalledConfigHash != hash
+	required := util.ConditionExists(i.Status.Conditions, seederv1alpha1.InventoryReprovisionRequired)
+	if drifted && !required {
+		i.Status.Conditions = util.CreateOrUpdateCondition(i.Status.Conditions, seederv1alpha1.InventoryReprovisionRequired,
+			"installed configuration does not match cluster spec, reprovision node to apply changes")
+		return true
+	}
+
+	if !drifted && required {
+		i.Status.Conditions = util.RemoveCondition(i.Status.Conditions, seederv1alpha1.InventoryReprovisionRequired)
+		return true
+	}
+
+	return false
+}
+
+// reconcileReprovision will reinstall nodes on demand. A full rebuild of the cluster is triggered by
+// the reprovision annotation on the cluster, and individual nodes can be reinstalled using the reprovision
+// annotation on the inventory or by changing the reprovisionToken in the node config
+func (r *ClusterReconciler) reconcileReprovision(ctx context.Context, c *seederv1alpha1.Cluster) error {
+	if c.Status.Status != seederv1alpha1.ClusterTinkHardwareSubmitted && c.Status.Status != seederv1alpha1.ClusterRunning {
+		return nil
+	}
+
+	if _, ok := c.Annotations[seederv1alpha1.ReprovisionAnnotation]; ok {
+		return r.reprovisionCluster(ctx, c)
+	}
+
+	// rebuild of the cluster is in progress, and the remaining nodes can be reinstalled
+	// once the first node is running again
+	if util.ConditionExists(c.Status.Conditions, seederv1alpha1.ClusterReprovisioning) {
+		if c.Status.Status != seederv1alpha1.ClusterRunning {
+			return nil
+		}
+		for _, nc := range c.Spec.Nodes {
+			i := &seederv1alpha1.Inventory{}
+			if err := r.Get(ctx, types.NamespacedName{Namespace: nc.InventoryReference.Namespace,
+				Name: nc.InventoryReference.Name}, i); err != nil {
+				return err
+			}
+			if util.ConditionExists(i.Status.Conditions, seederv1alpha1.InventoryReprovisionPending) {
+				if err := r.reprovisionNode(ctx, c, i); err != nil {
+					return err
+				}
+			}
+		}
+		c.Status.Conditions = util.RemoveCondition(c.Status.Conditions, seederv1alpha1.ClusterReprovisioning)
+		return r.Status().Update(ctx, c)
+	}
+
+	for _, nc := range c.Spec.Nodes {
+		i := &seederv1alpha1.Inventory{}
+		if err := r.Get(ctx, types.NamespacedName{Namespace: nc.InventoryReference.Namespace,
+			Name: nc.InventoryReference.Name}, i); err != nil {
+			return err
+		}
+
+		if !util.ConditionExists(i.Status.Conditions, seederv1alpha1.InventoryAllocatedToCluster) ||
+			i.Status.Cluster.Name != c.Name || i.Status.Cluster.Namespace != c.Namespace {
+			continue
+		}
+
+		_, annotated := i.Annotations[seederv1alpha1.ReprovisionAnnotation]
+		if !annotated && nc.ReprovisionToken == i.Status.ReprovisionToken {
+			continue
+		}
+
+		if annotated {
+			delete(i.Annotations, seederv1alpha1.ReprovisionAnnotation)
+			if err := r.Update(ctx, i); err != nil {
+				return err
+			}
+		}
+
+		// node needs to join the running cluster once reinstalled
+		if len(c.Spec.Nodes) > 1 && util.ConditionExists(i.Status.Conditions, seederv1alpha1.HarvesterCreateNode) {
+			i.Status.Conditions = util.RemoveCondition(i.Status.Conditions, seederv1alpha1.HarvesterCreateNode)
+			i.Status.Conditions = util.CreateOrUpdateCondition(i.Status.Conditions, seederv1alpha1.HarvesterJoinNode, "Join Mode")
+		}
+
+		if err := r.removeRemoteNode(ctx, c, i); err != nil {
+			r.Error(err, "unable to remove node from cluster, proceeding with reprovision", "inventory", i.Name)
+		}
+
+		i.Status.ReprovisionToken = nc.ReprovisionToken
+		if err := r.reprovisionNode(ctx, c, i); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// reprovisionCluster will rebuild the cluster with a new token. The first node in the cluster spec creates the
+// cluster and is reinstalled first, and remaining nodes are marked to be reinstalled once the cluster is running.
+// The node creating the cluster is chosen again, as a node reprovisioned on its own rejoins the cluster
+func (r *ClusterReconciler) reprovisionCluster(ctx context.Context, c *seederv1alpha1.Cluster) error {
+	delete(c.Annotations, seederv1alpha1.ReprovisionAnnotation)
+	if err := r.Update(ctx, c); err != nil {
+		return err
+	}
+
+	for n, nc := range c.Spec.Nodes {
+		i := &seederv1alpha1.Inventory{}
+		if err := r.Get(ctx, types.NamespacedName{Namespace: nc.InventoryReference.Namespace,
+			Name: nc.InventoryReference.Name}, i); err != nil {
+			return err
+		}
+
+		if n == 0 {
+			i.Status.Conditions = util.RemoveCondition(i.Status.Conditions, seederv1alpha1.HarvesterJoinNode)
+			i.Status.Conditions = util.CreateOrUpdateCondition(i.Status.Conditions, seederv1alpha1.HarvesterCreateNode, "Create Mode")
+			if err := r.reprovisionNode(ctx, c, i); err != nil {
+				return err
+			}
+			continue
+		}
+
+		i.Status.Conditions = util.RemoveCondition(i.Status.Conditions, seederv1alpha1.HarvesterCreateNode)
+		i.Status.Conditions = util.CreateOrUpdateCondition(i.Status.Conditions, seederv1alpha1.HarvesterJoinNode, "Join Mode")
+		i.Status.Conditions = util.CreateOrUpdateCondition(i.Status.Conditions, seederv1alpha1.InventoryReprovisionPending,
+			fmt.Sprintf("waiting for cluster %s to be rebuilt", c.Name))
+		if err := r.Status().Update(ctx, i); err != nil {
+			return err
+		}
+	}
+
+	c.Status.ClusterToken = util.GenerateRand()
+	c.Status.Status = seederv1alpha1.ClusterTinkHardwareSubmitted
+	c.Status.Conditions = util.CreateOrUpdateCondition(c.Status.Conditions, seederv1alpha1.ClusterReprovisioning, "cluster rebuild in progress")
+	return r.Status().Update(ctx, c)
+}
+
+// reprovisionNode regenerates the credentials for the inventory and removes the existing provisioning and BMCJob
+// objects. The provisioning objects are regenerated in the same reconcile, which will trigger a new BMCJob to PXE boot the node
+func (r *ClusterReconciler) reprovisionNode(ctx context.Context, c *seederv1alpha1.Cluster, i *seederv1alpha1.Inventory) error {
+	p, err := r.provisioner(c)
+	if err != nil {
+		return err
+	}
+
+	if err := p.Teardown(ctx, i); err != nil {
+		return err
+	}
+
+	// the post-install workflow is run again once the node has been reinstalled
+	for _, name := range []string{fmt.Sprintf("%s-reboot", i.Name), tink.PostInstallWorkflowName(i)} {
+		job := &rufio.BMCJob{}
+		err = r.Get(ctx, types.NamespacedName{Namespace: i.Namespace, Name: name}, job)
+		if err == nil {
+			err = r.Delete(ctx, job)
+		}
+		if err != nil && !apierrors.IsNotFound(err) {
+			return err
+		}
+	}
+
+	i.Status.GeneratedPassword = util.GenerateRand()
+	i.Status.Conditions = removeTinkConditions(i.Status.Conditions)
+	i.Status.Conditions = util.RemoveCondition(i.Status.Conditions, seederv1alpha1.BMCJobSubmitted)
+	i.Status.Conditions = util.RemoveCondition(i.Status.Conditions, seederv1alpha1.BMCJobComplete)
+	i.Status.Conditions = util.RemoveCondition(i.Status.Conditions, seederv1alpha1.BMCJobError)
+	i.Status.Conditions = util.RemoveCondition(i.Status.Conditions, seederv1alpha1.InventoryReprovisionPending)
+	// the node is provisioned again once the provisioner reports the new install has completed
+	i.Status.Conditions = util.RemoveCondition(i.Status.Conditions, seederv1alpha1.InventoryProvisioned)
+	i.Status.Conditions = util.CreateOrUpdateCondition(i.Status.Conditions, seederv1alpha1.InventoryReprovisioning, "node reprovision triggered")
+	return r.Status().Update(ctx, i)
+}
+
+// removeRemoteNode will remove the k8s node associated with the inventory from the target cluster
+func (r *ClusterReconciler) removeRemoteNode(ctx context.Context, c *seederv1alpha1.Cluster, i *seederv1alpha1.Inventory) error {
+	typedClient, err := genCoreTypedClient(ctx, c)
+	if err != nil {
+		return err
+	}
+
+	nodeList, err := typedClient.Nodes().List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return err
+	}
+
+	node := findNodeByIP(nodeList.Items, i.Status.Address)
+	if node == nil {
+		return nil
+	}
+
+	return typedClient.Nodes().Delete(ctx, node.Name, metav1.DeleteOptions{})
+}
+
 // reconcileNodes will perform housekeeping needed when nodes are added or
 // removed from the cluster
 func (r *ClusterReconciler) reconcileNodes(ctx context.Context, c *seederv1alpha1.Cluster) error {
@@ -360,40 +571,33 @@ func (r *ClusterReconciler) reconcileNodes(ctx context.Context, c *seederv1alpha
 				}
 			}
 
-			// free up address
-			a, err := util.FindIPInAddressPools(ctx, r.Client, i.Name, i.Namespace, i.Status.PXEBootInterface.Address)
-			if err != nil {
-				return err
-			}
-
-			if a != nil {
-				delete(a.Status.AddressAllocation, i.Status.PXEBootInterface.Address)
-				if err := r.Status().Update(ctx, a); err != nil {
+			// free up address, unless it is needed to wipe the node
+			policy := releasePolicy(c, iObj)
+			if policy != seederv1alpha1.DeletionPolicyWipe {
+				if err := util.ReleaseAddresses(ctx, r.Client, seederv1alpha1.KindInventory, i.Name, i.Namespace); err != nil {
 					return err
 				}
+				iObj.Status.PXEBootInterface = seederv1alpha1.PXEBootInterface{}
 			}
 			// need to clean up inventory
-			iObj.Status.PXEBootInterface = seederv1alpha1.PXEBootInterface{}
+			iObj.Status.ReleasePolicy = policy
 			iObj.Status.Cluster = seederv1alpha1.ObjectReference{}
 			iObj.Status.GeneratedPassword = ""
 			iObj.Status.Conditions = util.RemoveCondition(iObj.Status.Conditions, seederv1alpha1.InventoryAllocatedToCluster)
-			iObj.Status.Conditions = util.RemoveCondition(iObj.Status.Conditions, seederv1alpha1.TinkWorkflowCreated)
+			iObj.Status.Conditions = util.RemoveCondition(iObj.Status.Conditions, seederv1alpha1.InventoryProvisioned)
+			iObj.Status.Conditions = removeTinkConditions(iObj.Status.Conditions)
 			iObj.Status.Conditions = util.RemoveCondition(iObj.Status.Conditions, seederv1alpha1.HarvesterJoinNode)
 			iObj.Status.Conditions = util.CreateOrUpdateCondition(iObj.Status.Conditions, seederv1alpha1.InventoryFreed, "")
 			if err := r.Status().Update(ctx, iObj); err != nil {
 				return err
 			}
 
-			// find and clean up hardware object
-			hw := &tinkv1alpha1.Hardware{}
-			if err := r.Get(ctx, types.NamespacedName{Namespace: iObj.Namespace, Name: iObj.Name}, hw); err != nil {
-				if apierrors.IsNotFound(err) {
-					continue
-				} else {
-					return err
-				}
+			// clean up provisioning objects
+			p, err := r.provisioner(c)
+			if err != nil {
+				return err
 			}
-			if err := r.Delete(ctx, hw); err != nil {
+			if err := p.Teardown(ctx, iObj); err != nil {
 				return err
 			}
 		}
@@ -421,24 +625,37 @@ func (r *ClusterReconciler) reconcileNodes(ctx context.Context, c *seederv1alpha
 	return nil
 }
 
+// removeTinkConditions removes the conditions tracking tink objects generated to install a node
+func removeTinkConditions(conditions []seederv1alpha1.Conditions) []seederv1alpha1.Conditions {
+	for _, v := range []seederv1alpha1.ConditionType{seederv1alpha1.TinkHardwareCreated, seederv1alpha1.TinkWorkflowCreated,
+		seederv1alpha1.TinkWorkflowCompleted, seederv1alpha1.TinkWorkflowFailed, seederv1alpha1.TinkPostInstallWorkflowCreated,
+		seederv1alpha1.TinkPostInstallWorkflowCompleted, seederv1alpha1.TinkPostInstallWorkflowFailed} {
+		conditions = util.RemoveCondition(conditions, v)
+	}
+	return conditions
+}
+
+// releasePolicy returns the deletion policy to apply when an inventory is released from a cluster.
+// The inventory deletionPolicy takes precedence over the cluster deletionPolicy
+func releasePolicy(c *seederv1alpha1.Cluster, i *seederv1alpha1.Inventory) seederv1alpha1.DeletionPolicy {
+	if i.Spec.DeletionPolicy != "" {
+		return i.Spec.DeletionPolicy
+	}
+
+	if c.Spec.DeletionPolicy != "" {
+		return c.Spec.DeletionPolicy
+	}
+
+	return seederv1alpha1.DeletionPolicyPowerOff
+}
+
 // cleanupClusterDeps will trigger cleanup of nodes and associated infra
 func (r *ClusterReconciler) cleanupClusterDeps(ctx context.Context, c *seederv1alpha1.Cluster) error {
 	// clean up nodes
 	for _, nc := range c.Spec.Nodes {
-		var poolmissing, inventorymissing bool
-		pool := &seederv1alpha1.AddressPool{}
-		err := r.Get(ctx, types.NamespacedName{Namespace: nc.AddressPoolReference.Namespace,
-			Name: nc.AddressPoolReference.Name}, pool)
-		if err != nil {
-			if apierrors.IsNotFound(err) {
-				poolmissing = true
-			} else {
-				return err
-			}
-		}
-
+		var inventorymissing bool
 		i := &seederv1alpha1.Inventory{}
-		err = r.Get(ctx, types.NamespacedName{Namespace: nc.InventoryReference.Namespace,
+		err := r.Get(ctx, types.NamespacedName{Namespace: nc.InventoryReference.Namespace,
 			Name: nc.InventoryReference.Name}, i)
 		if err != nil {
 			if apierrors.IsNotFound(err) {
@@ -448,18 +665,32 @@ func (r *ClusterReconciler) cleanupClusterDeps(ctx context.Context, c *seederv1a
 			}
 		}
 
-		if !poolmissing {
-			delete(pool.Status.AddressAllocation, i.Status.PXEBootInterface.Address)
-			if err := r.Status().Update(ctx, pool); err != nil {
+		// address is released once the node has been wiped
+		policy := releasePolicy(c, i)
+		if inventorymissing || policy != seederv1alpha1.DeletionPolicyWipe {
+			if err := util.ReleaseAddresses(ctx, r.Client, seederv1alpha1.KindInventory, nc.InventoryReference.Name,
+				nc.InventoryReference.Namespace); err != nil {
 				return err
 			}
 		}
 
 		if !inventorymissing {
-			i.Status.PXEBootInterface = seederv1alpha1.PXEBootInterface{}
+			// a cluster with an unknown provisioner can still be deleted
+			p, err := r.provisioner(c)
+			if err != nil {
+				r.Error(err, "skipping cleanup of provisioning objects", "inventory", i.Name)
+			} else if err := p.Teardown(ctx, i); err != nil {
+				return err
+			}
+
+			if policy != seederv1alpha1.DeletionPolicyWipe {
+				i.Status.PXEBootInterface = seederv1alpha1.PXEBootInterface{}
+			}
+			i.Status.ReleasePolicy = policy
 			i.Status.Cluster = seederv1alpha1.ObjectReference{}
 			i.Status.GeneratedPassword = ""
 			i.Status.Conditions = util.RemoveCondition(i.Status.Conditions, seederv1alpha1.InventoryAllocatedToCluster)
+			i.Status.Conditions = util.RemoveCondition(i.Status.Conditions, seederv1alpha1.InventoryProvisioned)
 			i.Status.Conditions = util.CreateOrUpdateCondition(i.Status.Conditions, seederv1alpha1.InventoryFreed, "")
 			err = r.Status().Update(ctx, i)
 			if err != nil {
@@ -469,25 +700,9 @@ func (r *ClusterReconciler) cleanupClusterDeps(ctx context.Context, c *seederv1a
 
 	}
 
-	//cleanup VIP address pool
-	if c.Status.ClusterAddress != "" {
-		var poolNotFound bool
-		pool := &seederv1alpha1.AddressPool{}
-		if err := r.Get(ctx, types.NamespacedName{Namespace: c.Spec.VIPConfig.AddressPoolReference.Namespace,
-			Name: c.Spec.VIPConfig.AddressPoolReference.Name}, pool); err != nil {
-			if apierrors.IsNotFound(err) {
-				poolNotFound = true
-			} else {
-				return err
-			}
-		}
-
-		if !poolNotFound {
-			delete(pool.Status.AddressAllocation, c.Status.ClusterAddress)
-			if err := r.Status().Update(ctx, pool); err != nil {
-				return err
-			}
-		}
+	//cleanup VIP addresses
+	if err := util.ReleaseAddresses(ctx, r.Client, seederv1alpha1.KindCluster, c.Name, c.Namespace); err != nil {
+		return err
 	}
 
 	if controllerutil.ContainsFinalizer(c, seederv1alpha1.ClusterFinalizer) {
@@ -498,6 +713,77 @@ func (r *ClusterReconciler) cleanupClusterDeps(ctx context.Context, c *seederv1a
 	return nil
 }
 
+// claimAddress returns the IPClaim of the owner once an address has been bound to it, and creates the claim
+// if needed
+func (r *ClusterReconciler) claimAddress(ctx context.Context, owner client.Object, name string, pool seederv1alpha1.ObjectReference,
+	staticAddress string, consumer seederv1alpha1.ObjectReferenceWithKind) (*seederv1alpha1.IPClaim, error) {
+	claim := &seederv1alpha1.IPClaim{}
+	err := r.Get(ctx, types.NamespacedName{Namespace: owner.GetNamespace(), Name: name}, claim)
+	if err != nil {
+		if !apierrors.IsNotFound(err) {
+			return nil, err
+		}
+
+		claim = &seederv1alpha1.IPClaim{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      name,
+				Namespace: owner.GetNamespace(),
+			},
+			Spec: seederv1alpha1.IPClaimSpec{
+				PoolReference: pool,
+				StaticAddress: staticAddress,
+				Consumer:      &consumer,
+			},
+		}
+		if err := controllerutil.SetOwnerReference(owner, claim, r.Scheme); err != nil {
+			return nil, err
+		}
+		if err := r.Create(ctx, claim); err != nil {
+			return nil, err
+		}
+	}
+
+	if !claim.DeletionTimestamp.IsZero() {
+		return nil, fmt.Errorf("waiting for address claim %s in namespace %s to be released", claim.Name, claim.Namespace)
+	}
+
+	if claim.Status.Address == "" {
+		return nil, fmt.Errorf("waiting for address claim %s in namespace %s to be bound", claim.Name, claim.Namespace)
+	}
+	return claim, nil
+}
+
+// checkIPv6Pool ensures the pool referenced for IPv6 addresses is an IPv6 address pool
+func (r *ClusterReconciler) checkIPv6Pool(ctx context.Context, ref seederv1alpha1.ObjectReference) error {
+	pool := &seederv1alpha1.AddressPool{}
+	if err := r.Get(ctx, types.NamespacedName{Namespace: ref.Namespace, Name: ref.Name}, pool); err != nil {
+		return fmt.Errorf("error during ipv6 address pool lookup: %v", err)
+	}
+
+	isIPv6, err := util.IsIPv6Pool(pool)
+	if err != nil {
+		return err
+	}
+	if !isIPv6 {
+		return fmt.Errorf("address pool %s in namespace %s is not an ipv6 address pool", pool.Name, pool.Namespace)
+	}
+	return nil
+}
+
+func clusterOwner(c *seederv1alpha1.Cluster) seederv1alpha1.ObjectReferenceWithKind {
+	return seederv1alpha1.ObjectReferenceWithKind{
+		Kind:            seederv1alpha1.KindCluster,
+		ObjectReference: seederv1alpha1.ObjectReference{Name: c.Name, Namespace: c.Namespace},
+	}
+}
+
+func inventoryOwner(ref seederv1alpha1.ObjectReference) seederv1alpha1.ObjectReferenceWithKind {
+	return seederv1alpha1.ObjectReferenceWithKind{
+		Kind:            seederv1alpha1.KindInventory,
+		ObjectReference: ref,
+	}
+}
+
 // markClusterReady will use the cluster endpoint and token to try and generate a kubeconfig for target cluster
 // and will mark cluster running when the kubeconfig can be generated
 func (r *ClusterReconciler) markClusterReady(ctx context.Context, c *seederv1alpha1.Cluster) error {
@@ -520,33 +806,275 @@ func (r *ClusterReconciler) markClusterReady(ctx context.Context, c *seederv1alp
 		return fmt.Errorf("api server is running but waiting for one of the nodes to be available")
 	}
 
+	// the previous install of the node creating the cluster keeps answering on the VIP until it is rebooted,
+	// so a rebuilt cluster is only running once the node has been reinstalled
+	if util.ConditionExists(c.Status.Conditions, seederv1alpha1.ClusterReprovisioning) {
+		if err := r.checkCreateNodeReinstalled(ctx, c); err != nil {
+			return err
+		}
+	}
+
+	// the time to running is only observed for new clusters, and not when a rebuilt cluster is running again
+	firstRun := c.Status.HarvesterVersion == "" && !util.ConditionExists(c.Status.Conditions, seederv1alpha1.ClusterReprovisioning)
+
 	c.Status.Status = seederv1alpha1.ClusterRunning
+	c.Status.HarvesterVersion = c.Spec.HarvesterVersion
+	if err := r.Status().Update(ctx, c); err != nil {
+		return err
+	}
+
+	if firstRun {
+		metrics.ClusterTimeToRunning.Observe(time.Since(c.CreationTimestamp.Time).Seconds())
+	}
+	return nil
+}
+
+// checkCreateNodeReinstalled returns an error until the reprovision BMCJob of the node creating the cluster has
+// completed, and the provisioner reports the new install of the node has completed. For provisioners which do not
+// report completion, the api server answering with the regenerated cluster token shows the node was reinstalled
+func (r *ClusterReconciler) checkCreateNodeReinstalled(ctx context.Context, c *seederv1alpha1.Cluster) error {
+	p, err := r.provisioner(c)
+	if err != nil {
+		return err
+	}
+
+	for _, nc := range c.Spec.Nodes {
+		i := &seederv1alpha1.Inventory{}
+		if err := r.Get(ctx, types.NamespacedName{Namespace: nc.InventoryReference.Namespace,
+			Name: nc.InventoryReference.Name}, i); err != nil {
+			return err
+		}
+
+		if !util.ConditionExists(i.Status.Conditions, seederv1alpha1.HarvesterCreateNode) {
+			continue
+		}
+
+		if util.ConditionExists(i.Status.Conditions, seederv1alpha1.InventoryReprovisioning) ||
+			(p.ReportsCompletion(i) && !util.ConditionExists(i.Status.Conditions, seederv1alpha1.InventoryProvisioned)) {
+			return fmt.Errorf("waiting for node %s creating the cluster to be reinstalled", i.Name)
+		}
+	}
+
+	return nil
+}
+
+// reconcileUpgrade will trigger an upgrade of the target cluster when the HarvesterVersion in the spec differs
+// from the version running in the cluster, and track the progress of the upgrade in the cluster status
+func (r *ClusterReconciler) reconcileUpgrade(ctx context.Context, c *seederv1alpha1.Cluster) error {
+	if c.Status.Status != seederv1alpha1.ClusterRunning {
+		return nil
+	}
+
+	// clusters provisioned before version tracking was introduced are assumed to be running the spec version
+	if c.Status.HarvesterVersion == "" {
+		c.Status.HarvesterVersion = c.Spec.HarvesterVersion
+		return r.Status().Update(ctx, c)
+	}
+
+	if c.Status.UpgradeVersion != "" {
+		// upgrade in progress
+		if !util.ConditionExists(c.Status.Conditions, seederv1alpha1.ClusterUpgradeFailed) {
+			return r.trackUpgrade(ctx, c)
+		}
+		// failed upgrades are not retried until a different version is requested
+		if c.Status.UpgradeVersion == c.Spec.HarvesterVersion {
+			return nil
+		}
+	}
+
+	// reverting the spec to the running version abandons a blocked or failed upgrade
+	if c.Status.HarvesterVersion == c.Spec.HarvesterVersion {
+		if c.Status.UpgradeVersion != "" || util.ConditionExists(c.Status.Conditions, seederv1alpha1.ClusterUpgradeBlocked) ||
+			util.ConditionExists(c.Status.Conditions, seederv1alpha1.ClusterUpgradeFailed) {
+			c.Status.UpgradeVersion = ""
+			c.Status.Conditions = util.RemoveCondition(c.Status.Conditions, seederv1alpha1.ClusterUpgradeBlocked)
+			c.Status.Conditions = util.RemoveCondition(c.Status.Conditions, seederv1alpha1.ClusterUpgradeFailed)
+			c.Status.Conditions = util.RemoveCondition(c.Status.Conditions, seederv1alpha1.ClusterUpgradeSubmitted)
+			return r.Status().Update(ctx, c)
+		}
+		return nil
+	}
+
+	if err := util.CheckUpgradePath(c.Status.HarvesterVersion, c.Spec.HarvesterVersion); err != nil {
+		// no point requeuing until the version in the spec is changed again
+		c.Status.Conditions = util.CreateOrUpdateCondition(c.Status.Conditions, seederv1alpha1.ClusterUpgradeBlocked, err.Error())
+		return r.Status().Update(ctx, c)
+	}
+
+	dynamicClient, err := genDynamicClient(ctx, c)
+	if err != nil {
+		return err
+	}
+
+	arch, err := r.clusterArch(ctx, c)
+	if err != nil {
+		return err
+	}
+
+	v := util.GenerateHarvesterVersion(c.Spec.HarvesterVersion, util.GenerateISOURL(c.Spec.ImageURL, c.Spec.HarvesterVersion, arch))
+	_, err = dynamicClient.Resource(util.HarvesterVersionGVR).Namespace(v.GetNamespace()).Create(ctx, v, metav1.CreateOptions{})
+	if err != nil && !apierrors.IsAlreadyExists(err) {
+		return fmt.Errorf("error creating harvester version %s: %v", v.GetName(), err)
+	}
+
+	u := util.GenerateHarvesterUpgrade(c.Spec.HarvesterVersion)
+	upgrades := dynamicClient.Resource(util.HarvesterUpgradeGVR).Namespace(u.GetNamespace())
+	// a completed upgrade to the version, such as a failed upgrade which was reverted, is replaced
+	existing, err := upgrades.Get(ctx, u.GetName(), metav1.GetOptions{})
+	if err == nil {
+		completed, _, _, err := util.HarvesterUpgradeStatus(existing)
+		if err != nil {
+			return err
+		}
+		if completed {
+			if err := upgrades.Delete(ctx, u.GetName(), metav1.DeleteOptions{}); err != nil && !apierrors.IsNotFound(err) {
+				return err
+			}
+			return fmt.Errorf("waiting for completed harvester upgrade %s to be removed", u.GetName())
+		}
+	} else if !apierrors.IsNotFound(err) {
+		return err
+	}
+
+	_, err = upgrades.Create(ctx, u, metav1.CreateOptions{})
+	if err != nil && !apierrors.IsAlreadyExists(err) {
+		return fmt.Errorf("error creating harvester upgrade %s: %v", u.GetName(), err)
+	}
+
+	c.Status.UpgradeVersion = c.Spec.HarvesterVersion
+	c.Status.Conditions = util.RemoveCondition(c.Status.Conditions, seederv1alpha1.ClusterUpgradeBlocked)
+	c.Status.Conditions = util.RemoveCondition(c.Status.Conditions, seederv1alpha1.ClusterUpgradeCompleted)
+	c.Status.Conditions = util.RemoveCondition(c.Status.Conditions, seederv1alpha1.ClusterUpgradeFailed)
+	c.Status.Conditions = util.CreateOrUpdateCondition(c.Status.Conditions, seederv1alpha1.ClusterUpgradeSubmitted,
+		fmt.Sprintf("upgrade %s from %s to %s submitted", u.GetName(), c.Status.HarvesterVersion, c.Status.UpgradeVersion))
+	return r.Status().Update(ctx, c)
+}
+
+// clusterArch returns the CPU architecture of the cluster, using the architecture of the first node
+func (r *ClusterReconciler) clusterArch(ctx context.Context, c *seederv1alpha1.Cluster) (seederv1alpha1.Architecture, error) {
+	if len(c.Spec.Nodes) == 0 {
+		return seederv1alpha1.ArchitectureAMD64, nil
+	}
+
+	i := &seederv1alpha1.Inventory{}
+	if err := r.Get(ctx, types.NamespacedName{Namespace: c.Spec.Nodes[0].InventoryReference.Namespace,
+		Name: c.Spec.Nodes[0].InventoryReference.Name}, i); err != nil {
+		return "", err
+	}
+	return util.Arch(i), nil
+}
+
+// trackUpgrade will check the upgrade object in the target cluster and update cluster status once the upgrade is done
+func (r *ClusterReconciler) trackUpgrade(ctx context.Context, c *seederv1alpha1.Cluster) error {
+	dynamicClient, err := genDynamicClient(ctx, c)
+	if err != nil {
+		return err
+	}
+
+	u, err := dynamicClient.Resource(util.HarvesterUpgradeGVR).Namespace(util.HarvesterSystemNamespace).Get(ctx,
+		util.HarvesterUpgradeName(c.Status.UpgradeVersion), metav1.GetOptions{})
+	if err != nil {
+		return err
+	}
+
+	completed, failed, message, err := util.HarvesterUpgradeStatus(u)
+	if err != nil {
+		return err
+	}
+
+	if !completed {
+		return fmt.Errorf("waiting for upgrade %s of cluster %s to complete", u.GetName(), c.Name)
+	}
+
+	c.Status.Conditions = util.RemoveCondition(c.Status.Conditions, seederv1alpha1.ClusterUpgradeSubmitted)
+	if failed {
+		c.Status.Conditions = util.CreateOrUpdateCondition(c.Status.Conditions, seederv1alpha1.ClusterUpgradeFailed,
+			fmt.Sprintf("upgrade to %s failed: %s", c.Status.UpgradeVersion, message))
+		return r.Status().Update(ctx, c)
+	}
+
+	c.Status.HarvesterVersion = c.Status.UpgradeVersion
+	c.Status.UpgradeVersion = ""
+	c.Status.Conditions = util.CreateOrUpdateCondition(c.Status.Conditions, seederv1alpha1.ClusterUpgradeCompleted,
+		fmt.Sprintf("upgrade to %s completed", c.Status.HarvesterVersion))
 	return r.Status().Update(ctx, c)
 }
 
 // SetupWithManager sets up the controller with the Manager.
 func (r *ClusterReconciler) SetupWithManager(mgr ctrl.Manager) error {
-	return ctrl.NewControllerManagedBy(mgr).
+	if r.Provisioners == nil {
+		r.Provisioners = provisioner.NewDefaultProvisioners(r.Client, r.Scheme)
+	}
+
+	builder := ctrl.NewControllerManagedBy(mgr).
 		For(&seederv1alpha1.Cluster{}).
-		Watches(&source.Kind{Type: &tinkv1alpha1.Hardware{}}, handler.EnqueueRequestsFromMapFunc(func(a client.Object) []reconcile.Request {
-			var reconRequest []reconcile.Request
-			owners := a.GetOwnerReferences()
-			for _, o := range owners {
-				if o.Kind == "Cluster" && o.APIVersion == "metal.harvesterhci.io/v1alpha1" {
-					reconRequest = append(reconRequest, reconcile.Request{
-						NamespacedName: types.NamespacedName{
-							Namespace: a.GetNamespace(),
-							Name:      o.Name,
-						},
-					})
-				}
+		Watches(&source.Kind{Type: &seederv1alpha1.Inventory{}}, handler.EnqueueRequestsFromMapFunc(func(a client.Object) []reconcile.Request {
+			// only inventory which needs to be reprovisioned is of interest
+			i, ok := a.(*seederv1alpha1.Inventory)
+			if !ok || i.Status.Cluster.Name == "" {
+				return nil
+			}
+			if _, ok := i.Annotations[seederv1alpha1.ReprovisionAnnotation]; !ok {
+				return nil
 			}
-			return reconRequest
-		})).
-		Complete(r)
+			return []reconcile.Request{{
+				NamespacedName: types.NamespacedName{
+					Namespace: i.Status.Cluster.Namespace,
+					Name:      i.Status.Cluster.Name,
+				},
+			}}
+		}))
+
+	// objects created by provisioners are owned by the cluster
+	watched := make(map[string]bool)
+	for _, p := range r.Provisioners {
+		for _, obj := range p.WatchTypes() {
+			kind := fmt.Sprintf("%T", obj)
+			if watched[kind] {
+				continue
+			}
+			watched[kind] = true
+			builder = builder.Watches(&source.Kind{Type: obj}, handler.EnqueueRequestsFromMapFunc(func(a client.Object) []reconcile.Request {
+				var reconRequest []reconcile.Request
+				owners := a.GetOwnerReferences()
+				for _, o := range owners {
+					if o.Kind == "Cluster" && o.APIVersion == "metal.harvesterhci.io/v1alpha1" {
+						reconRequest = append(reconRequest, reconcile.Request{
+							NamespacedName: types.NamespacedName{
+								Namespace: a.GetNamespace(),
+								Name:      o.Name,
+							},
+						})
+					}
+				}
+				return reconRequest
+			}))
+		}
+	}
+
+	return builder.Complete(r)
 }
 
 func genCoreTypedClient(ctx context.Context, c *seederv1alpha1.Cluster) (*typedCore.CoreV1Client, error) {
+	restConfig, err := genRestConfig(ctx, c)
+	if err != nil {
+		return nil, err
+	}
+
+	return typedCore.NewForConfig(restConfig)
+}
+
+func genDynamicClient(ctx context.Context, c *seederv1alpha1.Cluster) (dynamic.Interface, error) {
+	restConfig, err := genRestConfig(ctx, c)
+	if err != nil {
+		return nil, err
+	}
+
+	return dynamic.NewForConfig(restConfig)
+}
+
+// genRestConfig uses the cluster endpoint and token to generate a rest config for the target cluster
+func genRestConfig(ctx context.Context, c *seederv1alpha1.Cluster) (*rest.Config, error) {
 	port, ok := c.Labels[seederv1alpha1.OverrideAPIPortLabel]
 	if !ok {
 		port = seederv1alpha1.DefaultAPIPort
@@ -562,10 +1090,5 @@ func genCoreTypedClient(ctx context.Context, c *seederv1alpha1.Cluster) (*typedC
 		return nil, err
 	}
 
-	restConfig, err := hcClientConfig.ClientConfig()
-	if err != nil {
-		return nil, err
-	}
-
-	return typedCore.NewForConfig(restConfig)
+	return hcClientConfig.ClientConfig()
 }
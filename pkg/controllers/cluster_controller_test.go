@@ -2,8 +2,13 @@ package controllers
 
 import (
 	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
 
 	seederv1alpha1 "github.com/harvester/seeder/pkg/api/v1alpha1"
+	"github.com/harvester/seeder/pkg/provisioner"
 	"github.com/harvester/seeder/pkg/util"
 	. "github.com/onsi/ginkgo/v2"
 	. "github.com/onsi/gomega"
@@ -14,7 +19,11 @@ import (
 	v1 "k8s.io/api/core/v1"
 	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
 	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/dynamic"
+	ctrl "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 )
 
@@ -152,7 +161,7 @@ var _ = Describe("Create cluster tests", func() {
 				return fmt.Errorf("expected inventory to be allocated to cluster %v", tmpInventory.Status)
 			}
 			// is tinkerbell workflow condition present
-			if !util.ConditionExists(tmpInventory.Status.Conditions, seederv1alpha1.TinkWorkflowCreated) {
+			if !util.ConditionExists(tmpInventory.Status.Conditions, seederv1alpha1.TinkHardwareCreated) {
 				return fmt.Errorf("expected tinkerbell hardware condition to exist %v", tmpInventory.Status.Conditions)
 			}
 
@@ -976,3 +985,2499 @@ var _ = Describe("cluster running test", func() {
 		}, "30s", "5s").ShouldNot(HaveOccurred())
 	})
 })
+
+var _ = Describe("cluster upgrade tests", func() {
+	var i *seederv1alpha1.Inventory
+	var c *seederv1alpha1.Cluster
+	var a *seederv1alpha1.AddressPool
+	var creds *v1.Secret
+	var k3sMock *dockertest.Resource
+
+	crdGVR := schema.GroupVersionResource{Group: "apiextensions.k8s.io", Version: "v1", Resource: "customresourcedefinitions"}
+
+	// remoteClient returns a dynamic client for the k3s mock
+	remoteClient := func() (dynamic.Interface, error) {
+		cObj := &seederv1alpha1.Cluster{}
+		if err := k8sClient.Get(ctx, types.NamespacedName{Namespace: c.Namespace, Name: c.Name}, cObj); err != nil {
+			return nil, err
+		}
+		return genDynamicClient(ctx, cObj)
+	}
+
+	// setVersion updates the harvester version in the cluster spec
+	setVersion := func(version string) {
+		Eventually(func() error {
+			cObj := &seederv1alpha1.Cluster{}
+			if err := k8sClient.Get(ctx, types.NamespacedName{Namespace: c.Namespace, Name: c.Name}, cObj); err != nil {
+				return err
+			}
+			cObj.Spec.HarvesterVersion = version
+			return k8sClient.Update(ctx, cObj)
+		}, "30s", "5s").ShouldNot(HaveOccurred())
+	}
+
+	// setUpgradeCompleted sets the Completed condition of the upgrade object in the k3s mock
+	setUpgradeCompleted := func(version, status, message string) {
+		Eventually(func() error {
+			dynamicClient, err := remoteClient()
+			if err != nil {
+				return err
+			}
+
+			upgrades := dynamicClient.Resource(util.HarvesterUpgradeGVR).Namespace(util.HarvesterSystemNamespace)
+			u, err := upgrades.Get(ctx, util.HarvesterUpgradeName(version), metav1.GetOptions{})
+			if err != nil {
+				return err
+			}
+
+			u.Object["status"] = map[string]interface{}{
+				"conditions": []interface{}{
+					map[string]interface{}{
+						"type":    "Completed",
+						"status":  status,
+						"message": message,
+					},
+				},
+			}
+			_, err = upgrades.Update(ctx, u, metav1.UpdateOptions{})
+			return err
+		}, "30s", "5s").ShouldNot(HaveOccurred())
+	}
+
+	// checkStatus waits for the cluster status to match
+	checkStatus := func(check func(*seederv1alpha1.Cluster) error) {
+		Eventually(func() error {
+			cObj := &seederv1alpha1.Cluster{}
+			if err := k8sClient.Get(ctx, types.NamespacedName{Namespace: c.Namespace, Name: c.Name}, cObj); err != nil {
+				return err
+			}
+			return check(cObj)
+		}, "120s", "5s").ShouldNot(HaveOccurred())
+	}
+
+	BeforeEach(func() {
+		a = &seederv1alpha1.AddressPool{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "upgrade-test",
+				Namespace: "default",
+			},
+			Spec: seederv1alpha1.AddressSpec{
+				CIDR:    "127.0.0.1/8",
+				Gateway: "127.0.0.1",
+			},
+		}
+
+		i = &seederv1alpha1.Inventory{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "upgrade-node",
+				Namespace: "default",
+			},
+			Spec: seederv1alpha1.InventorySpec{
+				PrimaryDisk:                   "/dev/sda",
+				ManagementInterfaceMacAddress: "xx:xx:xx:xx:xx",
+				BaseboardManagementSpec: rufio.BaseboardManagementSpec{
+					Connection: rufio.Connection{
+						Host:        "localhost",
+						Port:        623,
+						InsecureTLS: true,
+						AuthSecretRef: v1.SecretReference{
+							Name:      "upgrade-node",
+							Namespace: "default",
+						},
+					},
+				},
+			},
+		}
+
+		creds = &v1.Secret{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "upgrade-node",
+				Namespace: "default",
+			},
+			StringData: map[string]string{
+				"username": "root",
+				"password": "calvin",
+			},
+		}
+
+		c = &seederv1alpha1.Cluster{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "upgrade-cluster",
+				Namespace: "default",
+			},
+			Spec: seederv1alpha1.ClusterSpec{
+				HarvesterVersion: "v1.0.2",
+				ImageURL:         "http://localhost/iso",
+				Nodes: []seederv1alpha1.NodeConfig{
+					{
+						InventoryReference: seederv1alpha1.ObjectReference{
+							Name:      "upgrade-node",
+							Namespace: "default",
+						},
+						AddressPoolReference: seederv1alpha1.ObjectReference{
+							Name:      "upgrade-test",
+							Namespace: "default",
+						},
+					},
+				},
+				VIPConfig: seederv1alpha1.VIPConfig{
+					AddressPoolReference: seederv1alpha1.ObjectReference{
+						Name:      "upgrade-test",
+						Namespace: "default",
+					},
+				},
+				ClusterConfig: seederv1alpha1.ClusterConfig{
+					SSHKeys: []string{
+						"abc",
+						"def",
+					},
+					ConfigURL: "localhost:30300/config.yaml",
+				},
+			},
+		}
+
+		Eventually(func() error {
+			return k8sClient.Create(ctx, a)
+		}, "30s", "5s").ShouldNot(HaveOccurred())
+
+		Eventually(func() error {
+			return k8sClient.Create(ctx, creds)
+		}, "30s", "5s").ShouldNot(HaveOccurred())
+
+		Eventually(func() error {
+			return k8sClient.Create(ctx, i)
+		}, "30s", "5s").ShouldNot(HaveOccurred())
+
+		Eventually(func() error {
+			return k8sClient.Create(ctx, c)
+		}, "30s", "5s").ShouldNot(HaveOccurred())
+
+		Eventually(func() error {
+			cObj := &seederv1alpha1.Cluster{}
+			err := k8sClient.Get(ctx, types.NamespacedName{Namespace: c.Namespace, Name: c.Name}, cObj)
+			if err != nil {
+				return err
+			}
+
+			if cObj.Status.ClusterToken == "" {
+				return fmt.Errorf("waiting for cluster token to be generated")
+			}
+
+			k3sMock, err = pool.RunWithOptions(&dockertest.RunOptions{
+				Name:       "k3s-mock",
+				Repository: "rancher/k3s",
+				Tag:        "v1.24.2-k3s1",
+				Cmd:        []string{"server", "--cluster-init"},
+				Env: []string{
+					fmt.Sprintf("K3S_TOKEN=%s", cObj.Status.ClusterToken),
+				},
+				Mounts: []string{
+					"tmpfs:/run",
+					"tmpfs:/var/run",
+				},
+				Privileged: true,
+				ExposedPorts: []string{
+					"6443/tcp",
+				},
+			}, func(config *docker.HostConfig) {
+				config.RestartPolicy = docker.RestartPolicy{
+					Name: "no",
+				}
+			})
+			if err != nil {
+				return err
+			}
+
+			if cObj.Labels == nil {
+				cObj.Labels = make(map[string]string)
+			}
+
+			// since mock node is k3s, need to change prefix from rke2 to k3s
+			seederv1alpha1.DefaultAPIPrefix = "k3s"
+			cObj.Labels[seederv1alpha1.OverrideAPIPortLabel] = k3sMock.GetPort("6443/tcp")
+			return k8sClient.Update(ctx, cObj)
+		}, "30s", "5s").ShouldNot(HaveOccurred())
+
+		checkStatus(func(cObj *seederv1alpha1.Cluster) error {
+			if cObj.Status.Status != seederv1alpha1.ClusterRunning || cObj.Status.HarvesterVersion != "v1.0.2" {
+				return fmt.Errorf("waiting for cluster to be running v1.0.2. current status is %s %s", cObj.Status.Status, cObj.Status.HarvesterVersion)
+			}
+			return nil
+		})
+
+		// k3s mock does not run harvester, so the harvester version and upgrade crds are added to the mock
+		Eventually(func() error {
+			dynamicClient, err := remoteClient()
+			if err != nil {
+				return err
+			}
+
+			for _, kind := range []string{"Version", "Upgrade"} {
+				plural := strings.ToLower(kind) + "s"
+				crd := &unstructured.Unstructured{Object: map[string]interface{}{
+					"apiVersion": "apiextensions.k8s.io/v1",
+					"kind":       "CustomResourceDefinition",
+					"metadata": map[string]interface{}{
+						"name": fmt.Sprintf("%s.%s", plural, util.HarvesterUpgradeGVR.Group),
+					},
+					"spec": map[string]interface{}{
+						"group": util.HarvesterUpgradeGVR.Group,
+						"scope": "Namespaced",
+						"names": map[string]interface{}{
+							"kind":     kind,
+							"plural":   plural,
+							"singular": strings.ToLower(kind),
+						},
+						"versions": []interface{}{
+							map[string]interface{}{
+								"name":    util.HarvesterUpgradeGVR.Version,
+								"served":  true,
+								"storage": true,
+								"schema": map[string]interface{}{
+									"openAPIV3Schema": map[string]interface{}{
+										"type":                                 "object",
+										"x-kubernetes-preserve-unknown-fields": true,
+									},
+								},
+							},
+						},
+					},
+				}}
+				_, err = dynamicClient.Resource(crdGVR).Create(ctx, crd, metav1.CreateOptions{})
+				if err != nil && !apierrors.IsAlreadyExists(err) {
+					return err
+				}
+			}
+
+			ns := &unstructured.Unstructured{Object: map[string]interface{}{
+				"apiVersion": "v1",
+				"kind":       "Namespace",
+				"metadata": map[string]interface{}{
+					"name": util.HarvesterSystemNamespace,
+				},
+			}}
+			_, err = dynamicClient.Resource(v1.SchemeGroupVersion.WithResource("namespaces")).Create(ctx, ns, metav1.CreateOptions{})
+			if err != nil && !apierrors.IsAlreadyExists(err) {
+				return err
+			}
+
+			// wait for crds to be served
+			_, err = dynamicClient.Resource(util.HarvesterUpgradeGVR).Namespace(util.HarvesterSystemNamespace).List(ctx, metav1.ListOptions{})
+			return err
+		}, "60s", "5s").ShouldNot(HaveOccurred())
+	})
+
+	It("upgrade cluster and clear upgrade conditions when the version is reverted", func() {
+		// downgrades are blocked
+		setVersion("v1.0.1")
+		checkStatus(func(cObj *seederv1alpha1.Cluster) error {
+			if !util.ConditionExists(cObj.Status.Conditions, seederv1alpha1.ClusterUpgradeBlocked) {
+				return fmt.Errorf("waiting for downgrade to be blocked %v", cObj.Status.Conditions)
+			}
+			return nil
+		})
+
+		setVersion("v1.1.0")
+		checkStatus(func(cObj *seederv1alpha1.Cluster) error {
+			if cObj.Status.UpgradeVersion != "v1.1.0" || !util.ConditionExists(cObj.Status.Conditions, seederv1alpha1.ClusterUpgradeSubmitted) {
+				return fmt.Errorf("waiting for upgrade to be submitted %v", cObj.Status.Conditions)
+			}
+
+			if util.ConditionExists(cObj.Status.Conditions, seederv1alpha1.ClusterUpgradeBlocked) {
+				return fmt.Errorf("expected blocked condition to be removed")
+			}
+			return nil
+		})
+
+		dynamicClient, err := remoteClient()
+		Expect(err).ToNot(HaveOccurred())
+		version, err := dynamicClient.Resource(util.HarvesterVersionGVR).Namespace(util.HarvesterSystemNamespace).Get(ctx, "v1.1.0", metav1.GetOptions{})
+		Expect(err).ToNot(HaveOccurred())
+		isoURL, _, err := unstructured.NestedString(version.Object, "spec", "isoURL")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(isoURL).To(Equal("http://localhost/iso/v1.1.0/harvester-v1.1.0-amd64.iso"))
+
+		setUpgradeCompleted("v1.1.0", "False", "node upgrade failed")
+		checkStatus(func(cObj *seederv1alpha1.Cluster) error {
+			if !util.ConditionExists(cObj.Status.Conditions, seederv1alpha1.ClusterUpgradeFailed) {
+				return fmt.Errorf("waiting for upgrade to fail %v", cObj.Status.Conditions)
+			}
+
+			if cObj.Status.HarvesterVersion != "v1.0.2" {
+				return fmt.Errorf("expected failed upgrade to not change the running version")
+			}
+			return nil
+		})
+
+		// reverting the version abandons the failed upgrade
+		setVersion("v1.0.2")
+		checkStatus(func(cObj *seederv1alpha1.Cluster) error {
+			if cObj.Status.UpgradeVersion != "" {
+				return fmt.Errorf("waiting for upgrade version to be cleared")
+			}
+
+			for _, v := range []seederv1alpha1.ConditionType{seederv1alpha1.ClusterUpgradeBlocked,
+				seederv1alpha1.ClusterUpgradeSubmitted, seederv1alpha1.ClusterUpgradeFailed} {
+				if util.ConditionExists(cObj.Status.Conditions, v) {
+					return fmt.Errorf("waiting for condition %s to be removed", v)
+				}
+			}
+			return nil
+		})
+
+		setVersion("v1.1.0")
+		checkStatus(func(cObj *seederv1alpha1.Cluster) error {
+			if cObj.Status.UpgradeVersion != "v1.1.0" || !util.ConditionExists(cObj.Status.Conditions, seederv1alpha1.ClusterUpgradeSubmitted) {
+				return fmt.Errorf("waiting for upgrade to be submitted again %v", cObj.Status.Conditions)
+			}
+			return nil
+		})
+
+		setUpgradeCompleted("v1.1.0", "True", "")
+		checkStatus(func(cObj *seederv1alpha1.Cluster) error {
+			if cObj.Status.HarvesterVersion != "v1.1.0" || cObj.Status.UpgradeVersion != "" {
+				return fmt.Errorf("waiting for upgrade to complete. running version is %s", cObj.Status.HarvesterVersion)
+			}
+
+			if !util.ConditionExists(cObj.Status.Conditions, seederv1alpha1.ClusterUpgradeCompleted) ||
+				util.ConditionExists(cObj.Status.Conditions, seederv1alpha1.ClusterUpgradeSubmitted) {
+				return fmt.Errorf("waiting for upgrade conditions to be updated %v", cObj.Status.Conditions)
+			}
+			return nil
+		})
+	})
+
+	AfterEach(func() {
+		Eventually(func() error {
+			return k8sClient.Delete(ctx, c)
+		}, "30s", "5s").ShouldNot(HaveOccurred())
+
+		Eventually(func() error {
+			return k8sClient.Delete(ctx, i)
+		}, "30s", "5s").ShouldNot(HaveOccurred())
+
+		Eventually(func() error {
+			return k8sClient.Delete(ctx, creds)
+		}, "30s", "5s").ShouldNot(HaveOccurred())
+
+		Eventually(func() error {
+			return k8sClient.Delete(ctx, a)
+		}, "30s", "5s").ShouldNot(HaveOccurred())
+
+		Eventually(func() error {
+			cObj := &seederv1alpha1.Cluster{}
+			err := k8sClient.Get(ctx, types.NamespacedName{Namespace: c.Namespace, Name: c.Name}, cObj)
+			if err != nil {
+				if apierrors.IsNotFound(err) {
+					return nil
+				}
+				return err
+			}
+
+			return fmt.Errorf("waiting for cluster finalizers to finish")
+		}, "30s", "5s").ShouldNot(HaveOccurred())
+
+		Eventually(func() error {
+			return pool.Purge(k3sMock)
+		}, "30s", "5s").ShouldNot(HaveOccurred())
+	})
+})
+
+var _ = Describe("reprovision inventory tests", func() {
+	var i *seederv1alpha1.Inventory
+	var c *seederv1alpha1.Cluster
+	var a *seederv1alpha1.AddressPool
+	var creds *v1.Secret
+	BeforeEach(func() {
+		a = &seederv1alpha1.AddressPool{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "reprovision-test",
+				Namespace: "default",
+			},
+			Spec: seederv1alpha1.AddressSpec{
+				CIDR:    "192.168.1.1/29",
+				Gateway: "192.168.1.7",
+			},
+		}
+
+		i = &seederv1alpha1.Inventory{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "reprovision-node",
+				Namespace: "default",
+			},
+			Spec: seederv1alpha1.InventorySpec{
+				PrimaryDisk:                   "/dev/sda",
+				ManagementInterfaceMacAddress: "xx:xx:xx:xx:xx",
+				BaseboardManagementSpec: rufio.BaseboardManagementSpec{
+					Connection: rufio.Connection{
+						Host:        "localhost",
+						Port:        623,
+						InsecureTLS: true,
+						AuthSecretRef: v1.SecretReference{
+							Name:      "reprovision-node",
+							Namespace: "default",
+						},
+					},
+				},
+			},
+		}
+
+		creds = &v1.Secret{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "reprovision-node",
+				Namespace: "default",
+			},
+			StringData: map[string]string{
+				"username": "admin",
+				"password": "password",
+			},
+		}
+
+		c = &seederv1alpha1.Cluster{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "reprovision-cluster",
+				Namespace: "default",
+			},
+			Spec: seederv1alpha1.ClusterSpec{
+				HarvesterVersion: "harvester_1_0_2",
+				Nodes: []seederv1alpha1.NodeConfig{
+					{
+						InventoryReference: seederv1alpha1.ObjectReference{
+							Name:      "reprovision-node",
+							Namespace: "default",
+						},
+						AddressPoolReference: seederv1alpha1.ObjectReference{
+							Name:      "reprovision-test",
+							Namespace: "default",
+						},
+					},
+				},
+				VIPConfig: seederv1alpha1.VIPConfig{
+					AddressPoolReference: seederv1alpha1.ObjectReference{
+						Name:      "reprovision-test",
+						Namespace: "default",
+					},
+				},
+				ClusterConfig: seederv1alpha1.ClusterConfig{
+					SSHKeys: []string{
+						"abc",
+						"def",
+					},
+					ConfigURL: "localhost:30300/config.yaml",
+				},
+			},
+		}
+
+		Eventually(func() error {
+			return k8sClient.Create(ctx, a)
+		}, "30s", "5s").ShouldNot(HaveOccurred())
+
+		Eventually(func() error {
+			return k8sClient.Create(ctx, creds)
+		}, "30s", "5s").ShouldNot(HaveOccurred())
+
+		Eventually(func() error {
+			return k8sClient.Create(ctx, i)
+		}, "30s", "5s").ShouldNot(HaveOccurred())
+
+		Eventually(func() error {
+			return k8sClient.Create(ctx, c)
+		}, "30s", "5s").ShouldNot(HaveOccurred())
+	})
+
+	It("reprovision inventory in cluster controller workflow", func() {
+		var password string
+		Eventually(func() error {
+			iObj := &seederv1alpha1.Inventory{}
+			if err := k8sClient.Get(ctx, types.NamespacedName{Namespace: i.Namespace, Name: i.Name}, iObj); err != nil {
+				return err
+			}
+
+			if !util.ConditionExists(iObj.Status.Conditions, seederv1alpha1.BMCJobComplete) {
+				return fmt.Errorf("waiting for initial bmcjob to complete %v", iObj.Status.Conditions)
+			}
+
+			password = iObj.Status.GeneratedPassword
+			if iObj.Annotations == nil {
+				iObj.Annotations = make(map[string]string)
+			}
+			iObj.Annotations[seederv1alpha1.ReprovisionAnnotation] = "true"
+			return k8sClient.Update(ctx, iObj)
+		}, "60s", "5s").ShouldNot(HaveOccurred())
+
+		Eventually(func() error {
+			iObj := &seederv1alpha1.Inventory{}
+			if err := k8sClient.Get(ctx, types.NamespacedName{Namespace: i.Namespace, Name: i.Name}, iObj); err != nil {
+				return err
+			}
+
+			if _, ok := iObj.Annotations[seederv1alpha1.ReprovisionAnnotation]; ok {
+				return fmt.Errorf("waiting for reprovision annotation to be removed")
+			}
+
+			if iObj.Status.GeneratedPassword == password {
+				return fmt.Errorf("expected generated password to be regenerated")
+			}
+
+			if util.ConditionExists(iObj.Status.Conditions, seederv1alpha1.InventoryReprovisioning) ||
+				!util.ConditionExists(iObj.Status.Conditions, seederv1alpha1.BMCJobComplete) {
+				return fmt.Errorf("waiting for reprovision bmcjob to complete %v", iObj.Status.Conditions)
+			}
+			return nil
+		}, "60s", "5s").ShouldNot(HaveOccurred())
+	})
+
+	AfterEach(func() {
+		Eventually(func() error {
+			return k8sClient.Delete(ctx, c)
+		}, "30s", "5s").ShouldNot(HaveOccurred())
+
+		Eventually(func() error {
+			return k8sClient.Delete(ctx, i)
+		}, "30s", "5s").ShouldNot(HaveOccurred())
+
+		Eventually(func() error {
+			return k8sClient.Delete(ctx, creds)
+		}, "30s", "5s").ShouldNot(HaveOccurred())
+
+		Eventually(func() error {
+			return k8sClient.Delete(ctx, a)
+		}, "30s", "5s").ShouldNot(HaveOccurred())
+
+		Eventually(func() error {
+			cObj := &seederv1alpha1.Cluster{}
+			err := k8sClient.Get(ctx, types.NamespacedName{Namespace: c.Namespace, Name: c.Name}, cObj)
+			if err != nil {
+				if apierrors.IsNotFound(err) {
+					return nil
+				}
+				return err
+			}
+
+			return fmt.Errorf("waiting for cluster finalizers to finish")
+		}, "30s", "5s").ShouldNot(HaveOccurred())
+	})
+})
+
+var _ = Describe("cluster rebuild tests", func() {
+	var i1, i2 *seederv1alpha1.Inventory
+	var c *seederv1alpha1.Cluster
+	var a *seederv1alpha1.AddressPool
+	var creds *v1.Secret
+	var k3sMock *dockertest.Resource
+
+	// runK3sMock starts a k3s mock for the cluster token, and points the cluster api port at the mock
+	runK3sMock := func() error {
+		cObj := &seederv1alpha1.Cluster{}
+		err := k8sClient.Get(ctx, types.NamespacedName{Namespace: c.Namespace, Name: c.Name}, cObj)
+		if err != nil {
+			return err
+		}
+
+		if cObj.Status.ClusterToken == "" {
+			return fmt.Errorf("waiting for cluster token to be generated")
+		}
+
+		if k3sMock == nil {
+			k3sMock, err = pool.RunWithOptions(&dockertest.RunOptions{
+				Name:       "k3s-mock",
+				Repository: "rancher/k3s",
+				Tag:        "v1.24.2-k3s1",
+				Cmd:        []string{"server", "--cluster-init"},
+				Env: []string{
+					fmt.Sprintf("K3S_TOKEN=%s", cObj.Status.ClusterToken),
+				},
+				Mounts: []string{
+					"tmpfs:/run",
+					"tmpfs:/var/run",
+				},
+				Privileged: true,
+				ExposedPorts: []string{
+					"6443/tcp",
+				},
+			}, func(config *docker.HostConfig) {
+				config.RestartPolicy = docker.RestartPolicy{
+					Name: "no",
+				}
+			})
+			if err != nil {
+				return err
+			}
+		}
+
+		if cObj.Labels == nil {
+			cObj.Labels = make(map[string]string)
+		}
+
+		// since mock node is k3s, need to change prefix from rke2 to k3s
+		seederv1alpha1.DefaultAPIPrefix = "k3s"
+		cObj.Labels[seederv1alpha1.OverrideAPIPortLabel] = k3sMock.GetPort("6443/tcp")
+		return k8sClient.Update(ctx, cObj)
+	}
+
+	BeforeEach(func() {
+		a = &seederv1alpha1.AddressPool{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "rebuild-test",
+				Namespace: "default",
+			},
+			Spec: seederv1alpha1.AddressSpec{
+				CIDR:    "127.0.0.1/8",
+				Gateway: "127.0.0.1",
+			},
+		}
+
+		i1 = &seederv1alpha1.Inventory{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "rebuild-node-1",
+				Namespace: "default",
+			},
+			Spec: seederv1alpha1.InventorySpec{
+				PrimaryDisk:                   "/dev/sda",
+				ManagementInterfaceMacAddress: "xx:xx:xx:xx:xx",
+				BaseboardManagementSpec: rufio.BaseboardManagementSpec{
+					Connection: rufio.Connection{
+						Host:        "localhost",
+						Port:        623,
+						InsecureTLS: true,
+						AuthSecretRef: v1.SecretReference{
+							Name:      "rebuild-node",
+							Namespace: "default",
+						},
+					},
+				},
+			},
+		}
+
+		i2 = i1.DeepCopy()
+		i2.Name = "rebuild-node-2"
+
+		creds = &v1.Secret{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "rebuild-node",
+				Namespace: "default",
+			},
+			StringData: map[string]string{
+				"username": "admin",
+				"password": "password",
+			},
+		}
+
+		c = &seederv1alpha1.Cluster{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "rebuild-cluster",
+				Namespace: "default",
+			},
+			Spec: seederv1alpha1.ClusterSpec{
+				HarvesterVersion: "harvester_1_0_2",
+				Provisioner:      provisioner.FakeProvisioner,
+				Nodes: []seederv1alpha1.NodeConfig{
+					{
+						InventoryReference: seederv1alpha1.ObjectReference{
+							Name:      "rebuild-node-1",
+							Namespace: "default",
+						},
+						AddressPoolReference: seederv1alpha1.ObjectReference{
+							Name:      "rebuild-test",
+							Namespace: "default",
+						},
+					},
+					{
+						InventoryReference: seederv1alpha1.ObjectReference{
+							Name:      "rebuild-node-2",
+							Namespace: "default",
+						},
+						AddressPoolReference: seederv1alpha1.ObjectReference{
+							Name:      "rebuild-test",
+							Namespace: "default",
+						},
+					},
+				},
+				VIPConfig: seederv1alpha1.VIPConfig{
+					AddressPoolReference: seederv1alpha1.ObjectReference{
+						Name:      "rebuild-test",
+						Namespace: "default",
+					},
+				},
+				ClusterConfig: seederv1alpha1.ClusterConfig{
+					SSHKeys: []string{
+						"abc",
+						"def",
+					},
+					ConfigURL: "localhost:30300/config.yaml",
+				},
+			},
+		}
+
+		Eventually(func() error {
+			return k8sClient.Create(ctx, a)
+		}, "30s", "5s").ShouldNot(HaveOccurred())
+
+		Eventually(func() error {
+			return k8sClient.Create(ctx, creds)
+		}, "30s", "5s").ShouldNot(HaveOccurred())
+
+		Eventually(func() error {
+			return k8sClient.Create(ctx, i1)
+		}, "30s", "5s").ShouldNot(HaveOccurred())
+
+		Eventually(func() error {
+			return k8sClient.Create(ctx, i2)
+		}, "30s", "5s").ShouldNot(HaveOccurred())
+
+		Eventually(func() error {
+			return k8sClient.Create(ctx, c)
+		}, "30s", "5s").ShouldNot(HaveOccurred())
+
+		Eventually(runK3sMock, "30s", "5s").ShouldNot(HaveOccurred())
+	})
+
+	It("reinstall remaining nodes only once the node creating the cluster is reinstalled", func() {
+		var token string
+		Eventually(func() error {
+			cObj := &seederv1alpha1.Cluster{}
+			if err := k8sClient.Get(ctx, types.NamespacedName{Namespace: c.Namespace, Name: c.Name}, cObj); err != nil {
+				return err
+			}
+
+			if cObj.Status.Status != seederv1alpha1.ClusterRunning {
+				return fmt.Errorf("waiting for cluster to be running. current status is %s", cObj.Status.Status)
+			}
+
+			token = cObj.Status.ClusterToken
+			if cObj.Annotations == nil {
+				cObj.Annotations = make(map[string]string)
+			}
+			cObj.Annotations[seederv1alpha1.ReprovisionAnnotation] = "true"
+			return k8sClient.Update(ctx, cObj)
+		}, "60s", "5s").ShouldNot(HaveOccurred())
+
+		var createNode, joinNode *seederv1alpha1.Inventory
+		Eventually(func() error {
+			for _, v := range []*seederv1alpha1.Inventory{i1, i2} {
+				iObj := &seederv1alpha1.Inventory{}
+				if err := k8sClient.Get(ctx, types.NamespacedName{Namespace: v.Namespace, Name: v.Name}, iObj); err != nil {
+					return err
+				}
+
+				if util.ConditionExists(iObj.Status.Conditions, seederv1alpha1.HarvesterCreateNode) {
+					createNode = iObj
+				} else {
+					joinNode = iObj
+				}
+			}
+
+			if createNode == nil || joinNode == nil {
+				return fmt.Errorf("waiting to identify the node creating the cluster")
+			}
+
+			if !util.ConditionExists(joinNode.Status.Conditions, seederv1alpha1.InventoryReprovisionPending) {
+				return fmt.Errorf("waiting for node %s to be marked for reprovisioning", joinNode.Name)
+			}
+			return nil
+		}, "60s", "5s").ShouldNot(HaveOccurred())
+
+		// the api of the rebuilt cluster is available before the node creating it has been reinstalled
+		Eventually(func() error {
+			cObj := &seederv1alpha1.Cluster{}
+			if err := k8sClient.Get(ctx, types.NamespacedName{Namespace: c.Namespace, Name: c.Name}, cObj); err != nil {
+				return err
+			}
+
+			if cObj.Status.ClusterToken == token {
+				return fmt.Errorf("waiting for cluster token to be regenerated")
+			}
+			return pool.Purge(k3sMock)
+		}, "30s", "5s").ShouldNot(HaveOccurred())
+
+		k3sMock = nil
+		Eventually(runK3sMock, "60s", "5s").ShouldNot(HaveOccurred())
+
+		Consistently(func() error {
+			cObj := &seederv1alpha1.Cluster{}
+			if err := k8sClient.Get(ctx, types.NamespacedName{Namespace: c.Namespace, Name: c.Name}, cObj); err != nil {
+				return err
+			}
+
+			if cObj.Status.Status == seederv1alpha1.ClusterRunning {
+				return fmt.Errorf("expected cluster to not be running until the node creating it is reinstalled")
+			}
+
+			iObj := &seederv1alpha1.Inventory{}
+			if err := k8sClient.Get(ctx, types.NamespacedName{Namespace: joinNode.Namespace, Name: joinNode.Name}, iObj); err != nil {
+				return err
+			}
+
+			if !util.ConditionExists(iObj.Status.Conditions, seederv1alpha1.InventoryReprovisionPending) {
+				return fmt.Errorf("expected node %s to wait for the node creating the cluster to be reinstalled", iObj.Name)
+			}
+			return nil
+		}, "30s", "5s").ShouldNot(HaveOccurred())
+
+		fakeProvisioner.SetProgress(createNode, provisioner.Progress{Phase: provisioner.PhaseCompleted})
+
+		Eventually(func() error {
+			cObj := &seederv1alpha1.Cluster{}
+			if err := k8sClient.Get(ctx, types.NamespacedName{Namespace: c.Namespace, Name: c.Name}, cObj); err != nil {
+				return err
+			}
+
+			if cObj.Status.Status != seederv1alpha1.ClusterRunning {
+				return fmt.Errorf("waiting for cluster to be running. current status is %s", cObj.Status.Status)
+			}
+
+			if util.ConditionExists(cObj.Status.Conditions, seederv1alpha1.ClusterReprovisioning) {
+				return fmt.Errorf("waiting for cluster rebuild to complete")
+			}
+
+			iObj := &seederv1alpha1.Inventory{}
+			if err := k8sClient.Get(ctx, types.NamespacedName{Namespace: joinNode.Namespace, Name: joinNode.Name}, iObj); err != nil {
+				return err
+			}
+
+			if util.ConditionExists(iObj.Status.Conditions, seederv1alpha1.InventoryReprovisionPending) {
+				return fmt.Errorf("waiting for node %s to be reinstalled", iObj.Name)
+			}
+			return nil
+		}, "120s", "5s").ShouldNot(HaveOccurred())
+	})
+
+	It("rebuild the cluster from the first node after the node creating the cluster was reprovisioned", func() {
+		// reprovisioning the node creating the cluster on its own makes it rejoin the running cluster
+		Eventually(func() error {
+			cObj := &seederv1alpha1.Cluster{}
+			if err := k8sClient.Get(ctx, types.NamespacedName{Namespace: c.Namespace, Name: c.Name}, cObj); err != nil {
+				return err
+			}
+
+			if cObj.Status.Status != seederv1alpha1.ClusterRunning {
+				return fmt.Errorf("waiting for cluster to be running. current status is %s", cObj.Status.Status)
+			}
+
+			iObj := &seederv1alpha1.Inventory{}
+			if err := k8sClient.Get(ctx, types.NamespacedName{Namespace: i1.Namespace, Name: i1.Name}, iObj); err != nil {
+				return err
+			}
+
+			if !util.ConditionExists(iObj.Status.Conditions, seederv1alpha1.HarvesterCreateNode) {
+				return fmt.Errorf("expected node %s to create the cluster", iObj.Name)
+			}
+
+			if iObj.Annotations == nil {
+				iObj.Annotations = make(map[string]string)
+			}
+			iObj.Annotations[seederv1alpha1.ReprovisionAnnotation] = "true"
+			return k8sClient.Update(ctx, iObj)
+		}, "60s", "5s").ShouldNot(HaveOccurred())
+
+		var password string
+		Eventually(func() error {
+			iObj := &seederv1alpha1.Inventory{}
+			if err := k8sClient.Get(ctx, types.NamespacedName{Namespace: i1.Namespace, Name: i1.Name}, iObj); err != nil {
+				return err
+			}
+
+			if util.ConditionExists(iObj.Status.Conditions, seederv1alpha1.HarvesterCreateNode) ||
+				!util.ConditionExists(iObj.Status.Conditions, seederv1alpha1.HarvesterJoinNode) {
+				return fmt.Errorf("waiting for node %s to rejoin the cluster", iObj.Name)
+			}
+
+			password = iObj.Status.GeneratedPassword
+			return nil
+		}, "60s", "5s").ShouldNot(HaveOccurred())
+
+		var token string
+		Eventually(func() error {
+			cObj := &seederv1alpha1.Cluster{}
+			if err := k8sClient.Get(ctx, types.NamespacedName{Namespace: c.Namespace, Name: c.Name}, cObj); err != nil {
+				return err
+			}
+
+			if cObj.Status.Status != seederv1alpha1.ClusterRunning {
+				return fmt.Errorf("waiting for cluster to be running. current status is %s", cObj.Status.Status)
+			}
+
+			token = cObj.Status.ClusterToken
+			if cObj.Annotations == nil {
+				cObj.Annotations = make(map[string]string)
+			}
+			cObj.Annotations[seederv1alpha1.ReprovisionAnnotation] = "true"
+			return k8sClient.Update(ctx, cObj)
+		}, "60s", "5s").ShouldNot(HaveOccurred())
+
+		// the first node creates the rebuilt cluster, and is reinstalled straight away
+		Eventually(func() error {
+			cObj := &seederv1alpha1.Cluster{}
+			if err := k8sClient.Get(ctx, types.NamespacedName{Namespace: c.Namespace, Name: c.Name}, cObj); err != nil {
+				return err
+			}
+
+			if cObj.Status.ClusterToken == token {
+				return fmt.Errorf("waiting for cluster token to be regenerated")
+			}
+
+			createNode := &seederv1alpha1.Inventory{}
+			if err := k8sClient.Get(ctx, types.NamespacedName{Namespace: i1.Namespace, Name: i1.Name}, createNode); err != nil {
+				return err
+			}
+
+			if !util.ConditionExists(createNode.Status.Conditions, seederv1alpha1.HarvesterCreateNode) ||
+				util.ConditionExists(createNode.Status.Conditions, seederv1alpha1.HarvesterJoinNode) {
+				return fmt.Errorf("waiting for node %s to create the rebuilt cluster", createNode.Name)
+			}
+
+			if createNode.Status.GeneratedPassword == password {
+				return fmt.Errorf("waiting for node %s to be reinstalled", createNode.Name)
+			}
+
+			joinNode := &seederv1alpha1.Inventory{}
+			if err := k8sClient.Get(ctx, types.NamespacedName{Namespace: i2.Namespace, Name: i2.Name}, joinNode); err != nil {
+				return err
+			}
+
+			if util.ConditionExists(joinNode.Status.Conditions, seederv1alpha1.HarvesterCreateNode) ||
+				!util.ConditionExists(joinNode.Status.Conditions, seederv1alpha1.InventoryReprovisionPending) {
+				return fmt.Errorf("waiting for node %s to be marked for reprovisioning", joinNode.Name)
+			}
+			return pool.Purge(k3sMock)
+		}, "60s", "5s").ShouldNot(HaveOccurred())
+
+		k3sMock = nil
+		Eventually(runK3sMock, "60s", "5s").ShouldNot(HaveOccurred())
+
+		fakeProvisioner.SetProgress(i1, provisioner.Progress{Phase: provisioner.PhaseCompleted})
+
+		Eventually(func() error {
+			cObj := &seederv1alpha1.Cluster{}
+			if err := k8sClient.Get(ctx, types.NamespacedName{Namespace: c.Namespace, Name: c.Name}, cObj); err != nil {
+				return err
+			}
+
+			if cObj.Status.Status != seederv1alpha1.ClusterRunning {
+				return fmt.Errorf("waiting for cluster to be running. current status is %s", cObj.Status.Status)
+			}
+
+			if util.ConditionExists(cObj.Status.Conditions, seederv1alpha1.ClusterReprovisioning) {
+				return fmt.Errorf("waiting for cluster rebuild to complete")
+			}
+
+			iObj := &seederv1alpha1.Inventory{}
+			if err := k8sClient.Get(ctx, types.NamespacedName{Namespace: i2.Namespace, Name: i2.Name}, iObj); err != nil {
+				return err
+			}
+
+			if util.ConditionExists(iObj.Status.Conditions, seederv1alpha1.InventoryReprovisionPending) {
+				return fmt.Errorf("waiting for node %s to be reinstalled", iObj.Name)
+			}
+			return nil
+		}, "120s", "5s").ShouldNot(HaveOccurred())
+	})
+
+	AfterEach(func() {
+		Eventually(func() error {
+			return k8sClient.Delete(ctx, c)
+		}, "30s", "5s").ShouldNot(HaveOccurred())
+
+		Eventually(func() error {
+			return k8sClient.Delete(ctx, i1)
+		}, "30s", "5s").ShouldNot(HaveOccurred())
+
+		Eventually(func() error {
+			return k8sClient.Delete(ctx, i2)
+		}, "30s", "5s").ShouldNot(HaveOccurred())
+
+		Eventually(func() error {
+			return k8sClient.Delete(ctx, creds)
+		}, "30s", "5s").ShouldNot(HaveOccurred())
+
+		Eventually(func() error {
+			return k8sClient.Delete(ctx, a)
+		}, "30s", "5s").ShouldNot(HaveOccurred())
+
+		Eventually(func() error {
+			cObj := &seederv1alpha1.Cluster{}
+			err := k8sClient.Get(ctx, types.NamespacedName{Namespace: c.Namespace, Name: c.Name}, cObj)
+			if err != nil {
+				if apierrors.IsNotFound(err) {
+					return nil
+				}
+				return err
+			}
+
+			return fmt.Errorf("waiting for cluster finalizers to finish")
+		}, "30s", "5s").ShouldNot(HaveOccurred())
+
+		Eventually(func() error {
+			if k3sMock == nil {
+				return nil
+			}
+			return pool.Purge(k3sMock)
+		}, "30s", "5s").ShouldNot(HaveOccurred())
+	})
+})
+
+var _ = Describe("hardware drift tests", func() {
+	var i *seederv1alpha1.Inventory
+	var c *seederv1alpha1.Cluster
+	var a *seederv1alpha1.AddressPool
+	var creds *v1.Secret
+	BeforeEach(func() {
+		a = &seederv1alpha1.AddressPool{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "drift-test",
+				Namespace: "default",
+			},
+			Spec: seederv1alpha1.AddressSpec{
+				CIDR:    "192.168.1.1/29",
+				Gateway: "192.168.1.7",
+			},
+		}
+
+		i = &seederv1alpha1.Inventory{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "drift-node",
+				Namespace: "default",
+			},
+			Spec: seederv1alpha1.InventorySpec{
+				PrimaryDisk:                   "/dev/sda",
+				ManagementInterfaceMacAddress: "xx:xx:xx:xx:xx",
+				BaseboardManagementSpec: rufio.BaseboardManagementSpec{
+					Connection: rufio.Connection{
+						Host:        "localhost",
+						Port:        623,
+						InsecureTLS: true,
+						AuthSecretRef: v1.SecretReference{
+							Name:      "drift-node",
+							Namespace: "default",
+						},
+					},
+				},
+			},
+		}
+
+		creds = &v1.Secret{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "drift-node",
+				Namespace: "default",
+			},
+			StringData: map[string]string{
+				"username": "admin",
+				"password": "password",
+			},
+		}
+
+		c = &seederv1alpha1.Cluster{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "drift-cluster",
+				Namespace: "default",
+			},
+			Spec: seederv1alpha1.ClusterSpec{
+				HarvesterVersion: "harvester_1_0_2",
+				Nodes: []seederv1alpha1.NodeConfig{
+					{
+						InventoryReference: seederv1alpha1.ObjectReference{
+							Name:      "drift-node",
+							Namespace: "default",
+						},
+						AddressPoolReference: seederv1alpha1.ObjectReference{
+							Name:      "drift-test",
+							Namespace: "default",
+						},
+					},
+				},
+				VIPConfig: seederv1alpha1.VIPConfig{
+					AddressPoolReference: seederv1alpha1.ObjectReference{
+						Name:      "drift-test",
+						Namespace: "default",
+					},
+				},
+				ClusterConfig: seederv1alpha1.ClusterConfig{
+					SSHKeys: []string{
+						"abc",
+						"def",
+					},
+					ConfigURL: "localhost:30300/config.yaml",
+				},
+			},
+		}
+
+		Eventually(func() error {
+			return k8sClient.Create(ctx, a)
+		}, "30s", "5s").ShouldNot(HaveOccurred())
+
+		Eventually(func() error {
+			return k8sClient.Create(ctx, creds)
+		}, "30s", "5s").ShouldNot(HaveOccurred())
+
+		Eventually(func() error {
+			return k8sClient.Create(ctx, i)
+		}, "30s", "5s").ShouldNot(HaveOccurred())
+
+		Eventually(func() error {
+			return k8sClient.Create(ctx, c)
+		}, "30s", "5s").ShouldNot(HaveOccurred())
+	})
+
+	It("update hardware and flag drift when cluster spec changes", func() {
+		Eventually(func() error {
+			iObj := &seederv1alpha1.Inventory{}
+			if err := k8sClient.Get(ctx, types.NamespacedName{Namespace: i.Namespace, Name: i.Name}, iObj); err != nil {
+				return err
+			}
+
+			if iObj.Status.InstalledConfigHash == "" {
+				return fmt.Errorf("waiting for installed config hash to be recorded")
+			}
+			return nil
+		}, "60s", "5s").ShouldNot(HaveOccurred())
+
+		Eventually(func() error {
+			cObj := &seederv1alpha1.Cluster{}
+			if err := k8sClient.Get(ctx, types.NamespacedName{Namespace: c.Namespace, Name: c.Name}, cObj); err != nil {
+				return err
+			}
+			cObj.Spec.ClusterConfig.SSHKeys = []string{"ghi"}
+			return k8sClient.Update(ctx, cObj)
+		}, "30s", "5s").ShouldNot(HaveOccurred())
+
+		Eventually(func() error {
+			hwObj := &tinkv1alpha1.Hardware{}
+			if err := k8sClient.Get(ctx, types.NamespacedName{Namespace: i.Namespace, Name: i.Name}, hwObj); err != nil {
+				return err
+			}
+
+			if !strings.Contains(hwObj.Spec.Metadata.Instance.Userdata, "ghi") {
+				return fmt.Errorf("waiting for hardware to be updated with new ssh keys")
+			}
+
+			iObj := &seederv1alpha1.Inventory{}
+			if err := k8sClient.Get(ctx, types.NamespacedName{Namespace: i.Namespace, Name: i.Name}, iObj); err != nil {
+				return err
+			}
+
+			if !util.ConditionExists(iObj.Status.Conditions, seederv1alpha1.InventoryReprovisionRequired) {
+				return fmt.Errorf("waiting for reprovision required condition %v", iObj.Status.Conditions)
+			}
+			return nil
+		}, "60s", "5s").ShouldNot(HaveOccurred())
+	})
+
+	AfterEach(func() {
+		Eventually(func() error {
+			return k8sClient.Delete(ctx, c)
+		}, "30s", "5s").ShouldNot(HaveOccurred())
+
+		Eventually(func() error {
+			return k8sClient.Delete(ctx, i)
+		}, "30s", "5s").ShouldNot(HaveOccurred())
+
+		Eventually(func() error {
+			return k8sClient.Delete(ctx, creds)
+		}, "30s", "5s").ShouldNot(HaveOccurred())
+
+		Eventually(func() error {
+			return k8sClient.Delete(ctx, a)
+		}, "30s", "5s").ShouldNot(HaveOccurred())
+
+		Eventually(func() error {
+			cObj := &seederv1alpha1.Cluster{}
+			err := k8sClient.Get(ctx, types.NamespacedName{Namespace: c.Namespace, Name: c.Name}, cObj)
+			if err != nil {
+				if apierrors.IsNotFound(err) {
+					return nil
+				}
+				return err
+			}
+
+			return fmt.Errorf("waiting for cluster finalizers to finish")
+		}, "30s", "5s").ShouldNot(HaveOccurred())
+	})
+})
+
+var _ = Describe("fake provisioner tests", func() {
+	var i *seederv1alpha1.Inventory
+	var c *seederv1alpha1.Cluster
+	var a *seederv1alpha1.AddressPool
+	var creds *v1.Secret
+	BeforeEach(func() {
+		a = &seederv1alpha1.AddressPool{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "fake-test",
+				Namespace: "default",
+			},
+			Spec: seederv1alpha1.AddressSpec{
+				CIDR:    "192.168.1.1/29",
+				Gateway: "192.168.1.7",
+			},
+		}
+
+		i = &seederv1alpha1.Inventory{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "fake-node",
+				Namespace: "default",
+			},
+			Spec: seederv1alpha1.InventorySpec{
+				PrimaryDisk:                   "/dev/sda",
+				ManagementInterfaceMacAddress: "xx:xx:xx:xx:xx",
+				BaseboardManagementSpec: rufio.BaseboardManagementSpec{
+					Connection: rufio.Connection{
+						Host:        "localhost",
+						Port:        623,
+						InsecureTLS: true,
+						AuthSecretRef: v1.SecretReference{
+							Name:      "fake-node",
+							Namespace: "default",
+						},
+					},
+				},
+			},
+		}
+
+		creds = &v1.Secret{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "fake-node",
+				Namespace: "default",
+			},
+			StringData: map[string]string{
+				"username": "admin",
+				"password": "password",
+			},
+		}
+
+		c = &seederv1alpha1.Cluster{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "fake-cluster",
+				Namespace: "default",
+			},
+			Spec: seederv1alpha1.ClusterSpec{
+				HarvesterVersion: "harvester_1_0_2",
+				Provisioner:      provisioner.FakeProvisioner,
+				Nodes: []seederv1alpha1.NodeConfig{
+					{
+						InventoryReference: seederv1alpha1.ObjectReference{
+							Name:      "fake-node",
+							Namespace: "default",
+						},
+						AddressPoolReference: seederv1alpha1.ObjectReference{
+							Name:      "fake-test",
+							Namespace: "default",
+						},
+					},
+				},
+				VIPConfig: seederv1alpha1.VIPConfig{
+					AddressPoolReference: seederv1alpha1.ObjectReference{
+						Name:      "fake-test",
+						Namespace: "default",
+					},
+				},
+				ClusterConfig: seederv1alpha1.ClusterConfig{
+					SSHKeys: []string{
+						"abc",
+						"def",
+					},
+					ConfigURL: "localhost:30300/config.yaml",
+				},
+			},
+		}
+
+		Eventually(func() error {
+			return k8sClient.Create(ctx, a)
+		}, "30s", "5s").ShouldNot(HaveOccurred())
+
+		Eventually(func() error {
+			return k8sClient.Create(ctx, creds)
+		}, "30s", "5s").ShouldNot(HaveOccurred())
+
+		Eventually(func() error {
+			return k8sClient.Create(ctx, i)
+		}, "30s", "5s").ShouldNot(HaveOccurred())
+
+		Eventually(func() error {
+			return k8sClient.Create(ctx, c)
+		}, "30s", "5s").ShouldNot(HaveOccurred())
+	})
+
+	It("provision nodes using the cluster provisioner", func() {
+		Eventually(func() error {
+			iObj := &seederv1alpha1.Inventory{}
+			if err := k8sClient.Get(ctx, types.NamespacedName{Namespace: i.Namespace, Name: i.Name}, iObj); err != nil {
+				return err
+			}
+
+			if !util.ConditionExists(iObj.Status.Conditions, seederv1alpha1.TinkHardwareCreated) {
+				return fmt.Errorf("waiting for provisioning objects to be created %v", iObj.Status.Conditions)
+			}
+
+			if !fakeProvisioner.Provisioned(iObj) {
+				return fmt.Errorf("expected node to be provisioned by fake provisioner")
+			}
+			return nil
+		}, "60s", "5s").ShouldNot(HaveOccurred())
+
+		hwObj := &tinkv1alpha1.Hardware{}
+		err := k8sClient.Get(ctx, types.NamespacedName{Namespace: i.Namespace, Name: i.Name}, hwObj)
+		Expect(apierrors.IsNotFound(err)).To(BeTrue())
+	})
+
+	AfterEach(func() {
+		Eventually(func() error {
+			return k8sClient.Delete(ctx, c)
+		}, "30s", "5s").ShouldNot(HaveOccurred())
+
+		Eventually(func() error {
+			return k8sClient.Delete(ctx, i)
+		}, "30s", "5s").ShouldNot(HaveOccurred())
+
+		Eventually(func() error {
+			return k8sClient.Delete(ctx, creds)
+		}, "30s", "5s").ShouldNot(HaveOccurred())
+
+		Eventually(func() error {
+			return k8sClient.Delete(ctx, a)
+		}, "30s", "5s").ShouldNot(HaveOccurred())
+
+		Eventually(func() error {
+			cObj := &seederv1alpha1.Cluster{}
+			err := k8sClient.Get(ctx, types.NamespacedName{Namespace: c.Namespace, Name: c.Name}, cObj)
+			if err != nil {
+				if apierrors.IsNotFound(err) {
+					return nil
+				}
+				return err
+			}
+
+			return fmt.Errorf("waiting for cluster finalizers to finish")
+		}, "30s", "5s").ShouldNot(HaveOccurred())
+	})
+})
+
+var _ = Describe("workflow template tests", func() {
+	var i *seederv1alpha1.Inventory
+	var c *seederv1alpha1.Cluster
+	var a *seederv1alpha1.AddressPool
+	var creds *v1.Secret
+	var library *v1.ConfigMap
+	BeforeEach(func() {
+		a = &seederv1alpha1.AddressPool{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "workflow-test",
+				Namespace: "default",
+			},
+			Spec: seederv1alpha1.AddressSpec{
+				CIDR:    "192.168.1.1/29",
+				Gateway: "192.168.1.7",
+			},
+		}
+
+		i = &seederv1alpha1.Inventory{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "workflow-node",
+				Namespace: "default",
+			},
+			Spec: seederv1alpha1.InventorySpec{
+				PrimaryDisk:                   "/dev/sda",
+				ManagementInterfaceMacAddress: "xx:xx:xx:xx:xx",
+				WorkflowTemplate: &seederv1alpha1.WorkflowTemplateReference{
+					Library: seederv1alpha1.ObjectReference{
+						Name:      "workflow-library",
+						Namespace: "default",
+					},
+					Name: "firmware",
+				},
+				BaseboardManagementSpec: rufio.BaseboardManagementSpec{
+					Connection: rufio.Connection{
+						Host:        "localhost",
+						Port:        623,
+						InsecureTLS: true,
+						AuthSecretRef: v1.SecretReference{
+							Name:      "workflow-node",
+							Namespace: "default",
+						},
+					},
+				},
+			},
+		}
+
+		creds = &v1.Secret{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "workflow-node",
+				Namespace: "default",
+			},
+			StringData: map[string]string{
+				"username": "admin",
+				"password": "password",
+			},
+		}
+
+		library = &v1.ConfigMap{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "workflow-library",
+				Namespace: "default",
+			},
+			Data: map[string]string{
+				"firmware": "version: \"0.1\"\nname: firmware\n",
+			},
+		}
+
+		c = &seederv1alpha1.Cluster{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "workflow-cluster",
+				Namespace: "default",
+			},
+			Spec: seederv1alpha1.ClusterSpec{
+				HarvesterVersion: "harvester_1_0_2",
+				Nodes: []seederv1alpha1.NodeConfig{
+					{
+						InventoryReference: seederv1alpha1.ObjectReference{
+							Name:      "workflow-node",
+							Namespace: "default",
+						},
+						AddressPoolReference: seederv1alpha1.ObjectReference{
+							Name:      "workflow-test",
+							Namespace: "default",
+						},
+					},
+				},
+				VIPConfig: seederv1alpha1.VIPConfig{
+					AddressPoolReference: seederv1alpha1.ObjectReference{
+						Name:      "workflow-test",
+						Namespace: "default",
+					},
+				},
+				ClusterConfig: seederv1alpha1.ClusterConfig{
+					SSHKeys: []string{
+						"abc",
+						"def",
+					},
+					ConfigURL: "localhost:30300/config.yaml",
+				},
+			},
+		}
+
+		Eventually(func() error {
+			return k8sClient.Create(ctx, a)
+		}, "30s", "5s").ShouldNot(HaveOccurred())
+
+		Eventually(func() error {
+			return k8sClient.Create(ctx, creds)
+		}, "30s", "5s").ShouldNot(HaveOccurred())
+
+		Eventually(func() error {
+			return k8sClient.Create(ctx, library)
+		}, "30s", "5s").ShouldNot(HaveOccurred())
+
+		Eventually(func() error {
+			return k8sClient.Create(ctx, i)
+		}, "30s", "5s").ShouldNot(HaveOccurred())
+
+		Eventually(func() error {
+			return k8sClient.Create(ctx, c)
+		}, "30s", "5s").ShouldNot(HaveOccurred())
+	})
+
+	It("create workflow from template library and track workflow state", func() {
+		Eventually(func() error {
+			iObj := &seederv1alpha1.Inventory{}
+			if err := k8sClient.Get(ctx, types.NamespacedName{Namespace: i.Namespace, Name: i.Name}, iObj); err != nil {
+				return err
+			}
+
+			if !util.ConditionExists(iObj.Status.Conditions, seederv1alpha1.TinkWorkflowCreated) {
+				return fmt.Errorf("waiting for tink workflow to be created %v", iObj.Status.Conditions)
+			}
+
+			tmplObj := &tinkv1alpha1.Template{}
+			if err := k8sClient.Get(ctx, types.NamespacedName{Namespace: i.Namespace, Name: "workflow-node-workflow"}, tmplObj); err != nil {
+				return err
+			}
+
+			wfObj := &tinkv1alpha1.Workflow{}
+			if err := k8sClient.Get(ctx, types.NamespacedName{Namespace: i.Namespace, Name: "workflow-node-workflow"}, wfObj); err != nil {
+				return err
+			}
+
+			wfObj.Status.State = tinkv1alpha1.WorkflowStateSuccess
+			return k8sClient.Status().Update(ctx, wfObj)
+		}, "60s", "5s").ShouldNot(HaveOccurred())
+
+		Eventually(func() error {
+			iObj := &seederv1alpha1.Inventory{}
+			if err := k8sClient.Get(ctx, types.NamespacedName{Namespace: i.Namespace, Name: i.Name}, iObj); err != nil {
+				return err
+			}
+
+			if !util.ConditionExists(iObj.Status.Conditions, seederv1alpha1.TinkWorkflowCompleted) {
+				return fmt.Errorf("waiting for tink workflow to complete %v", iObj.Status.Conditions)
+			}
+			return nil
+		}, "60s", "5s").ShouldNot(HaveOccurred())
+	})
+
+	AfterEach(func() {
+		Eventually(func() error {
+			return k8sClient.Delete(ctx, c)
+		}, "30s", "5s").ShouldNot(HaveOccurred())
+
+		Eventually(func() error {
+			return k8sClient.Delete(ctx, i)
+		}, "30s", "5s").ShouldNot(HaveOccurred())
+
+		Eventually(func() error {
+			return k8sClient.Delete(ctx, creds)
+		}, "30s", "5s").ShouldNot(HaveOccurred())
+
+		Eventually(func() error {
+			return k8sClient.Delete(ctx, library)
+		}, "30s", "5s").ShouldNot(HaveOccurred())
+
+		Eventually(func() error {
+			return k8sClient.Delete(ctx, a)
+		}, "30s", "5s").ShouldNot(HaveOccurred())
+
+		Eventually(func() error {
+			cObj := &seederv1alpha1.Cluster{}
+			err := k8sClient.Get(ctx, types.NamespacedName{Namespace: c.Namespace, Name: c.Name}, cObj)
+			if err != nil {
+				if apierrors.IsNotFound(err) {
+					return nil
+				}
+				return err
+			}
+
+			return fmt.Errorf("waiting for cluster finalizers to finish")
+		}, "30s", "5s").ShouldNot(HaveOccurred())
+	})
+})
+
+var _ = Describe("post-install workflow tests", func() {
+	var i *seederv1alpha1.Inventory
+	var c *seederv1alpha1.Cluster
+	var a *seederv1alpha1.AddressPool
+	var creds *v1.Secret
+	var library *v1.ConfigMap
+	var k3sMock *dockertest.Resource
+
+	BeforeEach(func() {
+		a = &seederv1alpha1.AddressPool{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "post-install-test",
+				Namespace: "default",
+			},
+			Spec: seederv1alpha1.AddressSpec{
+				CIDR:    "127.0.0.1/8",
+				Gateway: "127.0.0.1",
+			},
+		}
+
+		i = &seederv1alpha1.Inventory{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "post-install-node",
+				Namespace: "default",
+			},
+			Spec: seederv1alpha1.InventorySpec{
+				PrimaryDisk:                   "/dev/sda",
+				ManagementInterfaceMacAddress: "xx:xx:xx:xx:xx",
+				PostInstallWorkflowTemplate: &seederv1alpha1.WorkflowTemplateReference{
+					Library: seederv1alpha1.ObjectReference{
+						Name:      "post-install-library",
+						Namespace: "default",
+					},
+					Name: "register",
+				},
+				BaseboardManagementSpec: rufio.BaseboardManagementSpec{
+					Connection: rufio.Connection{
+						Host:        "localhost",
+						Port:        623,
+						InsecureTLS: true,
+						AuthSecretRef: v1.SecretReference{
+							Name:      "post-install-node",
+							Namespace: "default",
+						},
+					},
+				},
+			},
+		}
+
+		creds = &v1.Secret{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "post-install-node",
+				Namespace: "default",
+			},
+			StringData: map[string]string{
+				"username": "root",
+				"password": "calvin",
+			},
+		}
+
+		library = &v1.ConfigMap{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "post-install-library",
+				Namespace: "default",
+			},
+			Data: map[string]string{
+				"register": "version: \"0.1\"\nname: register\n",
+			},
+		}
+
+		c = &seederv1alpha1.Cluster{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "post-install-cluster",
+				Namespace: "default",
+			},
+			Spec: seederv1alpha1.ClusterSpec{
+				HarvesterVersion: "harvester_1_0_2",
+				Nodes: []seederv1alpha1.NodeConfig{
+					{
+						InventoryReference: seederv1alpha1.ObjectReference{
+							Name:      "post-install-node",
+							Namespace: "default",
+						},
+						AddressPoolReference: seederv1alpha1.ObjectReference{
+							Name:      "post-install-test",
+							Namespace: "default",
+						},
+					},
+				},
+				VIPConfig: seederv1alpha1.VIPConfig{
+					AddressPoolReference: seederv1alpha1.ObjectReference{
+						Name:      "post-install-test",
+						Namespace: "default",
+					},
+				},
+				ClusterConfig: seederv1alpha1.ClusterConfig{
+					SSHKeys: []string{
+						"abc",
+						"def",
+					},
+					ConfigURL: "localhost:30300/config.yaml",
+				},
+			},
+		}
+
+		Eventually(func() error {
+			return k8sClient.Create(ctx, a)
+		}, "30s", "5s").ShouldNot(HaveOccurred())
+
+		Eventually(func() error {
+			return k8sClient.Create(ctx, creds)
+		}, "30s", "5s").ShouldNot(HaveOccurred())
+
+		Eventually(func() error {
+			return k8sClient.Create(ctx, library)
+		}, "30s", "5s").ShouldNot(HaveOccurred())
+
+		Eventually(func() error {
+			return k8sClient.Create(ctx, i)
+		}, "30s", "5s").ShouldNot(HaveOccurred())
+
+		Eventually(func() error {
+			return k8sClient.Create(ctx, c)
+		}, "30s", "5s").ShouldNot(HaveOccurred())
+
+		// wait for token to be populated and then use the same to create a k3s mock
+		Eventually(func() error {
+			cObj := &seederv1alpha1.Cluster{}
+			err := k8sClient.Get(ctx, types.NamespacedName{Namespace: c.Namespace, Name: c.Name}, cObj)
+			if err != nil {
+				return err
+			}
+
+			if cObj.Status.ClusterToken == "" {
+				return fmt.Errorf("waiting for cluster token to be generated")
+			}
+
+			k3sMock, err = pool.RunWithOptions(&dockertest.RunOptions{
+				Name:       "k3s-mock",
+				Repository: "rancher/k3s",
+				Tag:        "v1.24.2-k3s1",
+				Cmd:        []string{"server", "--cluster-init"},
+				Env: []string{
+					fmt.Sprintf("K3S_TOKEN=%s", cObj.Status.ClusterToken),
+				},
+				Mounts: []string{
+					"tmpfs:/run",
+					"tmpfs:/var/run",
+				},
+				Privileged: true,
+				ExposedPorts: []string{
+					"6443/tcp",
+				},
+			}, func(config *docker.HostConfig) {
+				config.RestartPolicy = docker.RestartPolicy{
+					Name: "no",
+				}
+			})
+			if err != nil {
+				return err
+			}
+
+			if cObj.Labels == nil {
+				cObj.Labels = make(map[string]string)
+			}
+
+			// since mock node is k3s, need to change prefix from rke2 to k3s
+			seederv1alpha1.DefaultAPIPrefix = "k3s"
+			cObj.Labels[seederv1alpha1.OverrideAPIPortLabel] = k3sMock.GetPort("6443/tcp")
+			return k8sClient.Update(ctx, cObj)
+		}, "30s", "5s").ShouldNot(HaveOccurred())
+	})
+
+	It("run post-install workflow once the node has joined the cluster", func() {
+		Eventually(func() error {
+			cObj := &seederv1alpha1.Cluster{}
+			if err := k8sClient.Get(ctx, types.NamespacedName{Namespace: c.Namespace, Name: c.Name}, cObj); err != nil {
+				return err
+			}
+
+			if cObj.Status.Status != seederv1alpha1.ClusterRunning {
+				return fmt.Errorf("waiting for cluster to be running. current status is %s", cObj.Status.Status)
+			}
+			return nil
+		}, "120s", "5s").ShouldNot(HaveOccurred())
+
+		// workflow is not run until the node is found in the cluster
+		Consistently(func() error {
+			iObj := &seederv1alpha1.Inventory{}
+			if err := k8sClient.Get(ctx, types.NamespacedName{Namespace: i.Namespace, Name: i.Name}, iObj); err != nil {
+				return err
+			}
+
+			if util.ConditionExists(iObj.Status.Conditions, seederv1alpha1.TinkPostInstallWorkflowCreated) {
+				return fmt.Errorf("expected post-install workflow to not be created before the node joins")
+			}
+			return nil
+		}, "15s", "5s").ShouldNot(HaveOccurred())
+
+		// register a node with the inventory address in the k3s mock
+		Eventually(func() error {
+			iObj := &seederv1alpha1.Inventory{}
+			if err := k8sClient.Get(ctx, types.NamespacedName{Namespace: i.Namespace, Name: i.Name}, iObj); err != nil {
+				return err
+			}
+
+			cObj := &seederv1alpha1.Cluster{}
+			if err := k8sClient.Get(ctx, types.NamespacedName{Namespace: c.Namespace, Name: c.Name}, cObj); err != nil {
+				return err
+			}
+
+			typedClient, err := genCoreTypedClient(ctx, cObj)
+			if err != nil {
+				return err
+			}
+
+			node, err := typedClient.Nodes().Get(ctx, i.Name, metav1.GetOptions{})
+			if err != nil {
+				if !apierrors.IsNotFound(err) {
+					return err
+				}
+				node, err = typedClient.Nodes().Create(ctx, &v1.Node{ObjectMeta: metav1.ObjectMeta{Name: i.Name}}, metav1.CreateOptions{})
+				if err != nil {
+					return err
+				}
+			}
+
+			node.Status.Addresses = []v1.NodeAddress{
+				{
+					Type:    v1.NodeInternalIP,
+					Address: iObj.Status.Address,
+				},
+			}
+			_, err = typedClient.Nodes().UpdateStatus(ctx, node, metav1.UpdateOptions{})
+			return err
+		}, "30s", "5s").ShouldNot(HaveOccurred())
+
+		Eventually(func() error {
+			iObj := &seederv1alpha1.Inventory{}
+			if err := k8sClient.Get(ctx, types.NamespacedName{Namespace: i.Namespace, Name: i.Name}, iObj); err != nil {
+				return err
+			}
+
+			if !util.ConditionExists(iObj.Status.Conditions, seederv1alpha1.TinkPostInstallWorkflowCreated) {
+				return fmt.Errorf("waiting for post-install workflow to be created %v", iObj.Status.Conditions)
+			}
+
+			tmplObj := &tinkv1alpha1.Template{}
+			if err := k8sClient.Get(ctx, types.NamespacedName{Namespace: i.Namespace, Name: "post-install-node-post-install"}, tmplObj); err != nil {
+				return err
+			}
+
+			jobObj := &rufio.BMCJob{}
+			if err := k8sClient.Get(ctx, types.NamespacedName{Namespace: i.Namespace, Name: "post-install-node-post-install"}, jobObj); err != nil {
+				return err
+			}
+
+			wfObj := &tinkv1alpha1.Workflow{}
+			if err := k8sClient.Get(ctx, types.NamespacedName{Namespace: i.Namespace, Name: "post-install-node-post-install"}, wfObj); err != nil {
+				return err
+			}
+
+			wfObj.Status.State = tinkv1alpha1.WorkflowStateSuccess
+			return k8sClient.Status().Update(ctx, wfObj)
+		}, "120s", "5s").ShouldNot(HaveOccurred())
+
+		Eventually(func() error {
+			iObj := &seederv1alpha1.Inventory{}
+			if err := k8sClient.Get(ctx, types.NamespacedName{Namespace: i.Namespace, Name: i.Name}, iObj); err != nil {
+				return err
+			}
+
+			if !util.ConditionExists(iObj.Status.Conditions, seederv1alpha1.TinkPostInstallWorkflowCompleted) {
+				return fmt.Errorf("waiting for post-install workflow to complete %v", iObj.Status.Conditions)
+			}
+			return nil
+		}, "60s", "5s").ShouldNot(HaveOccurred())
+	})
+
+	AfterEach(func() {
+		Eventually(func() error {
+			return k8sClient.Delete(ctx, c)
+		}, "30s", "5s").ShouldNot(HaveOccurred())
+
+		Eventually(func() error {
+			return k8sClient.Delete(ctx, i)
+		}, "30s", "5s").ShouldNot(HaveOccurred())
+
+		Eventually(func() error {
+			return k8sClient.Delete(ctx, creds)
+		}, "30s", "5s").ShouldNot(HaveOccurred())
+
+		Eventually(func() error {
+			return k8sClient.Delete(ctx, library)
+		}, "30s", "5s").ShouldNot(HaveOccurred())
+
+		Eventually(func() error {
+			return k8sClient.Delete(ctx, a)
+		}, "30s", "5s").ShouldNot(HaveOccurred())
+
+		Eventually(func() error {
+			cObj := &seederv1alpha1.Cluster{}
+			err := k8sClient.Get(ctx, types.NamespacedName{Namespace: c.Namespace, Name: c.Name}, cObj)
+			if err != nil {
+				if apierrors.IsNotFound(err) {
+					return nil
+				}
+				return err
+			}
+
+			return fmt.Errorf("waiting for cluster finalizers to finish")
+		}, "30s", "5s").ShouldNot(HaveOccurred())
+
+		Eventually(func() error {
+			return pool.Purge(k3sMock)
+		}, "30s", "5s").ShouldNot(HaveOccurred())
+	})
+})
+
+var _ = Describe("wipe inventory on cluster deletion tests", func() {
+	var i *seederv1alpha1.Inventory
+	var c *seederv1alpha1.Cluster
+	var a *seederv1alpha1.AddressPool
+	var creds *v1.Secret
+	BeforeEach(func() {
+		a = &seederv1alpha1.AddressPool{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "wipe-test",
+				Namespace: "default",
+			},
+			Spec: seederv1alpha1.AddressSpec{
+				CIDR:    "192.168.1.1/29",
+				Gateway: "192.168.1.7",
+			},
+		}
+
+		i = &seederv1alpha1.Inventory{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "wipe-node",
+				Namespace: "default",
+			},
+			Spec: seederv1alpha1.InventorySpec{
+				PrimaryDisk:                   "/dev/sda",
+				ManagementInterfaceMacAddress: "xx:xx:xx:xx:xx",
+				DeletionPolicy:                seederv1alpha1.DeletionPolicyWipe,
+				BaseboardManagementSpec: rufio.BaseboardManagementSpec{
+					Connection: rufio.Connection{
+						Host:        "localhost",
+						Port:        623,
+						InsecureTLS: true,
+						AuthSecretRef: v1.SecretReference{
+							Name:      "wipe-node",
+							Namespace: "default",
+						},
+					},
+				},
+			},
+		}
+
+		creds = &v1.Secret{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "wipe-node",
+				Namespace: "default",
+			},
+			StringData: map[string]string{
+				"username": "admin",
+				"password": "password",
+			},
+		}
+
+		c = &seederv1alpha1.Cluster{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "wipe-cluster",
+				Namespace: "default",
+			},
+			Spec: seederv1alpha1.ClusterSpec{
+				HarvesterVersion: "harvester_1_0_2",
+				Nodes: []seederv1alpha1.NodeConfig{
+					{
+						InventoryReference: seederv1alpha1.ObjectReference{
+							Name:      "wipe-node",
+							Namespace: "default",
+						},
+						AddressPoolReference: seederv1alpha1.ObjectReference{
+							Name:      "wipe-test",
+							Namespace: "default",
+						},
+					},
+				},
+				VIPConfig: seederv1alpha1.VIPConfig{
+					AddressPoolReference: seederv1alpha1.ObjectReference{
+						Name:      "wipe-test",
+						Namespace: "default",
+					},
+				},
+				ClusterConfig: seederv1alpha1.ClusterConfig{
+					SSHKeys: []string{
+						"abc",
+						"def",
+					},
+					ConfigURL: "localhost:30300/config.yaml",
+				},
+			},
+		}
+
+		Eventually(func() error {
+			return k8sClient.Create(ctx, a)
+		}, "30s", "5s").ShouldNot(HaveOccurred())
+
+		Eventually(func() error {
+			return k8sClient.Create(ctx, creds)
+		}, "30s", "5s").ShouldNot(HaveOccurred())
+
+		Eventually(func() error {
+			return k8sClient.Create(ctx, i)
+		}, "30s", "5s").ShouldNot(HaveOccurred())
+
+		Eventually(func() error {
+			return k8sClient.Create(ctx, c)
+		}, "30s", "5s").ShouldNot(HaveOccurred())
+	})
+
+	It("wipe inventory before releasing address", func() {
+		Eventually(func() error {
+			iObj := &seederv1alpha1.Inventory{}
+			if err := k8sClient.Get(ctx, types.NamespacedName{Namespace: i.Namespace, Name: i.Name}, iObj); err != nil {
+				return err
+			}
+
+			if !util.ConditionExists(iObj.Status.Conditions, seederv1alpha1.TinkHardwareCreated) {
+				return fmt.Errorf("waiting for inventory to be provisioned %v", iObj.Status.Conditions)
+			}
+			return nil
+		}, "60s", "5s").ShouldNot(HaveOccurred())
+
+		Eventually(func() error {
+			return k8sClient.Delete(ctx, c)
+		}, "30s", "5s").ShouldNot(HaveOccurred())
+
+		Eventually(func() error {
+			iObj := &seederv1alpha1.Inventory{}
+			if err := k8sClient.Get(ctx, types.NamespacedName{Namespace: i.Namespace, Name: i.Name}, iObj); err != nil {
+				return err
+			}
+
+			if !util.ConditionExists(iObj.Status.Conditions, seederv1alpha1.InventoryWiping) {
+				return fmt.Errorf("waiting for inventory wipe to start %v", iObj.Status.Conditions)
+			}
+
+			aObj := &seederv1alpha1.AddressPool{}
+			if err := k8sClient.Get(ctx, types.NamespacedName{Namespace: a.Namespace, Name: a.Name}, aObj); err != nil {
+				return err
+			}
+
+			if _, ok := aObj.Status.AddressAllocation[iObj.Status.Address]; !ok {
+				return fmt.Errorf("expected address %s to be allocated until wipe completes", iObj.Status.Address)
+			}
+
+			wfObj := &tinkv1alpha1.Workflow{}
+			if err := k8sClient.Get(ctx, types.NamespacedName{Namespace: i.Namespace, Name: "wipe-node-wipe"}, wfObj); err != nil {
+				return err
+			}
+
+			wfObj.Status.State = tinkv1alpha1.WorkflowStateSuccess
+			return k8sClient.Status().Update(ctx, wfObj)
+		}, "60s", "5s").ShouldNot(HaveOccurred())
+
+		Eventually(func() error {
+			iObj := &seederv1alpha1.Inventory{}
+			if err := k8sClient.Get(ctx, types.NamespacedName{Namespace: i.Namespace, Name: i.Name}, iObj); err != nil {
+				return err
+			}
+
+			if util.ConditionExists(iObj.Status.Conditions, seederv1alpha1.InventoryWiping) {
+				return fmt.Errorf("waiting for inventory wipe to complete %v", iObj.Status.Conditions)
+			}
+
+			aObj := &seederv1alpha1.AddressPool{}
+			if err := k8sClient.Get(ctx, types.NamespacedName{Namespace: a.Namespace, Name: a.Name}, aObj); err != nil {
+				return err
+			}
+
+			for _, v := range aObj.Status.AddressAllocation {
+				if v.Name == i.Name && v.Namespace == i.Namespace {
+					return fmt.Errorf("waiting for address to be released")
+				}
+			}
+			return nil
+		}, "60s", "5s").ShouldNot(HaveOccurred())
+	})
+
+	AfterEach(func() {
+		Eventually(func() error {
+			return k8sClient.Delete(ctx, i)
+		}, "30s", "5s").ShouldNot(HaveOccurred())
+
+		Eventually(func() error {
+			return k8sClient.Delete(ctx, creds)
+		}, "30s", "5s").ShouldNot(HaveOccurred())
+
+		Eventually(func() error {
+			return k8sClient.Delete(ctx, a)
+		}, "30s", "5s").ShouldNot(HaveOccurred())
+
+		Eventually(func() error {
+			cObj := &seederv1alpha1.Cluster{}
+			err := k8sClient.Get(ctx, types.NamespacedName{Namespace: c.Namespace, Name: c.Name}, cObj)
+			if err != nil {
+				if apierrors.IsNotFound(err) {
+					return nil
+				}
+				return err
+			}
+
+			return fmt.Errorf("waiting for cluster finalizers to finish")
+		}, "30s", "5s").ShouldNot(HaveOccurred())
+	})
+})
+
+var _ = Describe("virtual media boot tests", func() {
+	var i *seederv1alpha1.Inventory
+	var c *seederv1alpha1.Cluster
+	var a *seederv1alpha1.AddressPool
+	var creds *v1.Secret
+	BeforeEach(func() {
+		a = &seederv1alpha1.AddressPool{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "vm-test",
+				Namespace: "default",
+			},
+			Spec: seederv1alpha1.AddressSpec{
+				CIDR:    "192.168.1.1/29",
+				Gateway: "192.168.1.7",
+			},
+		}
+
+		i = &seederv1alpha1.Inventory{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "vm-node",
+				Namespace: "default",
+			},
+			Spec: seederv1alpha1.InventorySpec{
+				PrimaryDisk:                   "/dev/sda",
+				ManagementInterfaceMacAddress: "xx:xx:xx:xx:xx",
+				BaseboardManagementSpec: rufio.BaseboardManagementSpec{
+					Connection: rufio.Connection{
+						Host:        "localhost",
+						Port:        623,
+						InsecureTLS: true,
+						AuthSecretRef: v1.SecretReference{
+							Name:      "vm-node",
+							Namespace: "default",
+						},
+					},
+				},
+			},
+		}
+
+		creds = &v1.Secret{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "vm-node",
+				Namespace: "default",
+			},
+			StringData: map[string]string{
+				"username": "admin",
+				"password": "password",
+			},
+		}
+
+		c = &seederv1alpha1.Cluster{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "vm-cluster",
+				Namespace: "default",
+			},
+			Spec: seederv1alpha1.ClusterSpec{
+				HarvesterVersion: "v1.1.0",
+				ImageURL:         "http://localhost/iso",
+				Provisioner:      provisioner.FakeProvisioner,
+				BootMethod:       seederv1alpha1.BootMethodVirtualMedia,
+				Nodes: []seederv1alpha1.NodeConfig{
+					{
+						InventoryReference: seederv1alpha1.ObjectReference{
+							Name:      "vm-node",
+							Namespace: "default",
+						},
+						AddressPoolReference: seederv1alpha1.ObjectReference{
+							Name:      "vm-test",
+							Namespace: "default",
+						},
+					},
+				},
+				VIPConfig: seederv1alpha1.VIPConfig{
+					AddressPoolReference: seederv1alpha1.ObjectReference{
+						Name:      "vm-test",
+						Namespace: "default",
+					},
+				},
+				ClusterConfig: seederv1alpha1.ClusterConfig{
+					ConfigURL: "localhost:30300/config.yaml",
+				},
+			},
+		}
+
+		Eventually(func() error {
+			return k8sClient.Create(ctx, a)
+		}, "30s", "5s").ShouldNot(HaveOccurred())
+
+		Eventually(func() error {
+			return k8sClient.Create(ctx, creds)
+		}, "30s", "5s").ShouldNot(HaveOccurred())
+
+		Eventually(func() error {
+			return k8sClient.Create(ctx, i)
+		}, "30s", "5s").ShouldNot(HaveOccurred())
+
+		Eventually(func() error {
+			return k8sClient.Create(ctx, c)
+		}, "30s", "5s").ShouldNot(HaveOccurred())
+	})
+
+	It("boot node from virtual media and eject media when released", func() {
+		endpoint := util.RedfishEndpoint(i)
+		Eventually(func() error {
+			iObj := &seederv1alpha1.Inventory{}
+			if err := k8sClient.Get(ctx, types.NamespacedName{Namespace: i.Namespace, Name: i.Name}, iObj); err != nil {
+				return err
+			}
+
+			if !util.ConditionExists(iObj.Status.Conditions, seederv1alpha1.VirtualMediaInserted) {
+				return fmt.Errorf("waiting for virtual media to be inserted %v", iObj.Status.Conditions)
+			}
+
+			if image := fakeVirtualMedia.Image(endpoint); image != "http://localhost/iso/v1.1.0/harvester-v1.1.0-amd64.iso" {
+				return fmt.Errorf("expected harvester iso to be inserted, found %s", image)
+			}
+
+			if iObj.Status.VirtualMediaToken == "" {
+				return fmt.Errorf("expected virtual media token to be generated")
+			}
+
+			if config := fakeVirtualMedia.ConfigImage(endpoint); config != VirtualMediaConfigURL(virtualMediaURL, iObj) {
+				return fmt.Errorf("expected config iso to be inserted, found %s", config)
+			}
+
+			job := &rufio.BMCJob{}
+			if err := k8sClient.Get(ctx, types.NamespacedName{Namespace: i.Namespace, Name: "vm-node-reboot"}, job); err != nil {
+				return err
+			}
+
+			if job.Spec.Tasks[1].OneTimeBootDeviceAction.Devices[0] != rufio.CDROM {
+				return fmt.Errorf("expected node to boot from cdrom, found %v", job.Spec.Tasks[1].OneTimeBootDeviceAction.Devices)
+			}
+			return nil
+		}, "60s", "5s").ShouldNot(HaveOccurred())
+
+		// the config iso is only served with the token of the inventory
+		server := &VirtualMediaServer{
+			Client: k8sClient,
+			Logger: ctrl.Log.WithName("virtual-media-server"),
+		}
+		iObj := &seederv1alpha1.Inventory{}
+		Expect(k8sClient.Get(ctx, types.NamespacedName{Namespace: i.Namespace, Name: i.Name}, iObj)).ToNot(HaveOccurred())
+		configURL, err := url.Parse(VirtualMediaConfigURL(virtualMediaURL, iObj))
+		Expect(err).ToNot(HaveOccurred())
+		resp := httptest.NewRecorder()
+		server.ServeHTTP(resp, httptest.NewRequest(http.MethodGet, configURL.Path, nil))
+		Expect(resp.Code).To(Equal(http.StatusOK))
+		Expect(resp.Body.String()).To(ContainSubstring("CIDATA"))
+		Expect(resp.Body.String()).To(ContainSubstring("automatic: true"))
+		Expect(resp.Body.String()).To(ContainSubstring("iso_url: http://localhost/iso/v1.1.0/harvester-v1.1.0-amd64.iso"))
+
+		resp = httptest.NewRecorder()
+		server.ServeHTTP(resp, httptest.NewRequest(http.MethodGet, strings.Replace(configURL.Path, iObj.Status.VirtualMediaToken, "invalid", 1), nil))
+		Expect(resp.Code).To(Equal(http.StatusNotFound))
+
+		Eventually(func() error {
+			return k8sClient.Delete(ctx, c)
+		}, "30s", "5s").ShouldNot(HaveOccurred())
+
+		Eventually(func() error {
+			iObj := &seederv1alpha1.Inventory{}
+			if err := k8sClient.Get(ctx, types.NamespacedName{Namespace: i.Namespace, Name: i.Name}, iObj); err != nil {
+				return err
+			}
+
+			if !util.ConditionExists(iObj.Status.Conditions, seederv1alpha1.VirtualMediaEjected) {
+				return fmt.Errorf("waiting for virtual media to be ejected %v", iObj.Status.Conditions)
+			}
+
+			if image := fakeVirtualMedia.Image(endpoint); image != "" {
+				return fmt.Errorf("expected virtual media to be ejected, found %s", image)
+			}
+
+			if config := fakeVirtualMedia.ConfigImage(endpoint); config != "" {
+				return fmt.Errorf("expected config media to be ejected, found %s", config)
+			}
+
+			if iObj.Status.VirtualMediaToken != "" {
+				return fmt.Errorf("expected virtual media token to be removed")
+			}
+			return nil
+		}, "60s", "5s").ShouldNot(HaveOccurred())
+	})
+
+	AfterEach(func() {
+		Eventually(func() error {
+			return k8sClient.Delete(ctx, i)
+		}, "30s", "5s").ShouldNot(HaveOccurred())
+
+		Eventually(func() error {
+			return k8sClient.Delete(ctx, creds)
+		}, "30s", "5s").ShouldNot(HaveOccurred())
+
+		Eventually(func() error {
+			return k8sClient.Delete(ctx, a)
+		}, "30s", "5s").ShouldNot(HaveOccurred())
+
+		Eventually(func() error {
+			cObj := &seederv1alpha1.Cluster{}
+			err := k8sClient.Get(ctx, types.NamespacedName{Namespace: c.Namespace, Name: c.Name}, cObj)
+			if err != nil {
+				if apierrors.IsNotFound(err) {
+					return nil
+				}
+				return err
+			}
+
+			return fmt.Errorf("waiting for cluster finalizers to finish")
+		}, "30s", "5s").ShouldNot(HaveOccurred())
+	})
+})
+
+var _ = Describe("dual-stack cluster tests", func() {
+	var i *seederv1alpha1.Inventory
+	var c *seederv1alpha1.Cluster
+	var a, a6 *seederv1alpha1.AddressPool
+	var creds *v1.Secret
+	BeforeEach(func() {
+		a = &seederv1alpha1.AddressPool{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "ds-cluster",
+				Namespace: "default",
+			},
+			Spec: seederv1alpha1.AddressSpec{
+				CIDR:    "192.168.1.1/29",
+				Gateway: "192.168.1.7",
+			},
+		}
+
+		a6 = &seederv1alpha1.AddressPool{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "ds-cluster-v6",
+				Namespace: "default",
+			},
+			Spec: seederv1alpha1.AddressSpec{
+				CIDR:    "fd00:1::/64",
+				Gateway: "fd00:1::1",
+			},
+		}
+
+		i = &seederv1alpha1.Inventory{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "ds-cluster",
+				Namespace: "default",
+			},
+			Spec: seederv1alpha1.InventorySpec{
+				PrimaryDisk:                   "/dev/sda",
+				ManagementInterfaceMacAddress: "xx:xx:xx:xx:xx",
+				BaseboardManagementSpec: rufio.BaseboardManagementSpec{
+					Connection: rufio.Connection{
+						Host:        "localhost",
+						Port:        623,
+						InsecureTLS: true,
+						AuthSecretRef: v1.SecretReference{
+							Name:      "ds-cluster",
+							Namespace: "default",
+						},
+					},
+				},
+			},
+		}
+
+		creds = &v1.Secret{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "ds-cluster",
+				Namespace: "default",
+			},
+			StringData: map[string]string{
+				"username": "admin",
+				"password": "password",
+			},
+		}
+
+		c = &seederv1alpha1.Cluster{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "ds-cluster",
+				Namespace: "default",
+			},
+			Spec: seederv1alpha1.ClusterSpec{
+				HarvesterVersion: "harvester_1_0_2",
+				Nodes: []seederv1alpha1.NodeConfig{
+					{
+						InventoryReference: seederv1alpha1.ObjectReference{
+							Name:      "ds-cluster",
+							Namespace: "default",
+						},
+						AddressPoolReference: seederv1alpha1.ObjectReference{
+							Name:      "ds-cluster",
+							Namespace: "default",
+						},
+						IPv6AddressPoolReference: &seederv1alpha1.ObjectReference{
+							Name:      "ds-cluster-v6",
+							Namespace: "default",
+						},
+					},
+				},
+				VIPConfig: seederv1alpha1.VIPConfig{
+					AddressPoolReference: seederv1alpha1.ObjectReference{
+						Name:      "ds-cluster",
+						Namespace: "default",
+					},
+					IPv6AddressPoolReference: &seederv1alpha1.ObjectReference{
+						Name:      "ds-cluster-v6",
+						Namespace: "default",
+					},
+					StaticIPv6Address: "fd00:1::100",
+				},
+			},
+		}
+
+		for _, obj := range []client.Object{a, a6, creds, i, c} {
+			obj := obj
+			Eventually(func() error {
+				return k8sClient.Create(ctx, obj)
+			}, "30s", "5s").ShouldNot(HaveOccurred())
+		}
+	})
+
+	It("allocate ipv4 and ipv6 addresses to cluster and inventory", func() {
+		Eventually(func() error {
+			cObj := &seederv1alpha1.Cluster{}
+			if err := k8sClient.Get(ctx, types.NamespacedName{Namespace: c.Namespace, Name: c.Name}, cObj); err != nil {
+				return err
+			}
+			if cObj.Status.ClusterAddress == "" || cObj.Status.ClusterIPv6Address != "fd00:1::100" {
+				return fmt.Errorf("waiting for cluster addresses to be allocated: %v", cObj.Status)
+			}
+
+			iObj := &seederv1alpha1.Inventory{}
+			if err := k8sClient.Get(ctx, types.NamespacedName{Namespace: i.Namespace, Name: i.Name}, iObj); err != nil {
+				return err
+			}
+			if iObj.Status.Address == "" || iObj.Status.IPv6Address == "" {
+				return fmt.Errorf("waiting for inventory addresses to be allocated: %v", iObj.Status.PXEBootInterface)
+			}
+			if iObj.Status.IPv6Gateway != "fd00:1::1" {
+				return fmt.Errorf("expected ipv6 gateway to be set from the pool: %v", iObj.Status.PXEBootInterface)
+			}
+
+			hw := &tinkv1alpha1.Hardware{}
+			if err := k8sClient.Get(ctx, types.NamespacedName{Namespace: i.Namespace, Name: i.Name}, hw); err != nil {
+				return err
+			}
+			if len(hw.Spec.Metadata.Instance.Ips) != 2 || hw.Spec.Metadata.Instance.Ips[1].Address != iObj.Status.IPv6Address {
+				return fmt.Errorf("expected hardware to contain both management addresses: %v", hw.Spec.Metadata.Instance.Ips)
+			}
+			return nil
+		}, "60s", "5s").ShouldNot(HaveOccurred())
+	})
+
+	It("release ipv6 addresses on cluster deletion", func() {
+		Eventually(func() error {
+			poolObj := &seederv1alpha1.AddressPool{}
+			if err := k8sClient.Get(ctx, types.NamespacedName{Namespace: a6.Namespace, Name: a6.Name}, poolObj); err != nil {
+				return err
+			}
+			if len(poolObj.Status.AddressAllocation) != 2 {
+				return fmt.Errorf("waiting for ipv6 addresses to be allocated: %v", poolObj.Status.AddressAllocation)
+			}
+			return nil
+		}, "60s", "5s").ShouldNot(HaveOccurred())
+
+		Eventually(func() error {
+			return k8sClient.Delete(ctx, c)
+		}, "30s", "5s").ShouldNot(HaveOccurred())
+
+		Eventually(func() error {
+			poolObj := &seederv1alpha1.AddressPool{}
+			if err := k8sClient.Get(ctx, types.NamespacedName{Namespace: a6.Namespace, Name: a6.Name}, poolObj); err != nil {
+				return err
+			}
+			if len(poolObj.Status.AddressAllocation) != 0 {
+				return fmt.Errorf("waiting for ipv6 addresses to be released: %v", poolObj.Status.AddressAllocation)
+			}
+			return nil
+		}, "60s", "5s").ShouldNot(HaveOccurred())
+	})
+
+	AfterEach(func() {
+		Eventually(func() error {
+			cObj := &seederv1alpha1.Cluster{}
+			err := k8sClient.Get(ctx, types.NamespacedName{Namespace: c.Namespace, Name: c.Name}, cObj)
+			if err != nil {
+				if apierrors.IsNotFound(err) {
+					return nil
+				}
+				return err
+			}
+			return k8sClient.Delete(ctx, c)
+		}, "30s", "5s").ShouldNot(HaveOccurred())
+
+		for _, obj := range []client.Object{i, creds, a, a6} {
+			obj := obj
+			Eventually(func() error {
+				return k8sClient.Delete(ctx, obj)
+			}, "30s", "5s").ShouldNot(HaveOccurred())
+		}
+
+		Eventually(func() error {
+			cObj := &seederv1alpha1.Cluster{}
+			err := k8sClient.Get(ctx, types.NamespacedName{Namespace: c.Namespace, Name: c.Name}, cObj)
+			if err != nil {
+				if apierrors.IsNotFound(err) {
+					return nil
+				}
+				return err
+			}
+
+			return fmt.Errorf("waiting for cluster finalizers to finish")
+		}, "30s", "5s").ShouldNot(HaveOccurred())
+	})
+})
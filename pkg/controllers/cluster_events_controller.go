@@ -3,20 +3,32 @@ package controllers
 import (
 	"context"
 	"fmt"
+	"reflect"
+	"strings"
 	"time"
 
 	"github.com/go-logr/logr"
 	seederv1alpha1 "github.com/harvester/seeder/pkg/api/v1alpha1"
 	"github.com/harvester/seeder/pkg/events"
+	"github.com/harvester/seeder/pkg/provisioner"
+	"github.com/harvester/seeder/pkg/util"
 	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
 	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/types"
 	typedCore "k8s.io/client-go/kubernetes/typed/core/v1"
 	"k8s.io/client-go/tools/record"
+	"k8s.io/client-go/util/retry"
 	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/builder"
 	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+	"sigs.k8s.io/controller-runtime/pkg/source"
 )
 
 type ClusterEventReconciler struct {
@@ -24,8 +36,20 @@ type ClusterEventReconciler struct {
 	Scheme *runtime.Scheme
 	logr.Logger
 	record.EventRecorder
+	// HardwareLabels configures the labels describing the hardware of the inventory added to its node
+	HardwareLabels util.HardwareLabels
+	// Provisioners are the backends used to install nodes, keyed by name
+	Provisioners map[string]provisioner.Provisioner
 }
 
+const (
+	// defaultMissingNodeGracePeriod is how long the node of a provisioned inventory can be missing from the cluster
+	// when the missing node policy does not set a grace period
+	defaultMissingNodeGracePeriod = 10 * time.Minute
+	// missingNodeRequeue is how often a cluster with missing nodes is checked again
+	missingNodeRequeue = time.Minute
+)
+
 type clusterEventReconciler func(context.Context, *seederv1alpha1.Cluster) error
 
 func (r *ClusterEventReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
@@ -53,6 +77,7 @@ func (r *ClusterEventReconciler) Reconcile(ctx context.Context, req ctrl.Request
 	}
 
 	reconcileList := []clusterEventReconciler{
+		r.detectMissingNodes,
 		r.updateNodes,
 	}
 
@@ -62,9 +87,315 @@ func (r *ClusterEventReconciler) Reconcile(ctx context.Context, req ctrl.Request
 		}
 	}
 
+	// missing nodes are checked more often, to apply the missing node policy soon after the grace period, as are
+	// nodes which have yet to join the cluster
+	joining, err := r.nodesJoining(ctx, c)
+	if err != nil {
+		return ctrl.Result{}, err
+	}
+
+	if joining || util.ConditionExists(c.Status.Conditions, seederv1alpha1.ClusterNodesMissing) {
+		return ctrl.Result{RequeueAfter: missingNodeRequeue}, nil
+	}
+
 	return ctrl.Result{RequeueAfter: 15 * time.Minute}, nil
 }
 
+// detectMissingNodes flags the provisioned inventories whose node is not found in the running cluster, such as nodes
+// removed from the cluster by an admin. Once a node has been missing beyond the grace period, the action of the
+// missing node policy is applied to its inventory
+func (r *ClusterEventReconciler) detectMissingNodes(ctx context.Context, c *seederv1alpha1.Cluster) error {
+	gracePeriod := defaultMissingNodeGracePeriod
+	if c.Spec.MissingNodePolicy.GracePeriod != "" {
+		var err error
+		gracePeriod, err = time.ParseDuration(c.Spec.MissingNodePolicy.GracePeriod)
+		if err != nil {
+			return fmt.Errorf("invalid missing node grace period for cluster %s: %v", c.Name, err)
+		}
+	}
+
+	p, ok := r.Provisioners[provisioner.Name(c)]
+	if !ok {
+		return fmt.Errorf("unknown provisioner %s for cluster %s", provisioner.Name(c), c.Name)
+	}
+
+	typedClient, err := genCoreTypedClient(ctx, c)
+	if err != nil {
+		return err
+	}
+
+	nodeList, err := typedClient.Nodes().List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return err
+	}
+
+	var missing []string
+	var released []*seederv1alpha1.Inventory
+	for _, nc := range c.Spec.Nodes {
+		i := &seederv1alpha1.Inventory{}
+		if err := r.Get(ctx, types.NamespacedName{Namespace: nc.InventoryReference.Namespace,
+			Name: nc.InventoryReference.Name}, i); err != nil {
+			return err
+		}
+
+		found := findNodeByIP(nodeList.Items, i.Status.Address) != nil
+		if found && awaitingJoin(c, i, p) {
+			if err := r.markNodeJoined(ctx, i); err != nil {
+				return err
+			}
+			observeNodeProvisioned(i, provisioner.Name(c))
+			r.Event(i, "Normal", "NodeJoined", fmt.Sprintf("node with address %s joined cluster %s", i.Status.Address, c.Name))
+			continue
+		}
+
+		if !expectNode(c, i) || found {
+			if util.ConditionExists(i.Status.Conditions, seederv1alpha1.InventoryNodeMissing) {
+				if err := r.updateNodeMissing(ctx, i, ""); err != nil {
+					return err
+				}
+				if found {
+					r.Event(i, "Normal", "NodeFound", fmt.Sprintf("node with address %s found in cluster %s", i.Status.Address, c.Name))
+				}
+			}
+			continue
+		}
+
+		startTime, ok := util.ConditionStartTime(i.Status.Conditions, seederv1alpha1.InventoryNodeMissing)
+		if !ok {
+			message := fmt.Sprintf("node with address %s not found in cluster %s", i.Status.Address, c.Name)
+			if err := r.updateNodeMissing(ctx, i, message); err != nil {
+				return err
+			}
+			r.Event(i, "Warning", "NodeMissing", message)
+			startTime = metav1.Now()
+		}
+
+		if time.Since(startTime.Time) < gracePeriod {
+			missing = append(missing, i.Name)
+			continue
+		}
+
+		switch c.Spec.MissingNodePolicy.Action {
+		case seederv1alpha1.MissingNodeActionReprovision:
+			if err := r.reprovisionMissingNode(ctx, i); err != nil {
+				return err
+			}
+			r.Event(i, "Normal", "MissingNodeReprovisioned", fmt.Sprintf("reprovisioning node missing from cluster %s for more than %s", c.Name, gracePeriod))
+		case seederv1alpha1.MissingNodeActionRelease:
+			released = append(released, i)
+		default:
+			missing = append(missing, i.Name)
+		}
+	}
+
+	if len(released) != 0 {
+		if err := r.releaseMissingNodes(ctx, c, released); err != nil {
+			return err
+		}
+	}
+
+	var message string
+	if len(missing) != 0 {
+		message = fmt.Sprintf("nodes of inventories %s are missing from the cluster", strings.Join(missing, ", "))
+	}
+
+	return r.updateNodesMissing(ctx, c, message)
+}
+
+// expectNode returns true if the inventory is provisioned as a node of the cluster, and is not being reinstalled
+func expectNode(c *seederv1alpha1.Cluster, i *seederv1alpha1.Inventory) bool {
+	if i.Status.Cluster.Name != c.Name || i.Status.Cluster.Namespace != c.Namespace || i.Status.Address == "" {
+		return false
+	}
+
+	if _, ok := i.Annotations[seederv1alpha1.ReprovisionAnnotation]; ok {
+		return false
+	}
+
+	return util.ConditionExists(i.Status.Conditions, seederv1alpha1.InventoryProvisioned) &&
+		!util.ConditionExists(i.Status.Conditions, seederv1alpha1.InventoryReprovisioning)
+}
+
+// awaitingJoin returns true if the inventory is installed by a provisioner which does not report the completion of
+// the install, and has yet to be marked as provisioned. Such inventories are provisioned once their node joins the cluster
+func awaitingJoin(c *seederv1alpha1.Cluster, i *seederv1alpha1.Inventory, p provisioner.Provisioner) bool {
+	if i.Status.Cluster.Name != c.Name || i.Status.Cluster.Namespace != c.Namespace || i.Status.Address == "" {
+		return false
+	}
+
+	if _, ok := i.Annotations[seederv1alpha1.ReprovisionAnnotation]; ok {
+		return false
+	}
+
+	return !p.ReportsCompletion(i) &&
+		util.ConditionExists(i.Status.Conditions, seederv1alpha1.InventoryAllocatedToCluster) &&
+		!util.ConditionExists(i.Status.Conditions, seederv1alpha1.InventoryProvisioned) &&
+		!util.ConditionExists(i.Status.Conditions, seederv1alpha1.InventoryReprovisioning)
+}
+
+// nodesJoining returns true if any inventory of the cluster is waiting for its node to join the cluster
+func (r *ClusterEventReconciler) nodesJoining(ctx context.Context, c *seederv1alpha1.Cluster) (bool, error) {
+	p, ok := r.Provisioners[provisioner.Name(c)]
+	if !ok {
+		return false, nil
+	}
+
+	for _, nc := range c.Spec.Nodes {
+		i := &seederv1alpha1.Inventory{}
+		if err := r.Get(ctx, types.NamespacedName{Namespace: nc.InventoryReference.Namespace,
+			Name: nc.InventoryReference.Name}, i); err != nil {
+			if apierrors.IsNotFound(err) {
+				continue
+			}
+			return false, err
+		}
+
+		if awaitingJoin(c, i, p) {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// markNodeJoined marks the inventory as provisioned once its node has joined the cluster
+func (r *ClusterEventReconciler) markNodeJoined(ctx context.Context, i *seederv1alpha1.Inventory) error {
+	return retry.RetryOnConflict(retry.DefaultRetry, func() error {
+		obj := &seederv1alpha1.Inventory{}
+		err := r.Get(ctx, types.NamespacedName{Namespace: i.Namespace, Name: i.Name}, obj)
+		if err != nil {
+			return err
+		}
+
+		obj.Status.Conditions = util.CreateOrUpdateCondition(obj.Status.Conditions, seederv1alpha1.InventoryProvisioned,
+			"node joined the cluster")
+		obj.Status.Conditions = util.RemoveCondition(obj.Status.Conditions, seederv1alpha1.InventoryNodeMissing)
+		return r.Status().Update(ctx, obj)
+	})
+}
+
+// updateNodeMissing sets the node missing condition on the inventory, or removes it when the message is empty
+func (r *ClusterEventReconciler) updateNodeMissing(ctx context.Context, i *seederv1alpha1.Inventory, message string) error {
+	return retry.RetryOnConflict(retry.DefaultRetry, func() error {
+		obj := &seederv1alpha1.Inventory{}
+		err := r.Get(ctx, types.NamespacedName{Namespace: i.Namespace, Name: i.Name}, obj)
+		if err != nil {
+			return err
+		}
+
+		if message == "" {
+			obj.Status.Conditions = util.RemoveCondition(obj.Status.Conditions, seederv1alpha1.InventoryNodeMissing)
+		} else {
+			obj.Status.Conditions = util.CreateOrUpdateCondition(obj.Status.Conditions, seederv1alpha1.InventoryNodeMissing, message)
+		}
+		return r.Status().Update(ctx, obj)
+	})
+}
+
+// reprovisionMissingNode annotates the inventory to be reinstalled by the cluster controller. The node missing
+// condition is removed, so the grace period starts again once the node has been reinstalled
+func (r *ClusterEventReconciler) reprovisionMissingNode(ctx context.Context, i *seederv1alpha1.Inventory) error {
+	err := retry.RetryOnConflict(retry.DefaultRetry, func() error {
+		obj := &seederv1alpha1.Inventory{}
+		err := r.Get(ctx, types.NamespacedName{Namespace: i.Namespace, Name: i.Name}, obj)
+		if err != nil {
+			return err
+		}
+
+		if obj.Annotations == nil {
+			obj.Annotations = make(map[string]string)
+		}
+		obj.Annotations[seederv1alpha1.ReprovisionAnnotation] = "true"
+		return r.Update(ctx, obj)
+	})
+	if err != nil {
+		return err
+	}
+
+	return r.updateNodeMissing(ctx, i, "")
+}
+
+// releaseMissingNodes removes the inventories from the nodes in the cluster spec, as inventories are only released
+// by the cluster controller once removed from the spec. The cluster controller releases the removed inventories
+// using their deletion policy
+func (r *ClusterEventReconciler) releaseMissingNodes(ctx context.Context, c *seederv1alpha1.Cluster, released []*seederv1alpha1.Inventory) error {
+	for _, i := range released {
+		if err := r.updateNodeMissing(ctx, i, ""); err != nil {
+			return err
+		}
+	}
+
+	err := retry.RetryOnConflict(retry.DefaultRetry, func() error {
+		obj := &seederv1alpha1.Cluster{}
+		err := r.Get(ctx, types.NamespacedName{Namespace: c.Namespace, Name: c.Name}, obj)
+		if err != nil {
+			return err
+		}
+
+		var nodes []seederv1alpha1.NodeConfig
+		for _, nc := range obj.Spec.Nodes {
+			if !containsInventory(released, nc.InventoryReference) {
+				nodes = append(nodes, nc)
+			}
+		}
+		obj.Spec.Nodes = nodes
+		if err := r.Update(ctx, obj); err != nil {
+			return err
+		}
+
+		c.Spec.Nodes = obj.Spec.Nodes
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	for _, i := range released {
+		r.Event(c, "Normal", "MissingNodeReleased", fmt.Sprintf("released inventory %s as its node is missing from the cluster", i.Name))
+	}
+	return nil
+}
+
+// updateNodesMissing sets the nodes missing condition on the cluster, or removes it when the message is empty. The
+// status is only updated when the message changes
+func (r *ClusterEventReconciler) updateNodesMissing(ctx context.Context, c *seederv1alpha1.Cluster, message string) error {
+	if util.ConditionMessage(c.Status.Conditions, seederv1alpha1.ClusterNodesMissing) == message {
+		return nil
+	}
+
+	return retry.RetryOnConflict(retry.DefaultRetry, func() error {
+		obj := &seederv1alpha1.Cluster{}
+		err := r.Get(ctx, types.NamespacedName{Namespace: c.Namespace, Name: c.Name}, obj)
+		if err != nil {
+			return err
+		}
+
+		if message == "" {
+			obj.Status.Conditions = util.RemoveCondition(obj.Status.Conditions, seederv1alpha1.ClusterNodesMissing)
+		} else {
+			obj.Status.Conditions = util.CreateOrUpdateCondition(obj.Status.Conditions, seederv1alpha1.ClusterNodesMissing, message)
+		}
+
+		if err := r.Status().Update(ctx, obj); err != nil {
+			return err
+		}
+
+		c.Status.Conditions = obj.Status.Conditions
+		return nil
+	})
+}
+
+func containsInventory(inventories []*seederv1alpha1.Inventory, ref seederv1alpha1.ObjectReference) bool {
+	for _, i := range inventories {
+		if i.Namespace == ref.Namespace && i.Name == ref.Name {
+			return true
+		}
+	}
+	return false
+}
+
+// updateNodes propagates the inventory labels and annotations selected by the propagation policy to the node of each
+// inventory in the cluster. Nodes of inventories with events enabled are also labelled with the hardware details and
+// health read from the BMC
 func (r *ClusterEventReconciler) updateNodes(ctx context.Context, c *seederv1alpha1.Cluster) error {
 	typedClient, err := genCoreTypedClient(ctx, c)
 	if err != nil {
@@ -77,7 +408,7 @@ func (r *ClusterEventReconciler) updateNodes(ctx context.Context, c *seederv1alp
 	}
 
 	if len(inventoryList) == 0 {
-		// no nodes have event collection enabled. nothing to do
+		// no nodes have been provisioned. nothing to do
 		return nil
 	}
 
@@ -86,67 +417,106 @@ func (r *ClusterEventReconciler) updateNodes(ctx context.Context, c *seederv1alp
 		return err
 	}
 
+	recorder := remoteEventRecorder(typedClient, r.Scheme)
 	// associate k8s node with inventory using the address allocated to inventory by cluster
 	// this should make it easy to uniquely identify nodes in the cluster
 	for _, i := range inventoryList {
 		node := findNodeByIP(nodeList.Items, i.Status.Address)
-		if node != nil {
-			s := &corev1.Secret{}
-			err := r.Get(ctx, types.NamespacedName{Namespace: i.Spec.BaseboardManagementSpec.Connection.AuthSecretRef.Namespace,
-				Name: i.Spec.BaseboardManagementSpec.Connection.AuthSecretRef.Name}, s)
-			if err != nil {
-				return err
-			}
-			username := s.Data["username"]
-			password := s.Data["password"]
-			bmcendpoint := fmt.Sprintf("https://%s", i.Spec.BaseboardManagementSpec.Connection.Host)
-			if port, ok := i.Labels[seederv1alpha1.OverrideRedfishPortLabel]; ok {
-				bmcendpoint = fmt.Sprintf("https://%s:%s", i.Spec.BaseboardManagementSpec.Connection.Host, port)
-			}
-			e, err := events.NewEventFetcher(ctx, string(username), string(password), bmcendpoint)
-			if err != nil {
-				return err
-			}
-			labels, status, err := e.GetConfig()
+		if node == nil {
+			continue
+		}
+		original := node.DeepCopy()
+
+		var facts map[string]string
+		var health string
+		var added, removed bool
+		if i.Spec.Events.Enabled {
+			health, facts, err = r.applyHardwareConfig(ctx, c, i, node)
 			if err != nil {
 				return err
 			}
+			added, removed = taintNode(node, health, c.Spec.NodeHealthPolicy.TaintDegradedNodes)
+		}
 
-			if node.Labels == nil {
-				node.Labels = make(map[string]string)
-			}
-			for k, v := range labels {
-				node.Labels[k] = v
-			}
+		if skipped := util.PropagateToNode(node, i, c.Spec.NodePropagationPolicy, facts); len(skipped) != 0 {
+			r.Info("unable to propagate invalid keys to node", "inventory", i.Name, "node", node.Name, "keys", skipped)
+		}
 
-			updatedNode, err := typedClient.Nodes().Update(ctx, node, metav1.UpdateOptions{})
+		if !equality.Semantic.DeepEqual(original, node) {
+			node, err = typedClient.Nodes().Update(ctx, node, metav1.UpdateOptions{})
 			if err != nil {
 				return err
 			}
+		}
 
-			recorder := remoteEventRecorder(typedClient, r.Scheme)
-			var update string
-			if status == "OK" {
-				update = "Normal"
-			} else {
-				update = "Warning"
-			}
-			recorder.Event(updatedNode, update, "SeederUpdated", fmt.Sprintf("Underlying inventory %s status is %s", i.Name, status))
+		if !i.Spec.Events.Enabled {
+			continue
+		}
+
+		if added {
+			recorder.Event(node, "Warning", "HardwareDegraded", fmt.Sprintf("added taint %s as the hardware health of inventory %s is degraded", seederv1alpha1.HardwareDegradedTaint, i.Name))
+		}
+		if removed {
+			recorder.Event(node, "Normal", "HardwareRecovered", fmt.Sprintf("removed taint %s as the hardware health of inventory %s is no longer degraded", seederv1alpha1.HardwareDegradedTaint, i.Name))
+		}
+		var update string
+		if health == "OK" {
+			update = "Normal"
+		} else {
+			update = "Warning"
 		}
+		recorder.Event(node, update, "SeederUpdated", fmt.Sprintf("Underlying inventory %s status is %s", i.Name, health))
 	}
 	return nil
 }
 
+// applyHardwareConfig reads the hardware details of the inventory from its BMC, and labels and annotates the node
+// with them. The hardware health of the inventory, and the hardware facts selected by the propagation policy are
+// returned
+func (r *ClusterEventReconciler) applyHardwareConfig(ctx context.Context, c *seederv1alpha1.Cluster, i *seederv1alpha1.Inventory, node *corev1.Node) (string, map[string]string, error) {
+	username, password, err := util.FetchBMCCredentials(ctx, r.Client, i)
+	if err != nil {
+		return "", nil, err
+	}
+	e, err := events.NewEventFetcher(ctx, username, password, util.RedfishEndpoint(i))
+	if err != nil {
+		return "", nil, err
+	}
+	labels, status, err := e.GetConfig()
+	if err != nil {
+		return "", nil, err
+	}
+
+	// health in the inventory status includes the sensors, and is more accurate than the chassis status
+	health := nodeHealth(i, status)
+	if node.Labels == nil {
+		node.Labels = make(map[string]string)
+	}
+	labels[util.HardwareHealth] = health
+	r.HardwareLabels.Apply(node.Labels, labels)
+	annotateNode(node, i)
+
+	var facts map[string]string
+	if len(c.Spec.NodePropagationPolicy.HardwareFacts) != 0 {
+		facts, err = e.GetHardwareFacts()
+		if err != nil {
+			return "", nil, err
+		}
+	}
+	return health, facts, nil
+}
+
+// identifyInventory returns the inventories allocated to the cluster which have been allocated an address, and can
+// be associated with a node
 func (r *ClusterEventReconciler) identifyInventory(ctx context.Context, c *seederv1alpha1.Cluster) ([]*seederv1alpha1.Inventory, error) {
 	var retNodes []*seederv1alpha1.Inventory
-	// identify nodes for which event collection is enabled
 	for _, v := range c.Spec.Nodes {
 		nodeObj := &seederv1alpha1.Inventory{}
 		err := r.Get(ctx, types.NamespacedName{Namespace: v.InventoryReference.Namespace, Name: v.InventoryReference.Name}, nodeObj)
 		if err != nil {
 			return nil, err
 		}
-		if nodeObj.Spec.Events.Enabled {
+		if nodeObj.Status.Cluster.Name == c.Name && nodeObj.Status.Cluster.Namespace == c.Namespace && nodeObj.Status.Address != "" {
 			retNodes = append(retNodes, nodeObj)
 		}
 	}
@@ -167,6 +537,56 @@ func findNodeByIP(nodeList []corev1.Node, address string) *corev1.Node {
 	return nil
 }
 
+// nodeHealth returns the hardware health in the inventory status, falling back to the chassis health read from the BMC
+// until the inventory has been polled
+func nodeHealth(i *seederv1alpha1.Inventory, status string) string {
+	if i.Status.Health != nil && i.Status.Health.Status != "" {
+		return string(i.Status.Health.Status)
+	}
+	return status
+}
+
+// annotateNode references the inventory and its BMC address from the node, for looking up the inventory of a node
+func annotateNode(node *corev1.Node, i *seederv1alpha1.Inventory) {
+	if node.Annotations == nil {
+		node.Annotations = make(map[string]string)
+	}
+	node.Annotations[seederv1alpha1.NodeInventoryAnnotation] = fmt.Sprintf("%s/%s", i.Namespace, i.Name)
+	node.Annotations[seederv1alpha1.NodeBMCAddressAnnotation] = i.Spec.Connection.Host
+}
+
+// taintNode adds the hardware degraded taint to the node while the health is Warning or Critical and degraded nodes
+// are tainted, and removes it otherwise. The value of the taint is the health
+func taintNode(node *corev1.Node, health string, taintDegraded bool) (added bool, removed bool) {
+	degraded := taintDegraded && (health == string(seederv1alpha1.HealthWarning) || health == string(seederv1alpha1.HealthCritical))
+
+	var taints []corev1.Taint
+	var found bool
+	for _, t := range node.Spec.Taints {
+		if t.Key != seederv1alpha1.HardwareDegradedTaint {
+			taints = append(taints, t)
+			continue
+		}
+
+		found = true
+		if degraded {
+			t.Value = health
+			taints = append(taints, t)
+		}
+	}
+
+	if degraded && !found {
+		taints = append(taints, corev1.Taint{
+			Key:    seederv1alpha1.HardwareDegradedTaint,
+			Value:  health,
+			Effect: corev1.TaintEffectNoSchedule,
+		})
+	}
+
+	node.Spec.Taints = taints
+	return degraded && !found, !degraded && found
+}
+
 func remoteEventRecorder(c *typedCore.CoreV1Client, scheme *runtime.Scheme) record.EventRecorder {
 	eventBroadcaster := record.NewBroadcaster()
 	eventBroadcaster.StartStructuredLogging(0)
@@ -177,7 +597,42 @@ func remoteEventRecorder(c *typedCore.CoreV1Client, scheme *runtime.Scheme) reco
 
 // SetupWithManager sets up the controller with the Manager.
 func (r *ClusterEventReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	if r.Provisioners == nil {
+		r.Provisioners = provisioner.NewDefaultProvisioners(r.Client, r.Scheme)
+	}
+
 	return ctrl.NewControllerManagedBy(mgr).
 		For(&seederv1alpha1.Cluster{}).
+		// nodes are updated when the hardware health, labels or annotations of their inventory change
+		Watches(&source.Kind{Type: &seederv1alpha1.Inventory{}}, handler.EnqueueRequestsFromMapFunc(func(a client.Object) []reconcile.Request {
+			i, ok := a.(*seederv1alpha1.Inventory)
+			if !ok || i.Status.Cluster.Name == "" {
+				return nil
+			}
+			return []reconcile.Request{{
+				NamespacedName: types.NamespacedName{
+					Namespace: i.Status.Cluster.Namespace,
+					Name:      i.Status.Cluster.Name,
+				},
+			}}
+		}), builder.WithPredicates(predicate.Funcs{
+			CreateFunc:  func(event.CreateEvent) bool { return false },
+			DeleteFunc:  func(event.DeleteEvent) bool { return false },
+			GenericFunc: func(event.GenericEvent) bool { return false },
+			UpdateFunc: func(e event.UpdateEvent) bool {
+				oldObj, ok := e.ObjectOld.(*seederv1alpha1.Inventory)
+				if !ok {
+					return false
+				}
+				newObj, ok := e.ObjectNew.(*seederv1alpha1.Inventory)
+				if !ok {
+					return false
+				}
+				// labels and annotations are propagated to the node
+				return !reflect.DeepEqual(oldObj.Status.Health, newObj.Status.Health) ||
+					!reflect.DeepEqual(oldObj.Labels, newObj.Labels) ||
+					!reflect.DeepEqual(oldObj.Annotations, newObj.Annotations)
+			},
+		})).
 		Complete(r)
 }
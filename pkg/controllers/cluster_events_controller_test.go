@@ -2,8 +2,11 @@ package controllers
 
 import (
 	"fmt"
+	"strings"
 
 	seederv1alpha1 "github.com/harvester/seeder/pkg/api/v1alpha1"
+	"github.com/harvester/seeder/pkg/provisioner"
+	"github.com/harvester/seeder/pkg/util"
 	. "github.com/onsi/ginkgo/v2"
 	. "github.com/onsi/gomega"
 	"github.com/ory/dockertest/v3"
@@ -13,6 +16,7 @@ import (
 	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/types"
+	ctrlmetrics "sigs.k8s.io/controller-runtime/pkg/metrics"
 )
 
 var _ = Describe("cluster events test", func() {
@@ -267,9 +271,12 @@ var _ = Describe("cluster events test", func() {
 				for _, a := range v.Status.Addresses {
 					if a.Address == iObj.Status.Address {
 						found = true
-						if _, ok := v.Labels["manufacturer"]; !ok {
+						if _, ok := v.Labels[util.DefaultHardwareLabelPrefix+util.HardwareManufacturer]; !ok {
 							return fmt.Errorf("waiting for manufacturer to be populated")
 						}
+						if v.Annotations[seederv1alpha1.NodeInventoryAnnotation] != fmt.Sprintf("%s/%s", iObj.Namespace, iObj.Name) {
+							return fmt.Errorf("waiting for inventory annotation to be populated")
+						}
 					}
 				}
 			}
@@ -281,6 +288,71 @@ var _ = Describe("cluster events test", func() {
 		}, "120s", "5s").ShouldNot(HaveOccurred())
 	})
 
+	It("propagate inventory labels to nodes of inventories without events enabled", func() {
+		// nodeLabel returns the propagated rack label of the node matching the inventory address
+		nodeLabel := func() (string, error) {
+			cObj := &seederv1alpha1.Cluster{}
+			if err := k8sClient.Get(ctx, types.NamespacedName{Namespace: c.Namespace, Name: c.Name}, cObj); err != nil {
+				return "", err
+			}
+
+			if cObj.Status.Status != seederv1alpha1.ClusterRunning {
+				return "", fmt.Errorf("expected cluster to running but current status is %s", cObj.Status.Status)
+			}
+
+			remoteClient, err := genCoreTypedClient(ctx, cObj)
+			if err != nil {
+				return "", err
+			}
+
+			nodeList, err := remoteClient.Nodes().List(ctx, metav1.ListOptions{})
+			if err != nil {
+				return "", err
+			}
+
+			node := findNodeByIP(nodeList.Items, address)
+			if node == nil {
+				return "", fmt.Errorf("waiting to find node matching ip address allocated to inventory %s", address)
+			}
+			return node.Labels[seederv1alpha1.PropagationPrefix+"rack"], nil
+		}
+
+		Eventually(func() error {
+			iObj := &seederv1alpha1.Inventory{}
+			if err := k8sClient.Get(ctx, types.NamespacedName{Namespace: i.Namespace, Name: i.Name}, iObj); err != nil {
+				return err
+			}
+			iObj.Labels = map[string]string{
+				"rack": "r1",
+			}
+			iObj.Spec.Events.Enabled = false
+			return k8sClient.Update(ctx, iObj)
+		}, "30s", "5s").ShouldNot(HaveOccurred())
+
+		Eventually(func() error {
+			cObj := &seederv1alpha1.Cluster{}
+			if err := k8sClient.Get(ctx, types.NamespacedName{Namespace: c.Namespace, Name: c.Name}, cObj); err != nil {
+				return err
+			}
+			cObj.Spec.NodePropagationPolicy.Labels = []string{"rack"}
+			return k8sClient.Update(ctx, cObj)
+		}, "30s", "5s").ShouldNot(HaveOccurred())
+
+		Eventually(nodeLabel, "120s", "5s").Should(Equal("r1"))
+
+		// label changes on the inventory are propagated without waiting for the periodic resync
+		Eventually(func() error {
+			iObj := &seederv1alpha1.Inventory{}
+			if err := k8sClient.Get(ctx, types.NamespacedName{Namespace: i.Namespace, Name: i.Name}, iObj); err != nil {
+				return err
+			}
+			iObj.Labels["rack"] = "r2"
+			return k8sClient.Update(ctx, iObj)
+		}, "30s", "5s").ShouldNot(HaveOccurred())
+
+		Eventually(nodeLabel, "60s", "5s").Should(Equal("r2"))
+	})
+
 	AfterEach(func() {
 
 		Eventually(func() error {
@@ -332,3 +404,863 @@ var _ = Describe("cluster events test", func() {
 	})
 
 })
+
+var _ = Describe("node hardware health taint tests", func() {
+	It("taints nodes only while the hardware health is degraded", func() {
+		node := &corev1.Node{
+			Spec: corev1.NodeSpec{
+				Taints: []corev1.Taint{
+					{Key: "existing", Effect: corev1.TaintEffectNoExecute},
+				},
+			},
+		}
+
+		added, removed := taintNode(node, "OK", true)
+		Expect(added || removed).To(BeFalse())
+		Expect(node.Spec.Taints).To(HaveLen(1))
+
+		added, removed = taintNode(node, "Warning", false)
+		Expect(added || removed).To(BeFalse())
+		Expect(node.Spec.Taints).To(HaveLen(1))
+
+		added, _ = taintNode(node, "Warning", true)
+		Expect(added).To(BeTrue())
+		Expect(node.Spec.Taints).To(ContainElement(corev1.Taint{Key: seederv1alpha1.HardwareDegradedTaint, Value: "Warning", Effect: corev1.TaintEffectNoSchedule}))
+
+		added, removed = taintNode(node, "Critical", true)
+		Expect(added || removed).To(BeFalse())
+		Expect(node.Spec.Taints).To(ContainElement(corev1.Taint{Key: seederv1alpha1.HardwareDegradedTaint, Value: "Critical", Effect: corev1.TaintEffectNoSchedule}))
+
+		_, removed = taintNode(node, "OK", true)
+		Expect(removed).To(BeTrue())
+		Expect(node.Spec.Taints).To(Equal([]corev1.Taint{{Key: "existing", Effect: corev1.TaintEffectNoExecute}}))
+	})
+
+	It("reports the inventory health in preference to the chassis status", func() {
+		i := &seederv1alpha1.Inventory{}
+		Expect(nodeHealth(i, "OK")).To(Equal("OK"))
+
+		i.Status.Health = &seederv1alpha1.HardwareHealth{Status: seederv1alpha1.HealthCritical}
+		Expect(nodeHealth(i, "OK")).To(Equal(string(seederv1alpha1.HealthCritical)))
+	})
+})
+
+var _ = Describe("missing node detection tests", func() {
+	It("only expects nodes for provisioned inventories which are not being reinstalled", func() {
+		c := &seederv1alpha1.Cluster{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "missing-node",
+				Namespace: "default",
+			},
+		}
+
+		i := &seederv1alpha1.Inventory{
+			Status: seederv1alpha1.InventoryStatus{
+				Cluster: seederv1alpha1.ObjectReference{
+					Name:      "missing-node",
+					Namespace: "default",
+				},
+			},
+		}
+		i.Status.Address = "192.168.1.10"
+		Expect(expectNode(c, i)).To(BeFalse())
+
+		i.Status.Conditions = util.CreateOrUpdateCondition(i.Status.Conditions, seederv1alpha1.InventoryProvisioned, "")
+		Expect(expectNode(c, i)).To(BeTrue())
+
+		i.Annotations = map[string]string{seederv1alpha1.ReprovisionAnnotation: "true"}
+		Expect(expectNode(c, i)).To(BeFalse())
+
+		i.Annotations = nil
+		i.Status.Conditions = util.CreateOrUpdateCondition(i.Status.Conditions, seederv1alpha1.InventoryReprovisioning, "node reprovision triggered")
+		Expect(expectNode(c, i)).To(BeFalse())
+
+		i.Status.Conditions = util.RemoveCondition(i.Status.Conditions, seederv1alpha1.InventoryReprovisioning)
+		i.Status.Cluster.Name = "other-cluster"
+		Expect(expectNode(c, i)).To(BeFalse())
+	})
+
+	It("waits for nodes to join when the provisioner does not report install completion", func() {
+		c := &seederv1alpha1.Cluster{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "missing-node",
+				Namespace: "default",
+			},
+		}
+
+		i := &seederv1alpha1.Inventory{
+			Status: seederv1alpha1.InventoryStatus{
+				Cluster: seederv1alpha1.ObjectReference{
+					Name:      "missing-node",
+					Namespace: "default",
+				},
+			},
+		}
+		i.Status.Address = "192.168.1.10"
+		i.Status.Conditions = util.CreateOrUpdateCondition(i.Status.Conditions, seederv1alpha1.InventoryAllocatedToCluster, "")
+		Expect(awaitingJoin(c, i, &provisioner.Tinkerbell{})).To(BeTrue())
+		Expect(awaitingJoin(c, i, provisioner.NewFake())).To(BeFalse())
+
+		i.Status.Conditions = util.CreateOrUpdateCondition(i.Status.Conditions, seederv1alpha1.InventoryReprovisioning, "node reprovision triggered")
+		Expect(awaitingJoin(c, i, &provisioner.Tinkerbell{})).To(BeFalse())
+
+		i.Status.Conditions = util.RemoveCondition(i.Status.Conditions, seederv1alpha1.InventoryReprovisioning)
+		i.Status.Conditions = util.CreateOrUpdateCondition(i.Status.Conditions, seederv1alpha1.InventoryProvisioned, "node joined the cluster")
+		Expect(awaitingJoin(c, i, &provisioner.Tinkerbell{})).To(BeFalse())
+	})
+})
+
+var _ = Describe("missing node policy tests", func() {
+	var i *seederv1alpha1.Inventory
+	var c *seederv1alpha1.Cluster
+	var a *seederv1alpha1.AddressPool
+	var s *corev1.Secret
+	var k3sMock *dockertest.Resource
+
+	// setPolicy updates the missing node policy of the cluster
+	setPolicy := func(action seederv1alpha1.MissingNodeAction) {
+		Eventually(func() error {
+			cObj := &seederv1alpha1.Cluster{}
+			if err := k8sClient.Get(ctx, types.NamespacedName{Namespace: c.Namespace, Name: c.Name}, cObj); err != nil {
+				return err
+			}
+			cObj.Spec.MissingNodePolicy.Action = action
+			return k8sClient.Update(ctx, cObj)
+		}, "30s", "5s").ShouldNot(HaveOccurred())
+	}
+
+	// waitForMissingNode waits for the node of the inventory to be reported as missing, and returns the inventory
+	waitForMissingNode := func() *seederv1alpha1.Inventory {
+		iObj := &seederv1alpha1.Inventory{}
+		Eventually(func() error {
+			if err := k8sClient.Get(ctx, types.NamespacedName{Namespace: i.Namespace, Name: i.Name}, iObj); err != nil {
+				return err
+			}
+
+			if !util.ConditionExists(iObj.Status.Conditions, seederv1alpha1.InventoryNodeMissing) {
+				return fmt.Errorf("waiting for inventory node to be reported missing %v", iObj.Status.Conditions)
+			}
+			return nil
+		}, "120s", "5s").ShouldNot(HaveOccurred())
+		return iObj
+	}
+
+	BeforeEach(func() {
+		a = &seederv1alpha1.AddressPool{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "missing-node-test",
+				Namespace: "default",
+			},
+			Spec: seederv1alpha1.AddressSpec{
+				CIDR:    "127.0.0.1/8",
+				Gateway: "127.0.0.1",
+			},
+		}
+
+		i = &seederv1alpha1.Inventory{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "missing-node",
+				Namespace: "default",
+			},
+			Spec: seederv1alpha1.InventorySpec{
+				PrimaryDisk:                   "/dev/sda",
+				ManagementInterfaceMacAddress: "xx:xx:xx:xx:xx",
+				BaseboardManagementSpec: rufio.BaseboardManagementSpec{
+					Connection: rufio.Connection{
+						Host:        "localhost",
+						Port:        623,
+						InsecureTLS: true,
+						AuthSecretRef: corev1.SecretReference{
+							Name:      "missing-node",
+							Namespace: "default",
+						},
+					},
+				},
+			},
+		}
+
+		s = &corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "missing-node",
+				Namespace: "default",
+			},
+			StringData: map[string]string{
+				"username": "root",
+				"password": "calvin",
+			},
+		}
+
+		c = &seederv1alpha1.Cluster{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "missing-node-cluster",
+				Namespace: "default",
+			},
+			Spec: seederv1alpha1.ClusterSpec{
+				HarvesterVersion: "harvester_1_0_2",
+				Provisioner:      provisioner.FakeProvisioner,
+				Nodes: []seederv1alpha1.NodeConfig{
+					{
+						InventoryReference: seederv1alpha1.ObjectReference{
+							Name:      "missing-node",
+							Namespace: "default",
+						},
+						AddressPoolReference: seederv1alpha1.ObjectReference{
+							Name:      "missing-node-test",
+							Namespace: "default",
+						},
+					},
+				},
+				VIPConfig: seederv1alpha1.VIPConfig{
+					AddressPoolReference: seederv1alpha1.ObjectReference{
+						Name:      "missing-node-test",
+						Namespace: "default",
+					},
+				},
+				ClusterConfig: seederv1alpha1.ClusterConfig{
+					SSHKeys: []string{
+						"abc",
+						"def",
+					},
+					ConfigURL: "localhost:30300/config.yaml",
+				},
+				MissingNodePolicy: seederv1alpha1.MissingNodePolicy{
+					GracePeriod: "20s",
+				},
+			},
+		}
+
+		Eventually(func() error {
+			return k8sClient.Create(ctx, a)
+		}, "30s", "5s").ShouldNot(HaveOccurred())
+
+		Eventually(func() error {
+			return k8sClient.Create(ctx, s)
+		}, "30s", "5s").ShouldNot(HaveOccurred())
+
+		Eventually(func() error {
+			return k8sClient.Create(ctx, i)
+		}, "30s", "5s").ShouldNot(HaveOccurred())
+
+		Eventually(func() error {
+			return k8sClient.Create(ctx, c)
+		}, "30s", "5s").ShouldNot(HaveOccurred())
+
+		// the install of the node is reported as completed by the fake provisioner
+		Eventually(func() error {
+			iObj := &seederv1alpha1.Inventory{}
+			if err := k8sClient.Get(ctx, types.NamespacedName{Namespace: i.Namespace, Name: i.Name}, iObj); err != nil {
+				return err
+			}
+
+			if !fakeProvisioner.Provisioned(iObj) {
+				return fmt.Errorf("waiting for node to be provisioned by fake provisioner")
+			}
+			fakeProvisioner.SetProgress(iObj, provisioner.Progress{Phase: provisioner.PhaseCompleted})
+			return nil
+		}, "60s", "5s").ShouldNot(HaveOccurred())
+
+		// the k3s mock node does not use the address allocated to the inventory, so the node of the inventory is missing
+		Eventually(func() error {
+			cObj := &seederv1alpha1.Cluster{}
+			err := k8sClient.Get(ctx, types.NamespacedName{Namespace: c.Namespace, Name: c.Name}, cObj)
+			if err != nil {
+				return err
+			}
+
+			if cObj.Status.ClusterToken == "" {
+				return fmt.Errorf("waiting for cluster token to be generated")
+			}
+
+			k3sMock, err = pool.RunWithOptions(&dockertest.RunOptions{
+				Name:       "k3s-mock",
+				Repository: "rancher/k3s",
+				Tag:        "v1.24.2-k3s1",
+				Cmd:        []string{"server", "--cluster-init"},
+				Env: []string{
+					fmt.Sprintf("K3S_TOKEN=%s", cObj.Status.ClusterToken),
+				},
+				Mounts: []string{
+					"tmpfs:/run",
+					"tmpfs:/var/run",
+				},
+				Privileged: true,
+				ExposedPorts: []string{
+					"6443/tcp",
+				},
+			}, func(config *docker.HostConfig) {
+				config.RestartPolicy = docker.RestartPolicy{
+					Name: "no",
+				}
+			})
+			if err != nil {
+				return err
+			}
+
+			if cObj.Labels == nil {
+				cObj.Labels = make(map[string]string)
+			}
+
+			// since mock node is k3s, need to change prefix from rke2 to k3s
+			seederv1alpha1.DefaultAPIPrefix = "k3s"
+			cObj.Labels[seederv1alpha1.OverrideAPIPortLabel] = k3sMock.GetPort("6443/tcp")
+			return k8sClient.Update(ctx, cObj)
+		}, "30s", "5s").ShouldNot(HaveOccurred())
+
+		Eventually(func() error {
+			cObj := &seederv1alpha1.Cluster{}
+			if err := k8sClient.Get(ctx, types.NamespacedName{Namespace: c.Namespace, Name: c.Name}, cObj); err != nil {
+				return err
+			}
+
+			if cObj.Status.Status != seederv1alpha1.ClusterRunning {
+				return fmt.Errorf("waiting for cluster to be running. current status is %s", cObj.Status.Status)
+			}
+
+			iObj := &seederv1alpha1.Inventory{}
+			if err := k8sClient.Get(ctx, types.NamespacedName{Namespace: i.Namespace, Name: i.Name}, iObj); err != nil {
+				return err
+			}
+
+			if !util.ConditionExists(iObj.Status.Conditions, seederv1alpha1.InventoryProvisioned) {
+				return fmt.Errorf("waiting for inventory to be provisioned %v", iObj.Status.Conditions)
+			}
+			return nil
+		}, "120s", "5s").ShouldNot(HaveOccurred())
+	})
+
+	It("report missing nodes until the node is found again", func() {
+		iObj := waitForMissingNode()
+		startTime, ok := util.ConditionStartTime(iObj.Status.Conditions, seederv1alpha1.InventoryNodeMissing)
+		Expect(ok).To(BeTrue())
+
+		Eventually(func() error {
+			cObj := &seederv1alpha1.Cluster{}
+			if err := k8sClient.Get(ctx, types.NamespacedName{Namespace: c.Namespace, Name: c.Name}, cObj); err != nil {
+				return err
+			}
+
+			if !strings.Contains(util.ConditionMessage(cObj.Status.Conditions, seederv1alpha1.ClusterNodesMissing), i.Name) {
+				return fmt.Errorf("waiting for cluster to report missing node %v", cObj.Status.Conditions)
+			}
+			return nil
+		}, "60s", "5s").ShouldNot(HaveOccurred())
+
+		// without an action the missing node is only reported after the grace period, and the start time is kept
+		Consistently(func() error {
+			cObj := &seederv1alpha1.Cluster{}
+			if err := k8sClient.Get(ctx, types.NamespacedName{Namespace: c.Namespace, Name: c.Name}, cObj); err != nil {
+				return err
+			}
+
+			if len(cObj.Spec.Nodes) != 1 {
+				return fmt.Errorf("expected inventory to not be released")
+			}
+
+			iObj := &seederv1alpha1.Inventory{}
+			if err := k8sClient.Get(ctx, types.NamespacedName{Namespace: i.Namespace, Name: i.Name}, iObj); err != nil {
+				return err
+			}
+
+			if _, ok := iObj.Annotations[seederv1alpha1.ReprovisionAnnotation]; ok {
+				return fmt.Errorf("expected inventory to not be reprovisioned")
+			}
+
+			current, ok := util.ConditionStartTime(iObj.Status.Conditions, seederv1alpha1.InventoryNodeMissing)
+			if !ok || !current.Equal(&startTime) {
+				return fmt.Errorf("expected node missing condition to be kept %v", iObj.Status.Conditions)
+			}
+			return nil
+		}, "30s", "5s").ShouldNot(HaveOccurred())
+
+		// register a node with the inventory address in the k3s mock
+		Eventually(func() error {
+			cObj := &seederv1alpha1.Cluster{}
+			if err := k8sClient.Get(ctx, types.NamespacedName{Namespace: c.Namespace, Name: c.Name}, cObj); err != nil {
+				return err
+			}
+
+			typedClient, err := genCoreTypedClient(ctx, cObj)
+			if err != nil {
+				return err
+			}
+
+			node, err := typedClient.Nodes().Get(ctx, i.Name, metav1.GetOptions{})
+			if err != nil {
+				if !apierrors.IsNotFound(err) {
+					return err
+				}
+				node, err = typedClient.Nodes().Create(ctx, &corev1.Node{ObjectMeta: metav1.ObjectMeta{Name: i.Name}}, metav1.CreateOptions{})
+				if err != nil {
+					return err
+				}
+			}
+
+			node.Status.Addresses = []corev1.NodeAddress{
+				{
+					Type:    corev1.NodeInternalIP,
+					Address: iObj.Status.Address,
+				},
+			}
+			_, err = typedClient.Nodes().UpdateStatus(ctx, node, metav1.UpdateOptions{})
+			return err
+		}, "30s", "5s").ShouldNot(HaveOccurred())
+
+		Eventually(func() error {
+			iObj := &seederv1alpha1.Inventory{}
+			if err := k8sClient.Get(ctx, types.NamespacedName{Namespace: i.Namespace, Name: i.Name}, iObj); err != nil {
+				return err
+			}
+
+			if util.ConditionExists(iObj.Status.Conditions, seederv1alpha1.InventoryNodeMissing) {
+				return fmt.Errorf("waiting for node missing condition to be removed %v", iObj.Status.Conditions)
+			}
+
+			cObj := &seederv1alpha1.Cluster{}
+			if err := k8sClient.Get(ctx, types.NamespacedName{Namespace: c.Namespace, Name: c.Name}, cObj); err != nil {
+				return err
+			}
+
+			if util.ConditionExists(cObj.Status.Conditions, seederv1alpha1.ClusterNodesMissing) {
+				return fmt.Errorf("waiting for nodes missing condition to be removed %v", cObj.Status.Conditions)
+			}
+			return nil
+		}, "120s", "5s").ShouldNot(HaveOccurred())
+	})
+
+	It("reprovision missing nodes after the grace period", func() {
+		setPolicy(seederv1alpha1.MissingNodeActionReprovision)
+		password := waitForMissingNode().Status.GeneratedPassword
+
+		// the node is reinstalled with new credentials once the grace period has passed
+		Eventually(func() error {
+			iObj := &seederv1alpha1.Inventory{}
+			if err := k8sClient.Get(ctx, types.NamespacedName{Namespace: i.Namespace, Name: i.Name}, iObj); err != nil {
+				return err
+			}
+
+			if iObj.Status.GeneratedPassword == password {
+				return fmt.Errorf("waiting for missing node to be reprovisioned")
+			}
+			return nil
+		}, "120s", "5s").ShouldNot(HaveOccurred())
+
+		Eventually(func() error {
+			cObj := &seederv1alpha1.Cluster{}
+			if err := k8sClient.Get(ctx, types.NamespacedName{Namespace: c.Namespace, Name: c.Name}, cObj); err != nil {
+				return err
+			}
+
+			if len(cObj.Spec.Nodes) != 1 {
+				return fmt.Errorf("expected reprovisioned inventory to remain in the cluster")
+			}
+			return nil
+		}, "30s", "5s").ShouldNot(HaveOccurred())
+	})
+
+	It("release missing nodes after the grace period", func() {
+		setPolicy(seederv1alpha1.MissingNodeActionRelease)
+		waitForMissingNode()
+
+		Eventually(func() error {
+			cObj := &seederv1alpha1.Cluster{}
+			if err := k8sClient.Get(ctx, types.NamespacedName{Namespace: c.Namespace, Name: c.Name}, cObj); err != nil {
+				return err
+			}
+
+			if len(cObj.Spec.Nodes) != 0 {
+				return fmt.Errorf("waiting for missing node to be removed from the cluster spec")
+			}
+
+			iObj := &seederv1alpha1.Inventory{}
+			if err := k8sClient.Get(ctx, types.NamespacedName{Namespace: i.Namespace, Name: i.Name}, iObj); err != nil {
+				return err
+			}
+
+			if util.ConditionExists(iObj.Status.Conditions, seederv1alpha1.InventoryAllocatedToCluster) {
+				return fmt.Errorf("waiting for inventory to be released %v", iObj.Status.Conditions)
+			}
+
+			if util.ConditionExists(iObj.Status.Conditions, seederv1alpha1.InventoryNodeMissing) {
+				return fmt.Errorf("expected node missing condition to be removed from released inventory")
+			}
+			return nil
+		}, "120s", "5s").ShouldNot(HaveOccurred())
+	})
+
+	AfterEach(func() {
+		Eventually(func() error {
+			return k8sClient.Delete(ctx, c)
+		}, "30s", "5s").ShouldNot(HaveOccurred())
+
+		Eventually(func() error {
+			return k8sClient.Delete(ctx, i)
+		}, "30s", "5s").ShouldNot(HaveOccurred())
+
+		Eventually(func() error {
+			return k8sClient.Delete(ctx, s)
+		}, "30s", "5s").ShouldNot(HaveOccurred())
+
+		Eventually(func() error {
+			return k8sClient.Delete(ctx, a)
+		}, "30s", "5s").ShouldNot(HaveOccurred())
+
+		Eventually(func() error {
+			cObj := &seederv1alpha1.Cluster{}
+			err := k8sClient.Get(ctx, types.NamespacedName{Namespace: c.Namespace, Name: c.Name}, cObj)
+			if err != nil {
+				if apierrors.IsNotFound(err) {
+					return nil
+				}
+				return err
+			}
+
+			return fmt.Errorf("waiting for cluster finalizers to finish")
+		}, "30s", "5s").ShouldNot(HaveOccurred())
+
+		Eventually(func() error {
+			return pool.Purge(k3sMock)
+		}, "30s", "5s").ShouldNot(HaveOccurred())
+	})
+})
+
+var _ = Describe("missing node detection without install completion tests", func() {
+	var i *seederv1alpha1.Inventory
+	var c *seederv1alpha1.Cluster
+	var a *seederv1alpha1.AddressPool
+	var s *corev1.Secret
+	var k3sMock *dockertest.Resource
+
+	BeforeEach(func() {
+		a = &seederv1alpha1.AddressPool{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "joined-node-test",
+				Namespace: "default",
+			},
+			Spec: seederv1alpha1.AddressSpec{
+				CIDR:    "127.0.0.1/8",
+				Gateway: "127.0.0.1",
+			},
+		}
+
+		i = &seederv1alpha1.Inventory{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "joined-node",
+				Namespace: "default",
+			},
+			Spec: seederv1alpha1.InventorySpec{
+				PrimaryDisk:                   "/dev/sda",
+				ManagementInterfaceMacAddress: "xx:xx:xx:xx:xx",
+				BaseboardManagementSpec: rufio.BaseboardManagementSpec{
+					Connection: rufio.Connection{
+						Host:        "localhost",
+						Port:        623,
+						InsecureTLS: true,
+						AuthSecretRef: corev1.SecretReference{
+							Name:      "joined-node",
+							Namespace: "default",
+						},
+					},
+				},
+			},
+		}
+
+		s = &corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "joined-node",
+				Namespace: "default",
+			},
+			StringData: map[string]string{
+				"username": "root",
+				"password": "calvin",
+			},
+		}
+
+		// the tinkerbell provisioner does not report completion of installs without a workflow template
+		c = &seederv1alpha1.Cluster{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "joined-node-cluster",
+				Namespace: "default",
+			},
+			Spec: seederv1alpha1.ClusterSpec{
+				HarvesterVersion: "harvester_1_0_2",
+				Nodes: []seederv1alpha1.NodeConfig{
+					{
+						InventoryReference: seederv1alpha1.ObjectReference{
+							Name:      "joined-node",
+							Namespace: "default",
+						},
+						AddressPoolReference: seederv1alpha1.ObjectReference{
+							Name:      "joined-node-test",
+							Namespace: "default",
+						},
+					},
+				},
+				VIPConfig: seederv1alpha1.VIPConfig{
+					AddressPoolReference: seederv1alpha1.ObjectReference{
+						Name:      "joined-node-test",
+						Namespace: "default",
+					},
+				},
+				ClusterConfig: seederv1alpha1.ClusterConfig{
+					SSHKeys: []string{
+						"abc",
+						"def",
+					},
+					ConfigURL: "localhost:30300/config.yaml",
+				},
+				MissingNodePolicy: seederv1alpha1.MissingNodePolicy{
+					GracePeriod: "20s",
+				},
+			},
+		}
+
+		Eventually(func() error {
+			return k8sClient.Create(ctx, a)
+		}, "30s", "5s").ShouldNot(HaveOccurred())
+
+		Eventually(func() error {
+			return k8sClient.Create(ctx, s)
+		}, "30s", "5s").ShouldNot(HaveOccurred())
+
+		Eventually(func() error {
+			return k8sClient.Create(ctx, i)
+		}, "30s", "5s").ShouldNot(HaveOccurred())
+
+		Eventually(func() error {
+			return k8sClient.Create(ctx, c)
+		}, "30s", "5s").ShouldNot(HaveOccurred())
+
+		Eventually(func() error {
+			cObj := &seederv1alpha1.Cluster{}
+			err := k8sClient.Get(ctx, types.NamespacedName{Namespace: c.Namespace, Name: c.Name}, cObj)
+			if err != nil {
+				return err
+			}
+
+			if cObj.Status.ClusterToken == "" {
+				return fmt.Errorf("waiting for cluster token to be generated")
+			}
+
+			k3sMock, err = pool.RunWithOptions(&dockertest.RunOptions{
+				Name:       "k3s-mock",
+				Repository: "rancher/k3s",
+				Tag:        "v1.24.2-k3s1",
+				Cmd:        []string{"server", "--cluster-init"},
+				Env: []string{
+					fmt.Sprintf("K3S_TOKEN=%s", cObj.Status.ClusterToken),
+				},
+				Mounts: []string{
+					"tmpfs:/run",
+					"tmpfs:/var/run",
+				},
+				Privileged: true,
+				ExposedPorts: []string{
+					"6443/tcp",
+				},
+			}, func(config *docker.HostConfig) {
+				config.RestartPolicy = docker.RestartPolicy{
+					Name: "no",
+				}
+			})
+			if err != nil {
+				return err
+			}
+
+			if cObj.Labels == nil {
+				cObj.Labels = make(map[string]string)
+			}
+
+			// since mock node is k3s, need to change prefix from rke2 to k3s
+			seederv1alpha1.DefaultAPIPrefix = "k3s"
+			cObj.Labels[seederv1alpha1.OverrideAPIPortLabel] = k3sMock.GetPort("6443/tcp")
+			return k8sClient.Update(ctx, cObj)
+		}, "30s", "5s").ShouldNot(HaveOccurred())
+
+		Eventually(func() error {
+			cObj := &seederv1alpha1.Cluster{}
+			if err := k8sClient.Get(ctx, types.NamespacedName{Namespace: c.Namespace, Name: c.Name}, cObj); err != nil {
+				return err
+			}
+
+			if cObj.Status.Status != seederv1alpha1.ClusterRunning {
+				return fmt.Errorf("waiting for cluster to be running. current status is %s", cObj.Status.Status)
+			}
+			return nil
+		}, "120s", "5s").ShouldNot(HaveOccurred())
+	})
+
+	// provisioningSamples returns the number of node provisioning durations observed for the tinkerbell provisioner
+	provisioningSamples := func() (uint64, error) {
+		families, err := ctrlmetrics.Registry.Gather()
+		if err != nil {
+			return 0, err
+		}
+
+		for _, f := range families {
+			if f.GetName() != "seeder_node_provisioning_duration_seconds" {
+				continue
+			}
+			for _, m := range f.GetMetric() {
+				for _, l := range m.GetLabel() {
+					if l.GetName() == "provisioner" && l.GetValue() == provisioner.TinkerbellProvisioner {
+						return m.GetHistogram().GetSampleCount(), nil
+					}
+				}
+			}
+		}
+		return 0, nil
+	}
+
+	It("mark inventories provisioned once their node joins and report the node missing once removed", func() {
+		// nodes which have not yet joined the cluster may still be installing, and are not reported as missing
+		Consistently(func() error {
+			iObj := &seederv1alpha1.Inventory{}
+			if err := k8sClient.Get(ctx, types.NamespacedName{Namespace: i.Namespace, Name: i.Name}, iObj); err != nil {
+				return err
+			}
+
+			if util.ConditionExists(iObj.Status.Conditions, seederv1alpha1.InventoryProvisioned) {
+				return fmt.Errorf("expected inventory to not be provisioned before its node joins")
+			}
+
+			if util.ConditionExists(iObj.Status.Conditions, seederv1alpha1.InventoryNodeMissing) {
+				return fmt.Errorf("expected node which has not joined to not be reported missing")
+			}
+			return nil
+		}, "30s", "5s").ShouldNot(HaveOccurred())
+
+		observed, err := provisioningSamples()
+		Expect(err).NotTo(HaveOccurred())
+
+		// register a node with the inventory address in the k3s mock
+		Eventually(func() error {
+			iObj := &seederv1alpha1.Inventory{}
+			if err := k8sClient.Get(ctx, types.NamespacedName{Namespace: i.Namespace, Name: i.Name}, iObj); err != nil {
+				return err
+			}
+
+			cObj := &seederv1alpha1.Cluster{}
+			if err := k8sClient.Get(ctx, types.NamespacedName{Namespace: c.Namespace, Name: c.Name}, cObj); err != nil {
+				return err
+			}
+
+			typedClient, err := genCoreTypedClient(ctx, cObj)
+			if err != nil {
+				return err
+			}
+
+			node, err := typedClient.Nodes().Get(ctx, i.Name, metav1.GetOptions{})
+			if err != nil {
+				if !apierrors.IsNotFound(err) {
+					return err
+				}
+				node, err = typedClient.Nodes().Create(ctx, &corev1.Node{ObjectMeta: metav1.ObjectMeta{Name: i.Name}}, metav1.CreateOptions{})
+				if err != nil {
+					return err
+				}
+			}
+
+			node.Status.Addresses = []corev1.NodeAddress{
+				{
+					Type:    corev1.NodeInternalIP,
+					Address: iObj.Status.Address,
+				},
+			}
+			_, err = typedClient.Nodes().UpdateStatus(ctx, node, metav1.UpdateOptions{})
+			return err
+		}, "30s", "5s").ShouldNot(HaveOccurred())
+
+		Eventually(func() error {
+			iObj := &seederv1alpha1.Inventory{}
+			if err := k8sClient.Get(ctx, types.NamespacedName{Namespace: i.Namespace, Name: i.Name}, iObj); err != nil {
+				return err
+			}
+
+			if !util.ConditionExists(iObj.Status.Conditions, seederv1alpha1.InventoryProvisioned) {
+				return fmt.Errorf("waiting for inventory to be provisioned once its node joined %v", iObj.Status.Conditions)
+			}
+			return nil
+		}, "150s", "5s").ShouldNot(HaveOccurred())
+
+		// the provisioning duration is observed once the node joins, as the install is not reported as completed
+		samples, err := provisioningSamples()
+		Expect(err).NotTo(HaveOccurred())
+		Expect(samples).To(BeNumerically(">", observed))
+
+		// remove the node from the cluster, and trigger a resync of the cluster nodes by annotating the inventory
+		Eventually(func() error {
+			cObj := &seederv1alpha1.Cluster{}
+			if err := k8sClient.Get(ctx, types.NamespacedName{Namespace: c.Namespace, Name: c.Name}, cObj); err != nil {
+				return err
+			}
+
+			typedClient, err := genCoreTypedClient(ctx, cObj)
+			if err != nil {
+				return err
+			}
+
+			err = typedClient.Nodes().Delete(ctx, i.Name, metav1.DeleteOptions{})
+			if err != nil && !apierrors.IsNotFound(err) {
+				return err
+			}
+
+			iObj := &seederv1alpha1.Inventory{}
+			if err := k8sClient.Get(ctx, types.NamespacedName{Namespace: i.Namespace, Name: i.Name}, iObj); err != nil {
+				return err
+			}
+
+			if iObj.Annotations == nil {
+				iObj.Annotations = make(map[string]string)
+			}
+			iObj.Annotations["resync"] = "true"
+			return k8sClient.Update(ctx, iObj)
+		}, "30s", "5s").ShouldNot(HaveOccurred())
+
+		Eventually(func() error {
+			iObj := &seederv1alpha1.Inventory{}
+			if err := k8sClient.Get(ctx, types.NamespacedName{Namespace: i.Namespace, Name: i.Name}, iObj); err != nil {
+				return err
+			}
+
+			if !util.ConditionExists(iObj.Status.Conditions, seederv1alpha1.InventoryNodeMissing) {
+				return fmt.Errorf("waiting for inventory node to be reported missing %v", iObj.Status.Conditions)
+			}
+			return nil
+		}, "120s", "5s").ShouldNot(HaveOccurred())
+	})
+
+	AfterEach(func() {
+		Eventually(func() error {
+			return k8sClient.Delete(ctx, c)
+		}, "30s", "5s").ShouldNot(HaveOccurred())
+
+		Eventually(func() error {
+			return k8sClient.Delete(ctx, i)
+		}, "30s", "5s").ShouldNot(HaveOccurred())
+
+		Eventually(func() error {
+			return k8sClient.Delete(ctx, s)
+		}, "30s", "5s").ShouldNot(HaveOccurred())
+
+		Eventually(func() error {
+			return k8sClient.Delete(ctx, a)
+		}, "30s", "5s").ShouldNot(HaveOccurred())
+
+		Eventually(func() error {
+			cObj := &seederv1alpha1.Cluster{}
+			err := k8sClient.Get(ctx, types.NamespacedName{Namespace: c.Namespace, Name: c.Name}, cObj)
+			if err != nil {
+				if apierrors.IsNotFound(err) {
+					return nil
+				}
+				return err
+			}
+
+			return fmt.Errorf("waiting for cluster finalizers to finish")
+		}, "30s", "5s").ShouldNot(HaveOccurred())
+
+		Eventually(func() error {
+			return pool.Purge(k3sMock)
+		}, "30s", "5s").ShouldNot(HaveOccurred())
+	})
+})
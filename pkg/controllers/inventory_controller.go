@@ -19,13 +19,18 @@ package controllers
 import (
 	"context"
 	"fmt"
+	"time"
 
 	"k8s.io/apimachinery/pkg/labels"
 
 	"github.com/go-logr/logr"
 	"github.com/google/uuid"
+	"github.com/harvester/seeder/pkg/metrics"
+	"github.com/harvester/seeder/pkg/redfish"
+	"github.com/harvester/seeder/pkg/tink"
 	"github.com/harvester/seeder/pkg/util"
 	rufio "github.com/tinkerbell/rufio/api/v1alpha1"
+	tinkv1alpha1 "github.com/tinkerbell/tink/pkg/apis/core/v1alpha1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/types"
 	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
@@ -45,6 +50,11 @@ type InventoryReconciler struct {
 	client.Client
 	Scheme *runtime.Scheme
 	logr.Logger
+	// NewVirtualMediaClient connects to the BMC of inventory booted using virtual media
+	NewVirtualMediaClient redfish.NewVirtualMediaClientFunc
+	// VirtualMediaURL is the URL of the VirtualMediaServer reachable from the BMCs. The install config ISO
+	// served from it is inserted alongside the Harvester ISO when booting from virtual media
+	VirtualMediaURL string
 }
 
 type inventoryReconciler func(context.Context, *seederv1alpha1.Inventory) error
@@ -57,6 +67,8 @@ type inventoryReconciler func(context.Context, *seederv1alpha1.Inventory) error
 //+kubebuilder:rbac:groups=bmc.tinkerbell.org,resources=baseboardmanagements,verbs=get;list;watch;create;update;patch;delete
 //+kubebuilder:rbac:groups=bmc.tinkerbell.org,resources=baseboardmanagements/status,verbs=get
 //+kubebuilder:rbac:groups="",resources=secrets,verbs=get;list;watch
+//+kubebuilder:rbac:groups=tinkerbell.org,resources=templates;workflows,verbs=get;list;watch;create;update;patch;delete
+//+kubebuilder:rbac:groups=metal.harvesterhci.io,resources=ipclaims,verbs=get;list;watch;create;update;patch;delete
 
 // Reconcile is part of the main kubernetes reconciliation loop which aims to
 // move the current state of the cluster closer to the desired state.
@@ -86,8 +98,12 @@ func (r *InventoryReconciler) Reconcile(ctx context.Context, req ctrl.Request) (
 		r.handleBaseboardDeletion,
 		r.triggerReboot,
 		r.reconcileBMCJob,
+		r.reconcileWorkflow,
 		r.housekeepingBMCJob,
 		r.inventoryFreed,
+		r.wipeInventory,
+		r.ejectVirtualMedia,
+		r.postInstallWorkflow,
 	}
 
 	deletionReconcileList := []inventoryReconciler{
@@ -229,6 +245,10 @@ func (r *InventoryReconciler) handleInventoryDeletion(ctx context.Context, i *se
 
 // SetupWithManager sets up the controller with the Manager.
 func (r *InventoryReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	if r.NewVirtualMediaClient == nil {
+		r.NewVirtualMediaClient = redfish.NewClient
+	}
+
 	return ctrl.NewControllerManagedBy(mgr).
 		For(&seederv1alpha1.Inventory{}).
 		Watches(&source.Kind{Type: &rufio.BaseboardManagement{}}, handler.EnqueueRequestsFromMapFunc(func(a client.Object) []reconcile.Request {
@@ -240,49 +260,44 @@ func (r *InventoryReconciler) SetupWithManager(mgr ctrl.Manager) error {
 			},
 			}
 		})).
+		Watches(&source.Kind{Type: &tinkv1alpha1.Workflow{}}, handler.EnqueueRequestsFromMapFunc(func(a client.Object) []reconcile.Request {
+			var reconRequest []reconcile.Request
+			for _, o := range a.GetOwnerReferences() {
+				if o.Kind == "Inventory" && o.APIVersion == "metal.harvesterhci.io/v1alpha1" {
+					reconRequest = append(reconRequest, reconcile.Request{
+						NamespacedName: types.NamespacedName{
+							Namespace: a.GetNamespace(),
+							Name:      o.Name,
+						},
+					})
+				}
+			}
+			return reconRequest
+		})).
 		Complete(r)
 }
 
-// triggerReboot will reboot the machine using the BMCJob object
+// triggerReboot will reboot the machine using the BMCJob object. Nodes are booted from PXE, or from
+// an ISO inserted using virtual media
 func (r *InventoryReconciler) triggerReboot(ctx context.Context, i *seederv1alpha1.Inventory) error {
 	// if tink hardware has been created and inventory is allocated to a cluster
 	// then reboot the hardware using BMC tasks
-	if i.Status.Status == seederv1alpha1.InventoryReady && util.ConditionExists(i.Status.Conditions, seederv1alpha1.TinkWorkflowCreated) && util.ConditionExists(i.Status.Conditions, seederv1alpha1.InventoryAllocatedToCluster) && !util.ConditionExists(i.Status.Conditions, seederv1alpha1.BMCJobSubmitted) {
-		// submit BMC task
-		off := rufio.HardPowerOff
-		on := rufio.PowerOn
-		job := &rufio.BMCJob{
-			ObjectMeta: metav1.ObjectMeta{
-				Name:      fmt.Sprintf("%s-reboot", i.Name),
-				Namespace: i.Namespace,
-				Labels: map[string]string{
-					"inventory": i.Name,
-				},
-			},
+	if i.Status.Status == seederv1alpha1.InventoryReady && util.ConditionExists(i.Status.Conditions, seederv1alpha1.TinkHardwareCreated) && util.ConditionExists(i.Status.Conditions, seederv1alpha1.InventoryAllocatedToCluster) && !util.ConditionExists(i.Status.Conditions, seederv1alpha1.BMCJobSubmitted) {
+		c := &seederv1alpha1.Cluster{}
+		if err := r.Get(ctx, types.NamespacedName{Namespace: i.Status.Cluster.Namespace, Name: i.Status.Cluster.Name}, c); err != nil {
+			return err
+		}
 
-			Spec: rufio.BMCJobSpec{
-				BaseboardManagementRef: rufio.BaseboardManagementRef{
-					Name:      i.Name,
-					Namespace: i.Namespace,
-				},
-				Tasks: []rufio.Task{
-					{
-						PowerAction: &off,
-					},
-					{
-						OneTimeBootDeviceAction: &rufio.OneTimeBootDeviceAction{
-							Devices: []rufio.BootDevice{
-								rufio.PXE,
-							},
-							EFIBoot: false,
-						},
-					},
-					{
-						PowerAction: &on,
-					},
-				},
-			},
+		device := rufio.PXE
+		if util.BootMethod(i, c) == seederv1alpha1.BootMethodVirtualMedia {
+			if err := r.insertVirtualMedia(ctx, i, c); err != nil {
+				return err
+			}
+			device = rufio.CDROM
 		}
+
+		// submit BMC task
+		job := generateBootJob(i, fmt.Sprintf("%s-reboot", i.Name), device)
 		err := controllerutil.SetOwnerReference(i, job, r.Scheme)
 		if err != nil {
 			return err
@@ -300,6 +315,123 @@ func (r *InventoryReconciler) triggerReboot(ctx context.Context, i *seederv1alph
 	return nil
 }
 
+// insertVirtualMedia inserts the install ISO into the virtual CD drive of the inventory. The Harvester ISO
+// for the cluster version is used unless the inventory specifies an image, and is booted with the install config
+// of the inventory inserted as config media
+func (r *InventoryReconciler) insertVirtualMedia(ctx context.Context, i *seederv1alpha1.Inventory, c *seederv1alpha1.Cluster) error {
+	image := i.Spec.VirtualMediaImage
+	var config string
+	if image == "" {
+		if r.VirtualMediaURL == "" {
+			return r.virtualMediaError(ctx, i, fmt.Errorf("virtual media url is not configured, unable to serve the install config of the Harvester ISO"))
+		}
+		image = util.GenerateISOURL(c.Spec.ImageURL, c.Spec.HarvesterVersion, util.Arch(i))
+		if i.Status.VirtualMediaToken == "" {
+			i.Status.VirtualMediaToken = util.GenerateRand()
+		}
+		config = VirtualMediaConfigURL(r.VirtualMediaURL, i)
+	}
+
+	err := r.withVirtualMedia(ctx, i, func(vm redfish.VirtualMediaClient) error {
+		if err := vm.InsertMedia(image); err != nil {
+			return err
+		}
+		if config == "" {
+			return nil
+		}
+		return vm.InsertConfigMedia(config)
+	})
+	if err != nil {
+		return r.virtualMediaError(ctx, i, fmt.Errorf("error inserting virtual media %s: %v", image, err))
+	}
+
+	i.Status.Conditions = util.RemoveCondition(i.Status.Conditions, seederv1alpha1.VirtualMediaError)
+	i.Status.Conditions = util.RemoveCondition(i.Status.Conditions, seederv1alpha1.VirtualMediaEjected)
+	i.Status.Conditions = util.CreateOrUpdateCondition(i.Status.Conditions, seederv1alpha1.VirtualMediaInserted,
+		fmt.Sprintf("virtual media %s inserted", image))
+	return nil
+}
+
+// ejectVirtualMedia ejects the install ISO once the node has joined the cluster, or has been released
+// from the cluster. Until then the next reconcile is requeued by returning an error
+func (r *InventoryReconciler) ejectVirtualMedia(ctx context.Context, i *seederv1alpha1.Inventory) error {
+	if !util.ConditionExists(i.Status.Conditions, seederv1alpha1.VirtualMediaInserted) {
+		return nil
+	}
+
+	if util.ConditionExists(i.Status.Conditions, seederv1alpha1.InventoryAllocatedToCluster) {
+		installed, err := r.nodeInstalled(ctx, i)
+		if err != nil {
+			return err
+		}
+		if !installed {
+			return fmt.Errorf("waiting for inventory %s to join cluster %s before ejecting virtual media", i.Name, i.Status.Cluster.Name)
+		}
+	}
+
+	err := r.withVirtualMedia(ctx, i, func(vm redfish.VirtualMediaClient) error {
+		return vm.EjectMedia()
+	})
+	if err != nil {
+		return r.virtualMediaError(ctx, i, fmt.Errorf("error ejecting virtual media: %v", err))
+	}
+
+	i.Status.VirtualMediaToken = ""
+	i.Status.Conditions = util.RemoveCondition(i.Status.Conditions, seederv1alpha1.VirtualMediaError)
+	i.Status.Conditions = util.RemoveCondition(i.Status.Conditions, seederv1alpha1.VirtualMediaInserted)
+	i.Status.Conditions = util.CreateOrUpdateCondition(i.Status.Conditions, seederv1alpha1.VirtualMediaEjected, "virtual media ejected")
+	return r.Status().Update(ctx, i)
+}
+
+// nodeInstalled checks if the inventory is a node in the running cluster it is allocated to
+func (r *InventoryReconciler) nodeInstalled(ctx context.Context, i *seederv1alpha1.Inventory) (bool, error) {
+	c := &seederv1alpha1.Cluster{}
+	if err := r.Get(ctx, types.NamespacedName{Namespace: i.Status.Cluster.Namespace, Name: i.Status.Cluster.Name}, c); err != nil {
+		return false, err
+	}
+
+	if c.Status.Status != seederv1alpha1.ClusterRunning {
+		return false, nil
+	}
+
+	typedClient, err := genCoreTypedClient(ctx, c)
+	if err != nil {
+		return false, err
+	}
+
+	nodeList, err := typedClient.Nodes().List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return false, err
+	}
+
+	return findNodeByIP(nodeList.Items, i.Status.Address) != nil, nil
+}
+
+// withVirtualMedia connects to the BMC of the inventory and runs fn against its virtual media
+func (r *InventoryReconciler) withVirtualMedia(ctx context.Context, i *seederv1alpha1.Inventory, fn func(redfish.VirtualMediaClient) error) error {
+	username, password, err := util.FetchBMCCredentials(ctx, r.Client, i)
+	if err != nil {
+		return err
+	}
+
+	vm, err := r.NewVirtualMediaClient(ctx, username, password, util.RedfishEndpoint(i), i.Spec.Connection.InsecureTLS)
+	if err != nil {
+		return err
+	}
+	defer vm.Close()
+
+	return fn(vm)
+}
+
+// virtualMediaError records a virtual media error on the inventory and returns the error
+func (r *InventoryReconciler) virtualMediaError(ctx context.Context, i *seederv1alpha1.Inventory, err error) error {
+	i.Status.Conditions = util.CreateOrUpdateCondition(i.Status.Conditions, seederv1alpha1.VirtualMediaError, err.Error())
+	if updateErr := r.Status().Update(ctx, i); updateErr != nil {
+		return updateErr
+	}
+	return err
+}
+
 // reconcileBMCJob will update the BMCJob conditions to reflect current state of the job for specific inventory
 func (r *InventoryReconciler) reconcileBMCJob(ctx context.Context, i *seederv1alpha1.Inventory) error {
 
@@ -317,7 +449,11 @@ func (r *InventoryReconciler) reconcileBMCJob(ctx context.Context, i *seederv1al
 
 		if jobFound {
 			if j.HasCondition(rufio.JobCompleted, rufio.ConditionTrue) {
+				if !util.ConditionExists(i.Status.Conditions, seederv1alpha1.BMCJobComplete) {
+					observeBMCJob(j, metrics.OutcomeCompleted)
+				}
 				i.Status.Conditions = util.CreateOrUpdateCondition(i.Status.Conditions, seederv1alpha1.BMCJobComplete, "")
+				i.Status.Conditions = util.RemoveCondition(i.Status.Conditions, seederv1alpha1.InventoryReprovisioning)
 			}
 
 			if j.HasCondition(rufio.JobFailed, rufio.ConditionTrue) {
@@ -327,6 +463,9 @@ func (r *InventoryReconciler) reconcileBMCJob(ctx context.Context, i *seederv1al
 						message = c.Message
 					}
 				}
+				if !util.ConditionExists(i.Status.Conditions, seederv1alpha1.BMCJobError) {
+					observeBMCJob(j, metrics.OutcomeFailed)
+				}
 				i.Status.Conditions = util.CreateOrUpdateCondition(i.Status.Conditions, seederv1alpha1.BMCJobError, message)
 			}
 
@@ -341,59 +480,358 @@ func (r *InventoryReconciler) reconcileBMCJob(ctx context.Context, i *seederv1al
 	return nil
 }
 
+// observeBMCJob records the outcome of a BMCJob, and the time from its creation until the outcome
+func observeBMCJob(j *rufio.BMCJob, outcome string) {
+	end := time.Now()
+	if j.Status.CompletionTime != nil {
+		end = j.Status.CompletionTime.Time
+	}
+	metrics.BMCJobs.WithLabelValues(outcome).Inc()
+	metrics.BMCJobDuration.WithLabelValues(outcome).Observe(end.Sub(j.CreationTimestamp.Time).Seconds())
+}
+
+// reconcileWorkflow will update the tink workflow conditions to reflect the current state of the inventory workflow
+func (r *InventoryReconciler) reconcileWorkflow(ctx context.Context, i *seederv1alpha1.Inventory) error {
+	if !util.ConditionExists(i.Status.Conditions, seederv1alpha1.TinkWorkflowCreated) ||
+		util.ConditionExists(i.Status.Conditions, seederv1alpha1.TinkWorkflowCompleted) ||
+		util.ConditionExists(i.Status.Conditions, seederv1alpha1.TinkWorkflowFailed) {
+		return nil
+	}
+
+	wf := &tinkv1alpha1.Workflow{}
+	if err := r.Get(ctx, types.NamespacedName{Namespace: i.Namespace, Name: tink.WorkflowName(i)}, wf); err != nil {
+		if apierrors.IsNotFound(err) {
+			return nil
+		}
+		return err
+	}
+
+	switch wf.Status.State {
+	case tinkv1alpha1.WorkflowStateSuccess:
+		i.Status.Conditions = util.CreateOrUpdateCondition(i.Status.Conditions, seederv1alpha1.TinkWorkflowCompleted, "")
+	case tinkv1alpha1.WorkflowStateFailed, tinkv1alpha1.WorkflowStateTimeout:
+		i.Status.Conditions = util.CreateOrUpdateCondition(i.Status.Conditions, seederv1alpha1.TinkWorkflowFailed, tink.WorkflowMessage(wf))
+	default:
+		return nil
+	}
+
+	return r.Status().Update(ctx, i)
+}
+
+// postInstallWorkflow runs the post-install workflow of the inventory once the node has joined the cluster, by
+// PXE booting the node into the tinkerbell OSIE. Until the node has joined, the next reconcile is requeued by
+// returning an error. The state of the workflow is then tracked in the inventory conditions
+func (r *InventoryReconciler) postInstallWorkflow(ctx context.Context, i *seederv1alpha1.Inventory) error {
+	if i.Spec.PostInstallWorkflowTemplate == nil ||
+		!util.ConditionExists(i.Status.Conditions, seederv1alpha1.InventoryAllocatedToCluster) ||
+		util.ConditionExists(i.Status.Conditions, seederv1alpha1.TinkPostInstallWorkflowCompleted) ||
+		util.ConditionExists(i.Status.Conditions, seederv1alpha1.TinkPostInstallWorkflowFailed) {
+		return nil
+	}
+
+	if util.ConditionExists(i.Status.Conditions, seederv1alpha1.TinkPostInstallWorkflowCreated) {
+		return r.reconcilePostInstallWorkflow(ctx, i)
+	}
+
+	// nodes being reinstalled may still be found in the cluster until the reboot into the installer has completed
+	if !util.ConditionExists(i.Status.Conditions, seederv1alpha1.BMCJobComplete) ||
+		util.ConditionExists(i.Status.Conditions, seederv1alpha1.InventoryReprovisioning) ||
+		util.ConditionExists(i.Status.Conditions, seederv1alpha1.InventoryReprovisionPending) {
+		return nil
+	}
+
+	c := &seederv1alpha1.Cluster{}
+	if err := r.Get(ctx, types.NamespacedName{Namespace: i.Status.Cluster.Namespace, Name: i.Status.Cluster.Name}, c); err != nil {
+		return err
+	}
+
+	// boots does not netboot nodes booted from virtual media, so the OSIE cannot be booted to run the workflow
+	if util.BootMethod(i, c) != seederv1alpha1.BootMethodPXE {
+		i.Status.Conditions = util.CreateOrUpdateCondition(i.Status.Conditions, seederv1alpha1.TinkPostInstallWorkflowFailed,
+			fmt.Sprintf("post-install workflows require boot method %s", seederv1alpha1.BootMethodPXE))
+		return r.Status().Update(ctx, i)
+	}
+
+	installed, err := r.nodeInstalled(ctx, i)
+	if err != nil {
+		return err
+	}
+	if !installed {
+		return fmt.Errorf("waiting for inventory %s to join cluster %s before running post-install workflow", i.Name, i.Status.Cluster.Name)
+	}
+
+	data, err := util.FetchWorkflowTemplate(ctx, r.Client, i.Spec.PostInstallWorkflowTemplate)
+	if err != nil {
+		return err
+	}
+
+	// node is only booted into the OSIE once the workflow exists
+	objs := []client.Object{
+		tink.GeneratePostInstallTemplate(i, data),
+		tink.GeneratePostInstallWorkflow(i, c),
+		generateBootJob(i, tink.PostInstallWorkflowName(i), rufio.PXE),
+	}
+	for _, obj := range objs {
+		if err := r.createOwnedObject(ctx, i, obj); err != nil {
+			return err
+		}
+	}
+
+	i.Status.Conditions = util.CreateOrUpdateCondition(i.Status.Conditions, seederv1alpha1.TinkPostInstallWorkflowCreated,
+		fmt.Sprintf("tink workflow %s created", tink.PostInstallWorkflowName(i)))
+	return r.Status().Update(ctx, i)
+}
+
+// reconcilePostInstallWorkflow will update the post-install workflow conditions to reflect the state of the workflow
+func (r *InventoryReconciler) reconcilePostInstallWorkflow(ctx context.Context, i *seederv1alpha1.Inventory) error {
+	wf := &tinkv1alpha1.Workflow{}
+	if err := r.Get(ctx, types.NamespacedName{Namespace: i.Namespace, Name: tink.PostInstallWorkflowName(i)}, wf); err != nil {
+		if apierrors.IsNotFound(err) {
+			return nil
+		}
+		return err
+	}
+
+	switch wf.Status.State {
+	case tinkv1alpha1.WorkflowStateSuccess:
+		i.Status.Conditions = util.CreateOrUpdateCondition(i.Status.Conditions, seederv1alpha1.TinkPostInstallWorkflowCompleted, "")
+	case tinkv1alpha1.WorkflowStateFailed, tinkv1alpha1.WorkflowStateTimeout:
+		i.Status.Conditions = util.CreateOrUpdateCondition(i.Status.Conditions, seederv1alpha1.TinkPostInstallWorkflowFailed, tink.WorkflowMessage(wf))
+	default:
+		return nil
+	}
+
+	return r.Status().Update(ctx, i)
+}
+
+// inventoryFreed applies the deletion policy recorded when the inventory was released from a cluster
 func (r *InventoryReconciler) inventoryFreed(ctx context.Context, i *seederv1alpha1.Inventory) error {
 	if util.ConditionExists(i.Status.Conditions, seederv1alpha1.InventoryFreed) {
-		// check and submit a power off job
-		var notFound bool
-		j := &rufio.BMCJob{}
-		err := r.Get(ctx, types.NamespacedName{Namespace: i.Namespace, Name: fmt.Sprintf("%s-poweroff", i.Name)}, j)
-		if err != nil {
-			if apierrors.IsNotFound(err) {
-				notFound = true
-			} else {
+		switch i.Status.ReleasePolicy {
+		case seederv1alpha1.DeletionPolicyRetain:
+			// node is left running
+		case seederv1alpha1.DeletionPolicyWipe:
+			i.Status.Conditions = util.RemoveCondition(i.Status.Conditions, seederv1alpha1.InventoryWipeFailed)
+			i.Status.Conditions = util.CreateOrUpdateCondition(i.Status.Conditions, seederv1alpha1.InventoryWiping, "wiping node disks")
+		default:
+			if err := r.powerOff(ctx, i); err != nil {
 				return err
 			}
 		}
 
-		if notFound {
-			off := rufio.HardPowerOff
-			job := &rufio.BMCJob{
-				ObjectMeta: metav1.ObjectMeta{
-					Name:      fmt.Sprintf("%s-poweroff", i.Name),
-					Namespace: i.Namespace,
-					Labels: map[string]string{
-						"inventory": i.Name,
-					},
-				},
-				Spec: rufio.BMCJobSpec{
-					BaseboardManagementRef: rufio.BaseboardManagementRef{
-						Name:      i.Name,
-						Namespace: i.Namespace,
-					},
-					Tasks: []rufio.Task{
-						{
-							PowerAction: &off,
-						},
-					},
+		// trigger status update
+		i.Status.Conditions = util.RemoveCondition(i.Status.Conditions, seederv1alpha1.InventoryFreed)
+		return r.Status().Update(ctx, i)
+	}
+	return nil
+}
+
+// powerOff will check and submit a power off job
+func (r *InventoryReconciler) powerOff(ctx context.Context, i *seederv1alpha1.Inventory) error {
+	j := &rufio.BMCJob{}
+	err := r.Get(ctx, types.NamespacedName{Namespace: i.Namespace, Name: fmt.Sprintf("%s-poweroff", i.Name)}, j)
+	if err == nil || !apierrors.IsNotFound(err) {
+		return err
+	}
+
+	off := rufio.HardPowerOff
+	job := &rufio.BMCJob{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      fmt.Sprintf("%s-poweroff", i.Name),
+			Namespace: i.Namespace,
+			Labels: map[string]string{
+				"inventory": i.Name,
+			},
+		},
+		Spec: rufio.BMCJobSpec{
+			BaseboardManagementRef: rufio.BaseboardManagementRef{
+				Name:      i.Name,
+				Namespace: i.Namespace,
+			},
+			Tasks: []rufio.Task{
+				{
+					PowerAction: &off,
 				},
-			}
-			if err := controllerutil.SetOwnerReference(i, job, r.Scheme); err != nil {
-				return err
-			}
-			if err := r.Create(ctx, job); err != nil {
-				return err
-			}
+			},
+		},
+	}
+	if err := controllerutil.SetOwnerReference(i, job, r.Scheme); err != nil {
+		return err
+	}
+	return r.Create(ctx, job)
+}
+
+// wipeInventory will run the tinkerbell wipe workflow on a released inventory. The address
+// used to PXE boot the node is only released once the workflow has succeeded
+func (r *InventoryReconciler) wipeInventory(ctx context.Context, i *seederv1alpha1.Inventory) error {
+	if !util.ConditionExists(i.Status.Conditions, seederv1alpha1.InventoryWiping) {
+		return nil
+	}
+
+	// a failed wipe is retried by annotating the inventory with the reprovision annotation
+	if util.ConditionExists(i.Status.Conditions, seederv1alpha1.InventoryWipeFailed) {
+		if _, ok := i.Annotations[seederv1alpha1.ReprovisionAnnotation]; !ok {
+			return nil
+		}
+		delete(i.Annotations, seederv1alpha1.ReprovisionAnnotation)
+		if err := r.Update(ctx, i); err != nil {
+			return err
 		}
+		i.Status.Conditions = util.RemoveCondition(i.Status.Conditions, seederv1alpha1.InventoryWipeFailed)
+		return r.Status().Update(ctx, i)
+	}
 
-		// trigger status update
-		i.Status.Conditions = util.RemoveCondition(i.Status.Conditions, seederv1alpha1.InventoryFreed)
+	// hardware object used to install the node needs to be replaced with one which boots into the tinkerbell OSIE
+	hw := &tinkv1alpha1.Hardware{}
+	err := r.Get(ctx, types.NamespacedName{Namespace: i.Namespace, Name: i.Name}, hw)
+	if err != nil {
+		if !apierrors.IsNotFound(err) {
+			return err
+		}
+		hw = tink.GenerateWipeHardware(i)
+		if err := r.createOwnedObject(ctx, i, hw); err != nil {
+			return err
+		}
+	} else if !isOwnedBy(hw, i) {
+		if err := r.Delete(ctx, hw); err != nil {
+			return err
+		}
+		return fmt.Errorf("waiting for install hardware %s to be removed before wiping", hw.Name)
+	}
+
+	if err := r.createOwnedObject(ctx, i, tink.GenerateWipeTemplate(i)); err != nil {
+		return err
+	}
+
+	wf := &tinkv1alpha1.Workflow{}
+	err = r.Get(ctx, types.NamespacedName{Namespace: i.Namespace, Name: tink.WipeName(i)}, wf)
+	if err != nil {
+		if !apierrors.IsNotFound(err) {
+			return err
+		}
+		wf = tink.GenerateWipeWorkflow(i)
+		if err := r.createOwnedObject(ctx, i, wf); err != nil {
+			return err
+		}
+	}
+
+	if err := r.createOwnedObject(ctx, i, generateBootJob(i, tink.WipeName(i), rufio.PXE)); err != nil {
+		return err
+	}
+
+	switch wf.Status.State {
+	case tinkv1alpha1.WorkflowStateSuccess:
+		if err := r.cleanupWipe(ctx, i, hw); err != nil {
+			return err
+		}
+
+		if err := util.ReleaseAddresses(ctx, r.Client, seederv1alpha1.KindInventory, i.Name, i.Namespace); err != nil {
+			return err
+		}
+
+		if err := r.powerOff(ctx, i); err != nil {
+			return err
+		}
+
+		i.Status.PXEBootInterface = seederv1alpha1.PXEBootInterface{}
+		i.Status.Conditions = util.RemoveCondition(i.Status.Conditions, seederv1alpha1.InventoryWiping)
 		return r.Status().Update(ctx, i)
+	case tinkv1alpha1.WorkflowStateFailed, tinkv1alpha1.WorkflowStateTimeout:
+		if err := r.cleanupWipe(ctx, i, nil); err != nil {
+			return err
+		}
+		i.Status.Conditions = util.CreateOrUpdateCondition(i.Status.Conditions, seederv1alpha1.InventoryWipeFailed,
+			fmt.Sprintf("wipe workflow finished with state %s", wf.Status.State))
+		return r.Status().Update(ctx, i)
+	}
+
+	return nil
+}
+
+// cleanupWipe removes the objects created to wipe an inventory. The hardware object is only removed if specified,
+// as it is needed to retry a failed wipe
+func (r *InventoryReconciler) cleanupWipe(ctx context.Context, i *seederv1alpha1.Inventory, hw *tinkv1alpha1.Hardware) error {
+	objs := []client.Object{
+		&tinkv1alpha1.Workflow{ObjectMeta: metav1.ObjectMeta{Name: tink.WipeName(i), Namespace: i.Namespace}},
+		&tinkv1alpha1.Template{ObjectMeta: metav1.ObjectMeta{Name: tink.WipeName(i), Namespace: i.Namespace}},
+		&rufio.BMCJob{ObjectMeta: metav1.ObjectMeta{Name: tink.WipeName(i), Namespace: i.Namespace}},
+	}
+
+	if hw != nil {
+		objs = append(objs, hw)
+	}
+
+	for _, obj := range objs {
+		if err := r.Delete(ctx, obj); err != nil && !apierrors.IsNotFound(err) {
+			return err
+		}
+	}
+	return nil
+}
+
+// createOwnedObject creates an object owned by the inventory if it does not already exist
+func (r *InventoryReconciler) createOwnedObject(ctx context.Context, i *seederv1alpha1.Inventory, obj client.Object) error {
+	if err := controllerutil.SetOwnerReference(i, obj, r.Scheme); err != nil {
+		return err
+	}
+
+	if err := r.Create(ctx, obj); err != nil && !apierrors.IsAlreadyExists(err) {
+		return err
 	}
 	return nil
 }
 
+// isOwnedBy checks if an object has an owner reference to the inventory
+func isOwnedBy(obj client.Object, i *seederv1alpha1.Inventory) bool {
+	for _, ref := range obj.GetOwnerReferences() {
+		if ref.UID == i.UID {
+			return true
+		}
+	}
+	return false
+}
+
+// generateBootJob generates a BMCJob which power cycles the inventory and boots it once from device
+func generateBootJob(i *seederv1alpha1.Inventory, name string, device rufio.BootDevice) *rufio.BMCJob {
+	off := rufio.HardPowerOff
+	on := rufio.PowerOn
+	return &rufio.BMCJob{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: i.Namespace,
+			Labels: map[string]string{
+				"inventory": i.Name,
+			},
+		},
+
+		Spec: rufio.BMCJobSpec{
+			BaseboardManagementRef: rufio.BaseboardManagementRef{
+				Name:      i.Name,
+				Namespace: i.Namespace,
+			},
+			Tasks: []rufio.Task{
+				{
+					PowerAction: &off,
+				},
+				{
+					OneTimeBootDeviceAction: &rufio.OneTimeBootDeviceAction{
+						Devices: []rufio.BootDevice{
+							device,
+						},
+						EFIBoot: util.IsUEFI(i),
+					},
+				},
+				{
+					PowerAction: &on,
+				},
+			},
+		},
+	}
+}
+
 func (r *InventoryReconciler) housekeepingBMCJob(ctx context.Context, i *seederv1alpha1.Inventory) error {
-	if !util.ConditionExists(i.Status.Conditions, seederv1alpha1.InventoryAllocatedToCluster) && !util.ConditionExists(i.Status.Conditions, seederv1alpha1.InventoryFreed) {
+	if !util.ConditionExists(i.Status.Conditions, seederv1alpha1.InventoryAllocatedToCluster) && !util.ConditionExists(i.Status.Conditions, seederv1alpha1.InventoryFreed) &&
+		!util.ConditionExists(i.Status.Conditions, seederv1alpha1.InventoryWiping) {
 		bmcjoblist := &rufio.BMCJobList{}
 		l, err := labels.Parse(fmt.Sprintf("inventory=%s", i.Name))
 		if err != nil {
@@ -3,19 +3,28 @@ package controllers
 import (
 	"context"
 	"fmt"
+	"reflect"
+	"strings"
 	"time"
 
 	"github.com/go-logr/logr"
+	"github.com/google/uuid"
 	seederv1alpha1 "github.com/harvester/seeder/pkg/api/v1alpha1"
 	"github.com/harvester/seeder/pkg/events"
+	"github.com/harvester/seeder/pkg/metrics"
+	"github.com/harvester/seeder/pkg/util"
+	"github.com/stmcginnis/gofish/common"
 	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
 	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/types"
 	"k8s.io/client-go/tools/record"
 	"k8s.io/client-go/util/retry"
 	ctrl "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
 )
 
 // ClusterReconciler reconciles a Cluster object
@@ -24,10 +33,18 @@ type InventoryEventReconciller struct {
 	Scheme *runtime.Scheme
 	logr.Logger
 	record.EventRecorder
+	// EventReceiverURL is the externally reachable URL of the RedfishEventReceiver. Inventories are subscribed
+	// to BMC alerts when it is set, and the BMC continues to be polled as a fallback
+	EventReceiverURL string
+	// HardwareLabels configures the labels describing the hardware of the inventory
+	HardwareLabels util.HardwareLabels
 }
 
 const (
 	NextCheckTime = "nextCheckTime"
+
+	// maxLogEntries limits the entries recorded from each log service on a single poll
+	maxLogEntries = 50
 )
 
 type inventoryEventReconciller func(context.Context, *seederv1alpha1.Inventory) error
@@ -49,15 +66,19 @@ func (r *InventoryEventReconciller) Reconcile(ctx context.Context, req ctrl.Requ
 	err := r.Get(ctx, req.NamespacedName, i)
 	if err != nil {
 		if apierrors.IsNotFound(err) {
+			metrics.Telemetry.Delete(req.NamespacedName)
+			metrics.RedfishPollErrors.DeleteLabelValues(req.Namespace, req.Name)
 			return ctrl.Result{}, nil
 		}
 		r.Error(err, "unable to fetch inventory object")
 		return ctrl.Result{}, err
 	}
 
-	// if Event lookup is disabled, ignore the objects
-	if !i.Spec.Events.Enabled {
-		return ctrl.Result{}, nil
+	// if Event lookup is disabled, remove the subscription from the BMC and ignore the objects
+	if !i.Spec.Events.Enabled || !i.DeletionTimestamp.IsZero() {
+		metrics.Telemetry.Delete(req.NamespacedName)
+		metrics.RedfishPollErrors.DeleteLabelValues(req.Namespace, req.Name)
+		return ctrl.Result{}, r.removeSubscription(ctx, i)
 	}
 
 	// if inventory is not ready, then return and wait for it to be ready
@@ -65,6 +86,12 @@ func (r *InventoryEventReconciller) Reconcile(ctx context.Context, req ctrl.Requ
 		return ctrl.Result{}, fmt.Errorf("waiting for inventory %s in namespace %s to be ready", i.Name, i.Namespace)
 	}
 
+	// the finalizer is added before subscribing, so the subscription is removed if the inventory is deleted
+	if r.EventReceiverURL != "" && !controllerutil.ContainsFinalizer(i, seederv1alpha1.InventoryEventFinalizer) {
+		controllerutil.AddFinalizer(i, seederv1alpha1.InventoryEventFinalizer)
+		return ctrl.Result{}, r.Update(ctx, i)
+	}
+
 	// if next check time is after current time then requeue
 	timeStamp, ok := i.Annotations[NextCheckTime]
 	if ok {
@@ -103,40 +130,45 @@ func (r *InventoryEventReconciller) getInventoryInfo(ctx context.Context, i *see
 	if err != nil {
 		return err
 	}
-	// fetch bmc secret first
-	s := &corev1.Secret{}
-	err = r.Client.Get(ctx, types.NamespacedName{Namespace: i.Spec.BaseboardManagementSpec.Connection.AuthSecretRef.Namespace,
-		Name: i.Spec.BaseboardManagementSpec.Connection.AuthSecretRef.Name}, s)
+	username, password, err := util.FetchBMCCredentials(ctx, r.Client, i)
+	if err != nil {
+		return err
+	}
 
+	rc, err := events.NewEventFetcher(ctx, username, password, util.RedfishEndpoint(i))
 	if err != nil {
+		metrics.RedfishPollErrors.WithLabelValues(i.Namespace, i.Name).Inc()
 		return err
 	}
 
-	username, ok := s.Data["username"]
-	if !ok {
-		return fmt.Errorf("secret %s has no key username", s.Name)
+	labels, _, err := rc.GetConfig()
+	if err != nil {
+		metrics.RedfishPollErrors.WithLabelValues(i.Namespace, i.Name).Inc()
+		return err
 	}
-	password, ok := s.Data["password"]
-	if !ok {
-		return fmt.Errorf("secret %s has no key password", s.Name)
+
+	health, err := rc.GetHealth()
+	if err != nil {
+		metrics.RedfishPollErrors.WithLabelValues(i.Namespace, i.Name).Inc()
+		return err
 	}
 
-	bmcendpoint := fmt.Sprintf("https://%s", i.Spec.BaseboardManagementSpec.Connection.Host)
-	if port, ok := i.Labels[seederv1alpha1.OverrideRedfishPortLabel]; ok {
-		bmcendpoint = fmt.Sprintf("https://%s:%s", i.Spec.BaseboardManagementSpec.Connection.Host, port)
+	r.pollTelemetry(rc, i)
+
+	if err := r.ingestLogs(ctx, rc, i); err != nil {
+		return err
 	}
-	rc, err := events.NewEventFetcher(ctx, string(username), string(password), bmcendpoint)
-	if err != nil {
+
+	if err := r.reconcileSubscription(ctx, rc, i, health.Status); err != nil {
 		return err
 	}
 
-	labels, status, err := rc.GetConfig()
-	if err != nil {
+	if err := r.updateHealth(ctx, i, health); err != nil {
 		return err
 	}
 
-	// record event and update object
-	err = retry.RetryOnConflict(retry.DefaultRetry, func() error {
+	labels[util.HardwareHealth] = health.Status
+	return retry.RetryOnConflict(retry.DefaultRetry, func() error {
 		obj := &seederv1alpha1.Inventory{}
 		err := r.Get(ctx, types.NamespacedName{Namespace: i.Namespace, Name: i.Name}, obj)
 		if err != nil {
@@ -152,21 +184,365 @@ func (r *InventoryEventReconciller) getInventoryInfo(ctx context.Context, i *see
 		}
 
 		obj.Annotations[NextCheckTime] = time.Now().Add(duration).Format(time.RFC3339)
-		for k, v := range labels {
-			obj.Labels[k] = v
-		}
-		obj.Labels["status"] = status
+		// health was previously reported using the status label, and is now part of the inventory status
+		delete(obj.Labels, "status")
+		r.HardwareLabels.Apply(obj.Labels, labels)
 		return r.Update(ctx, obj)
 	})
+}
+
+// updateHealth records the hardware health in the inventory status, and sets the inventoryHealthDegraded condition
+// while the health is not OK. An event is recorded when the health changes
+func (r *InventoryEventReconciller) updateHealth(ctx context.Context, i *seederv1alpha1.Inventory, h *events.Health) error {
+	health := &seederv1alpha1.HardwareHealth{
+		Status:     seederv1alpha1.HealthState(h.Status),
+		Processors: seederv1alpha1.HealthState(h.Processors),
+		Memory:     seederv1alpha1.HealthState(h.Memory),
+		Storage:    seederv1alpha1.HealthState(h.Storage),
+		Power:      seederv1alpha1.HealthState(h.Power),
+		Fans:       seederv1alpha1.HealthState(h.Fans),
+	}
+
+	var changed bool
+	err := retry.RetryOnConflict(retry.DefaultRetry, func() error {
+		obj := &seederv1alpha1.Inventory{}
+		err := r.Get(ctx, types.NamespacedName{Namespace: i.Namespace, Name: i.Name}, obj)
+		if err != nil {
+			return err
+		}
+
+		changed = !reflect.DeepEqual(obj.Status.Health, health)
+		if !changed {
+			return nil
+		}
+
+		obj.Status.Health = health
+		if health.Status == "" || health.Status == seederv1alpha1.HealthOK {
+			obj.Status.Conditions = util.RemoveCondition(obj.Status.Conditions, seederv1alpha1.InventoryHealthDegraded)
+		} else {
+			obj.Status.Conditions = util.CreateOrUpdateCondition(obj.Status.Conditions, seederv1alpha1.InventoryHealthDegraded, healthMessage(health))
+		}
+		return r.Status().Update(ctx, obj)
+	})
+
+	if err != nil || !changed {
+		return err
+	}
+
+	eventType := "Normal"
+	if health.Status != seederv1alpha1.HealthOK {
+		eventType = "Warning"
+	}
+	r.EventRecorder.Event(i, eventType, "HealthChanged", fmt.Sprintf("hardware health changed to %s", healthMessage(health)))
+	return nil
+}
+
+// healthMessage summarises the health of the node and its subsystems, such as "Warning (processors: OK, memory: Warning)"
+func healthMessage(h *seederv1alpha1.HardwareHealth) string {
+	status := string(h.Status)
+	if status == "" {
+		status = "unknown"
+	}
+
+	var subsystems []string
+	for _, v := range []struct {
+		name   string
+		health seederv1alpha1.HealthState
+	}{
+		{"processors", h.Processors},
+		{"memory", h.Memory},
+		{"storage", h.Storage},
+		{"power", h.Power},
+		{"fans", h.Fans},
+	} {
+		if v.health != "" {
+			subsystems = append(subsystems, fmt.Sprintf("%s: %s", v.name, v.health))
+		}
+	}
+
+	if len(subsystems) == 0 {
+		return status
+	}
+
+	return fmt.Sprintf("%s (%s)", status, strings.Join(subsystems, ", "))
+}
+
+// pollTelemetry reads the sensor and power readings of the inventory when telemetry is enabled. Failing to read
+// telemetry does not prevent the inventory health from being updated, but the stale readings are removed
+func (r *InventoryEventReconciller) pollTelemetry(rc *events.EventFetcher, i *seederv1alpha1.Inventory) {
+	name := types.NamespacedName{Namespace: i.Namespace, Name: i.Name}
+	if !i.Spec.Events.Telemetry {
+		metrics.Telemetry.Delete(name)
+		return
+	}
+
+	telemetry, err := rc.GetTelemetry()
+	if err != nil {
+		metrics.RedfishPollErrors.WithLabelValues(i.Namespace, i.Name).Inc()
+		r.Error(err, "unable to read telemetry", "inventory", i.Name, "namespace", i.Namespace)
+		metrics.Telemetry.Delete(name)
+		return
+	}
+
+	metrics.Telemetry.Update(name, i.Status.Cluster.Name, telemetry)
+}
+
+// ingestLogs records the entries added to the BMC log services of the inventory since the last poll as events on
+// the inventory, and on its node when the inventory is part of a running cluster. Failing to read the logs does not
+// prevent the inventory health from being updated. The cursor of each log service is stored in the inventory status
+func (r *InventoryEventReconciller) ingestLogs(ctx context.Context, rc *events.EventFetcher, i *seederv1alpha1.Inventory) error {
+	if len(i.Spec.Events.LogServices) == 0 {
+		return nil
+	}
+
+	services, err := rc.GetLogEntries(i.Spec.Events.LogServices)
+	if err != nil {
+		metrics.RedfishPollErrors.WithLabelValues(i.Namespace, i.Name).Inc()
+		r.Error(err, "unable to read log services", "inventory", i.Name, "namespace", i.Namespace)
+		return nil
+	}
+
+	cursors := make(map[string]seederv1alpha1.LogCursor)
+	var entries []events.LogEntry
+	var sources []string
+	for _, s := range services {
+		existing := i.Status.LogCursors[s.URI]
+		newEntries, cursor := events.NewLogEntries(s.Entries, events.LogCursor{Created: existing.Created.Time, IDs: existing.EntryIDs})
+		if !cursor.Created.IsZero() {
+			cursors[s.URI] = seederv1alpha1.LogCursor{Created: metav1.NewTime(cursor.Created), EntryIDs: cursor.IDs}
+		}
+
+		if len(newEntries) > maxLogEntries {
+			newEntries = newEntries[len(newEntries)-maxLogEntries:]
+		}
+
+		for _, e := range newEntries {
+			entries = append(entries, e)
+			sources = append(sources, s.ID)
+		}
+	}
+
+	if len(entries) != 0 {
+		recorder, node, err := r.remoteNodeRecorder(ctx, i)
+		if err != nil {
+			r.Error(err, "unable to record log entries on node", "inventory", i.Name, "namespace", i.Namespace)
+		}
+
+		for idx, e := range entries {
+			eventType := "Normal"
+			if events.IsWarningSeverity(e.Severity) {
+				eventType = "Warning"
+			}
+
+			message := fmt.Sprintf("%s entry %s: %s", sources[idx], e.ID, e.Message)
+			r.EventRecorder.Event(i, eventType, "RedfishLogEntry", message)
+			if node != nil {
+				recorder.Event(node, eventType, "RedfishLogEntry", message)
+			}
+		}
+	}
+
+	return retry.RetryOnConflict(retry.DefaultRetry, func() error {
+		obj := &seederv1alpha1.Inventory{}
+		err := r.Get(ctx, types.NamespacedName{Namespace: i.Namespace, Name: i.Name}, obj)
+		if err != nil {
+			return err
+		}
+
+		if equality.Semantic.DeepEqual(obj.Status.LogCursors, cursors) {
+			return nil
+		}
+
+		obj.Status.LogCursors = cursors
+		return r.Status().Update(ctx, obj)
+	})
+}
 
+// remoteNodeRecorder returns an event recorder for the cluster the inventory is allocated to, and the node of the
+// inventory in that cluster. A nil node is returned when the cluster is not running or the node is not found
+func (r *InventoryEventReconciller) remoteNodeRecorder(ctx context.Context, i *seederv1alpha1.Inventory) (record.EventRecorder, *corev1.Node, error) {
+	if i.Status.Cluster.Name == "" {
+		return nil, nil, nil
+	}
+
+	c := &seederv1alpha1.Cluster{}
+	err := r.Get(ctx, types.NamespacedName{Namespace: i.Status.Cluster.Namespace, Name: i.Status.Cluster.Name}, c)
 	if err != nil {
+		return nil, nil, err
+	}
+
+	if c.Status.Status != seederv1alpha1.ClusterRunning {
+		return nil, nil, nil
+	}
+
+	typedClient, err := genCoreTypedClient(ctx, c)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	nodeList, err := typedClient.Nodes().List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return nil, nil, err
+	}
+
+	node := findNodeByIP(nodeList.Items, i.Status.Address)
+	if node == nil {
+		return nil, nil, nil
+	}
+
+	return remoteEventRecorder(typedClient, r.Scheme), node, nil
+}
+
+// reconcileSubscription subscribes the inventory to alerts from the BMC when the event receiver is configured.
+// Failing to subscribe is recorded in the inventoryEventSubscriptionFailed condition, and the BMC is only polled.
+// The inventoryBMCAlert condition is removed once the BMC reports the inventory is healthy again
+func (r *InventoryEventReconciller) reconcileSubscription(ctx context.Context, rc *events.EventFetcher, i *seederv1alpha1.Inventory, health string) error {
+	subscription, subscriptionErr := r.subscribe(rc, i)
+	if subscriptionErr != nil {
+		r.Error(subscriptionErr, "unable to subscribe to redfish events", "inventory", i.Name, "namespace", i.Namespace)
+		if !util.ConditionExists(i.Status.Conditions, seederv1alpha1.InventoryEventSubscriptionFailed) {
+			r.EventRecorder.Event(i, "Warning", "EventSubscriptionFailed", fmt.Sprintf("unable to subscribe to redfish events, falling back to polling: %v", subscriptionErr))
+		}
+	}
+
+	err := retry.RetryOnConflict(retry.DefaultRetry, func() error {
+		obj := &seederv1alpha1.Inventory{}
+		err := r.Get(ctx, types.NamespacedName{Namespace: i.Namespace, Name: i.Name}, obj)
+		if err != nil {
+			return err
+		}
+
+		status := obj.Status.DeepCopy()
+		// a failed attempt retains the previous subscription, which may still be registered on the BMC
+		if subscriptionErr != nil {
+			if util.ConditionMessage(status.Conditions, seederv1alpha1.InventoryEventSubscriptionFailed) != subscriptionErr.Error() {
+				status.Conditions = util.CreateOrUpdateCondition(status.Conditions, seederv1alpha1.InventoryEventSubscriptionFailed, subscriptionErr.Error())
+			}
+		} else {
+			status.EventSubscription = subscription
+			status.Conditions = util.RemoveCondition(status.Conditions, seederv1alpha1.InventoryEventSubscriptionFailed)
+		}
+
+		if health == string(common.OKHealth) {
+			status.Conditions = util.RemoveCondition(status.Conditions, seederv1alpha1.InventoryBMCAlert)
+		}
+
+		if reflect.DeepEqual(status, &obj.Status) {
+			return nil
+		}
+
+		obj.Status = *status
+		return r.Status().Update(ctx, obj)
+	})
+	if err != nil || subscriptionErr != nil || subscription != nil {
 		return err
 	}
 
-	r.EventRecorder.Event(i, "Normal", "RedfishStatusEvent", fmt.Sprintf("current inventory status: %s", status))
+	// the subscription has been removed after the receiver was unset
+	return r.removeFinalizer(ctx, i)
+}
+
+// removeSubscription removes the event subscription of the inventory from the BMC when events are disabled or the
+// inventory is deleted, and then removes the finalizer. The BMC credentials are needed to remove the subscription,
+// so if they have been deleted the subscription is left on the BMC, where events are rejected by the receiver
+func (r *InventoryEventReconciller) removeSubscription(ctx context.Context, i *seederv1alpha1.Inventory) error {
+	if i.Status.EventSubscription != nil {
+		err := r.unsubscribe(ctx, i)
+		if err != nil {
+			if !apierrors.IsNotFound(err) {
+				return err
+			}
+			r.EventRecorder.Event(i, "Warning", "EventSubscriptionNotRemoved",
+				fmt.Sprintf("unable to remove subscription %s from the BMC: %v", i.Status.EventSubscription.URI, err))
+		}
+
+		err = retry.RetryOnConflict(retry.DefaultRetry, func() error {
+			obj := &seederv1alpha1.Inventory{}
+			if err := r.Get(ctx, types.NamespacedName{Namespace: i.Namespace, Name: i.Name}, obj); err != nil {
+				return err
+			}
+			obj.Status.EventSubscription = nil
+			obj.Status.Conditions = util.RemoveCondition(obj.Status.Conditions, seederv1alpha1.InventoryEventSubscriptionFailed)
+			return r.Status().Update(ctx, obj)
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	return r.removeFinalizer(ctx, i)
+}
+
+// unsubscribe connects to the BMC of the inventory and removes its event subscription
+func (r *InventoryEventReconciller) unsubscribe(ctx context.Context, i *seederv1alpha1.Inventory) error {
+	username, password, err := util.FetchBMCCredentials(ctx, r.Client, i)
+	if err != nil {
+		return err
+	}
+
+	rc, err := events.NewEventFetcher(ctx, username, password, util.RedfishEndpoint(i))
+	if err != nil {
+		return err
+	}
+
+	if err := rc.Unsubscribe(i.Status.EventSubscription.URI); err != nil {
+		return fmt.Errorf("error removing subscription %s: %w", i.Status.EventSubscription.URI, err)
+	}
 	return nil
 }
 
+// removeFinalizer removes the event finalizer once the inventory has no subscription registered on the BMC
+func (r *InventoryEventReconciller) removeFinalizer(ctx context.Context, i *seederv1alpha1.Inventory) error {
+	return retry.RetryOnConflict(retry.DefaultRetry, func() error {
+		obj := &seederv1alpha1.Inventory{}
+		err := r.Get(ctx, types.NamespacedName{Namespace: i.Namespace, Name: i.Name}, obj)
+		if err != nil {
+			if apierrors.IsNotFound(err) {
+				return nil
+			}
+			return err
+		}
+
+		if !controllerutil.ContainsFinalizer(obj, seederv1alpha1.InventoryEventFinalizer) {
+			return nil
+		}
+
+		controllerutil.RemoveFinalizer(obj, seederv1alpha1.InventoryEventFinalizer)
+		return r.Update(ctx, obj)
+	})
+}
+
+// subscribe registers a subscription to alerts on the BMC, reusing the context of an existing subscription to the
+// same destination. A subscription registered while the receiver was configured is removed once it is not
+func (r *InventoryEventReconciller) subscribe(rc *events.EventFetcher, i *seederv1alpha1.Inventory) (*seederv1alpha1.EventSubscription, error) {
+	existing := i.Status.EventSubscription
+	if r.EventReceiverURL == "" {
+		if existing != nil {
+			if err := rc.Unsubscribe(existing.URI); err != nil {
+				return nil, fmt.Errorf("error removing subscription %s: %w", existing.URI, err)
+			}
+		}
+		return nil, nil
+	}
+
+	destination := fmt.Sprintf("%s%s%s/%s", strings.TrimSuffix(r.EventReceiverURL, "/"), RedfishEventPath, i.Namespace, i.Name)
+	subscriptionContext := uuid.New().String()
+	if existing != nil && existing.Destination == destination {
+		subscriptionContext = existing.Context
+	}
+
+	uri, err := rc.Subscribe(destination, subscriptionContext)
+	if err != nil {
+		return nil, err
+	}
+
+	return &seederv1alpha1.EventSubscription{
+		URI:         uri,
+		Destination: destination,
+		Context:     subscriptionContext,
+	}, nil
+}
+
 // SetupWithManager sets up the controller with the Manager.
 func (r *InventoryEventReconciller) SetupWithManager(mgr ctrl.Manager) error {
 	return ctrl.NewControllerManagedBy(mgr).
@@ -4,6 +4,7 @@ import (
 	"fmt"
 
 	seederv1alpha1 "github.com/harvester/seeder/pkg/api/v1alpha1"
+	"github.com/harvester/seeder/pkg/util"
 	. "github.com/onsi/ginkgo/v2"
 	. "github.com/onsi/gomega"
 	rufio "github.com/tinkerbell/rufio/api/v1alpha1"
@@ -12,6 +13,7 @@ import (
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/types"
 	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
 )
 
 var _ = Describe("Inventory event controller tests", func() {
@@ -108,10 +110,14 @@ var _ = Describe("Inventory event controller tests", func() {
 				return err
 			}
 
-			_, ok := iObj.Labels["manufacturer"]
+			_, ok := iObj.Labels[util.DefaultHardwareLabelPrefix+util.HardwareManufacturer]
 			if !ok {
 				return fmt.Errorf("waiting for manufacturer to be populated")
 			}
+
+			if iObj.Status.Health == nil || iObj.Status.Health.Status != seederv1alpha1.HealthOK {
+				return fmt.Errorf("waiting for health to be populated")
+			}
 			return nil
 		}, "120s", "5s").ShouldNot(HaveOccurred())
 
@@ -129,6 +135,117 @@ var _ = Describe("Inventory event controller tests", func() {
 		}, "120s", "5s").ShouldNot(HaveOccurred())
 	})
 
+	It("remove event subscription when events are disabled", func() {
+		Eventually(func() error {
+			iObj := &seederv1alpha1.Inventory{}
+			if err := k8sClient.Get(ctx, types.NamespacedName{Namespace: i.Namespace, Name: i.Name}, iObj); err != nil {
+				return err
+			}
+			iObj.Labels = map[string]string{
+				seederv1alpha1.OverrideRedfishPortLabel: redfishPort,
+			}
+			return k8sClient.Update(ctx, iObj)
+		}, "30s", "5s").ShouldNot(HaveOccurred())
+
+		Eventually(func() error {
+			iObj := &seederv1alpha1.Inventory{}
+			if err := k8sClient.Get(ctx, types.NamespacedName{Namespace: i.Namespace, Name: i.Name}, iObj); err != nil {
+				return err
+			}
+			if iObj.Status.Status != seederv1alpha1.InventoryReady {
+				return fmt.Errorf("waiting for inventory to be ready. Current status %v", iObj.Status.Status)
+			}
+			return nil
+		}, "30s", "5s").ShouldNot(HaveOccurred())
+
+		// simulate a subscription registered while the event receiver was configured. The subscription no longer
+		// exists on the mock BMC, which is treated as removed
+		Eventually(func() error {
+			iObj := &seederv1alpha1.Inventory{}
+			if err := k8sClient.Get(ctx, types.NamespacedName{Namespace: i.Namespace, Name: i.Name}, iObj); err != nil {
+				return err
+			}
+			iObj.Status.EventSubscription = &seederv1alpha1.EventSubscription{
+				URI:         "/redfish/v1/EventService/Subscriptions/1",
+				Destination: "https://seeder.example.com" + RedfishEventPath + "event-test/events",
+				Context:     "event-test",
+			}
+			return k8sClient.Status().Update(ctx, iObj)
+		}, "30s", "5s").ShouldNot(HaveOccurred())
+
+		Eventually(func() error {
+			iObj := &seederv1alpha1.Inventory{}
+			if err := k8sClient.Get(ctx, types.NamespacedName{Namespace: i.Namespace, Name: i.Name}, iObj); err != nil {
+				return err
+			}
+			controllerutil.AddFinalizer(iObj, seederv1alpha1.InventoryEventFinalizer)
+			iObj.Spec.Events.Enabled = false
+			return k8sClient.Update(ctx, iObj)
+		}, "30s", "5s").ShouldNot(HaveOccurred())
+
+		Eventually(func() error {
+			iObj := &seederv1alpha1.Inventory{}
+			if err := k8sClient.Get(ctx, types.NamespacedName{Namespace: i.Namespace, Name: i.Name}, iObj); err != nil {
+				return err
+			}
+			if iObj.Status.EventSubscription != nil {
+				return fmt.Errorf("waiting for event subscription to be removed")
+			}
+			if controllerutil.ContainsFinalizer(iObj, seederv1alpha1.InventoryEventFinalizer) {
+				return fmt.Errorf("waiting for event finalizer to be removed")
+			}
+			return nil
+		}, "60s", "5s").ShouldNot(HaveOccurred())
+	})
+
+	It("remove event subscription when inventory is deleted", func() {
+		Eventually(func() error {
+			iObj := &seederv1alpha1.Inventory{}
+			if err := k8sClient.Get(ctx, types.NamespacedName{Namespace: i.Namespace, Name: i.Name}, iObj); err != nil {
+				return err
+			}
+			iObj.Labels = map[string]string{
+				seederv1alpha1.OverrideRedfishPortLabel: redfishPort,
+			}
+			controllerutil.AddFinalizer(iObj, seederv1alpha1.InventoryEventFinalizer)
+			return k8sClient.Update(ctx, iObj)
+		}, "30s", "5s").ShouldNot(HaveOccurred())
+
+		Eventually(func() error {
+			iObj := &seederv1alpha1.Inventory{}
+			if err := k8sClient.Get(ctx, types.NamespacedName{Namespace: i.Namespace, Name: i.Name}, iObj); err != nil {
+				return err
+			}
+			iObj.Status.EventSubscription = &seederv1alpha1.EventSubscription{
+				URI:         "/redfish/v1/EventService/Subscriptions/1",
+				Destination: "https://seeder.example.com" + RedfishEventPath + "event-test/events",
+				Context:     "event-test",
+			}
+			return k8sClient.Status().Update(ctx, iObj)
+		}, "30s", "5s").ShouldNot(HaveOccurred())
+
+		Eventually(func() error {
+			return k8sClient.Delete(ctx, i)
+		}, "30s", "5s").ShouldNot(HaveOccurred())
+
+		Eventually(func() error {
+			iObj := &seederv1alpha1.Inventory{}
+			err := k8sClient.Get(ctx, types.NamespacedName{Namespace: i.Namespace, Name: i.Name}, iObj)
+			if apierrors.IsNotFound(err) {
+				return nil
+			}
+			return fmt.Errorf("waiting for inventory to be removed once the subscription is removed: %v", err)
+		}, "60s", "5s").ShouldNot(HaveOccurred())
+
+		// recreate the inventory removed by the AfterEach
+		Eventually(func() error {
+			return k8sClient.Create(ctx, &seederv1alpha1.Inventory{
+				ObjectMeta: metav1.ObjectMeta{Name: i.Name, Namespace: i.Namespace},
+				Spec:       i.Spec,
+			})
+		}, "30s", "5s").ShouldNot(HaveOccurred())
+	})
+
 	AfterEach(func() {
 		Eventually(func() error {
 			return k8sClient.Delete(ctx, creds)
@@ -0,0 +1,219 @@
+package controllers
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/go-logr/logr"
+	seederv1alpha1 "github.com/harvester/seeder/pkg/api/v1alpha1"
+	"github.com/harvester/seeder/pkg/ipam"
+	"github.com/harvester/seeder/pkg/util"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+)
+
+// IPClaimReconciler allocates addresses from address pools to IPClaims
+type IPClaimReconciler struct {
+	client.Client
+	Scheme *runtime.Scheme
+	logr.Logger
+	Providers map[seederv1alpha1.IPAMProvider]ipam.Provider
+}
+
+type ipClaimReconciler func(context.Context, *seederv1alpha1.IPClaim) error
+
+//+kubebuilder:rbac:groups=metal.harvesterhci.io,resources=ipclaims,verbs=get;list;watch;create;update;patch;delete
+//+kubebuilder:rbac:groups=metal.harvesterhci.io,resources=ipclaims/status,verbs=get;update;patch
+//+kubebuilder:rbac:groups=metal.harvesterhci.io,resources=ipclaims/finalizers,verbs=update
+//+kubebuilder:rbac:groups=metal.harvesterhci.io,resources=ipaddresses,verbs=get;list;watch;create;update;patch;delete
+//+kubebuilder:rbac:groups="",resources=secrets,verbs=get;list;watch
+
+// Reconcile binds IPClaims to an address from the requested pool, and releases the address when the claim is deleted
+func (r *IPClaimReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
+	r.Info("Reconcilling ipclaim objects", req.Name, req.Namespace)
+	claim := &seederv1alpha1.IPClaim{}
+
+	err := r.Get(ctx, req.NamespacedName, claim)
+	if err != nil {
+		if apierrors.IsNotFound(err) {
+			return ctrl.Result{}, nil
+		}
+		r.Error(err, "Failed to get IPClaim Object")
+		return ctrl.Result{}, err
+	}
+
+	reconcileList := []ipClaimReconciler{
+		r.bindAddress,
+	}
+
+	deletionReconcileList := []ipClaimReconciler{
+		r.releaseAddress,
+	}
+
+	if claim.DeletionTimestamp.IsZero() {
+		for _, reconciler := range reconcileList {
+			if err := reconciler(ctx, claim); err != nil {
+				return ctrl.Result{}, err
+			}
+		}
+	} else {
+		for _, reconciler := range deletionReconcileList {
+			if err := reconciler(ctx, claim); err != nil {
+				return ctrl.Result{}, err
+			}
+		}
+	}
+
+	return ctrl.Result{}, nil
+}
+
+// bindAddress allocates an address to the claim by creating an IPAddress for it. IPAddresses are named after
+// the address, so a concurrent allocation of the same address fails and is retried with the next free address
+func (r *IPClaimReconciler) bindAddress(ctx context.Context, claim *seederv1alpha1.IPClaim) error {
+	if claim.Status.Address != "" {
+		return nil
+	}
+
+	if !controllerutil.ContainsFinalizer(claim, seederv1alpha1.IPClaimFinalizer) {
+		controllerutil.AddFinalizer(claim, seederv1alpha1.IPClaimFinalizer)
+		return r.Update(ctx, claim)
+	}
+
+	pool := &seederv1alpha1.AddressPool{}
+	if err := r.Get(ctx, types.NamespacedName{Namespace: claim.Spec.PoolReference.Namespace,
+		Name: claim.Spec.PoolReference.Name}, pool); err != nil {
+		return fmt.Errorf("error during address pool lookup for claim %s: %v", claim.Name, err)
+	}
+
+	if pool.Status.Status == "" {
+		return fmt.Errorf("waiting for address pool %s to be ready", pool.Name)
+	}
+
+	addresses, err := util.ListPoolAddresses(ctx, r.Client, pool)
+	if err != nil {
+		return err
+	}
+
+	// the address may already have been created if the last status update of the claim was lost
+	for _, v := range addresses {
+		if v.Spec.ClaimReference.Name == claim.Name && v.Spec.ClaimReference.Namespace == claim.Namespace {
+			return r.bindClaim(ctx, claim, pool, &v)
+		}
+	}
+
+	provider, err := r.provider(pool)
+	if err != nil {
+		return err
+	}
+
+	address, err := provider.Allocate(ctx, pool, claim)
+	if err != nil {
+		claim.Status.Conditions = util.CreateOrUpdateCondition(claim.Status.Conditions, seederv1alpha1.IPClaimFailed, err.Error())
+		if updateErr := r.Status().Update(ctx, claim); updateErr != nil {
+			return updateErr
+		}
+		return err
+	}
+
+	ipAddress, err := util.GenerateIPAddress(pool, claim, address)
+	if err != nil {
+		return err
+	}
+
+	if err := controllerutil.SetControllerReference(pool, ipAddress, r.Scheme); err != nil {
+		return err
+	}
+
+	if err := r.Create(ctx, ipAddress); err != nil {
+		if apierrors.IsAlreadyExists(err) {
+			return fmt.Errorf("address %s in pool %s was allocated concurrently, retrying claim %s", address, pool.Name, claim.Name)
+		}
+		// release the address from external providers, as the allocation was not recorded
+		if releaseErr := provider.Release(ctx, pool, claim, address); releaseErr != nil {
+			r.Error(releaseErr, "error releasing address after failed allocation", "address", address, "pool", pool.Name)
+		}
+		return err
+	}
+
+	return r.bindClaim(ctx, claim, pool, ipAddress)
+}
+
+// bindClaim records the address in the claim status
+func (r *IPClaimReconciler) bindClaim(ctx context.Context, claim *seederv1alpha1.IPClaim, pool *seederv1alpha1.AddressPool, ipAddress *seederv1alpha1.IPAddress) error {
+	claim.Status.Address = ipAddress.Spec.Address
+	claim.Status.Netmask = pool.Status.Netmask
+	claim.Status.Gateway = pool.Spec.Gateway
+	claim.Status.AddressReference = &seederv1alpha1.ObjectReference{
+		Name:      ipAddress.Name,
+		Namespace: ipAddress.Namespace,
+	}
+	claim.Status.Conditions = util.RemoveCondition(claim.Status.Conditions, seederv1alpha1.IPClaimFailed)
+	claim.Status.Conditions = util.CreateOrUpdateCondition(claim.Status.Conditions, seederv1alpha1.IPClaimBound,
+		fmt.Sprintf("address %s allocated from pool %s", ipAddress.Spec.Address, pool.Name))
+	return r.Status().Update(ctx, claim)
+}
+
+// releaseAddress releases the address bound to the claim from the pool provider, and deletes its IPAddress before
+// removing the finalizer
+func (r *IPClaimReconciler) releaseAddress(ctx context.Context, claim *seederv1alpha1.IPClaim) error {
+	if !controllerutil.ContainsFinalizer(claim, seederv1alpha1.IPClaimFinalizer) {
+		return nil
+	}
+
+	// addresses are released from the built-in provider if the pool no longer exists
+	var provider ipam.Provider = ipam.NewAddressPool(r.Client)
+	pool := &seederv1alpha1.AddressPool{}
+	err := r.Get(ctx, types.NamespacedName{Namespace: claim.Spec.PoolReference.Namespace,
+		Name: claim.Spec.PoolReference.Name}, pool)
+	if err != nil && !apierrors.IsNotFound(err) {
+		return err
+	}
+
+	if err == nil {
+		if provider, err = r.provider(pool); err != nil {
+			return err
+		}
+	}
+
+	addressList := &seederv1alpha1.IPAddressList{}
+	if err := r.List(ctx, addressList, client.InNamespace(claim.Spec.PoolReference.Namespace),
+		client.MatchingLabels{seederv1alpha1.AddressPoolLabel: claim.Spec.PoolReference.Name}); err != nil {
+		return err
+	}
+
+	for _, v := range addressList.Items {
+		if v.Spec.ClaimReference.Name != claim.Name || v.Spec.ClaimReference.Namespace != claim.Namespace {
+			continue
+		}
+		if err := provider.Release(ctx, pool, claim, v.Spec.Address); err != nil {
+			return fmt.Errorf("error releasing address %s from pool %s: %v", v.Spec.Address, pool.Name, err)
+		}
+		if err := r.Delete(ctx, &v); err != nil && !apierrors.IsNotFound(err) {
+			return err
+		}
+	}
+
+	controllerutil.RemoveFinalizer(claim, seederv1alpha1.IPClaimFinalizer)
+	return r.Update(ctx, claim)
+}
+
+// provider returns the IPAM provider used by the pool
+func (r *IPClaimReconciler) provider(pool *seederv1alpha1.AddressPool) (ipam.Provider, error) {
+	name := ipam.Name(pool)
+	p, ok := r.Providers[name]
+	if !ok {
+		return nil, fmt.Errorf("unknown ipam provider %s for pool %s", name, pool.Name)
+	}
+	return p, nil
+}
+
+// SetupWithManager sets up the controller with the Manager.
+func (r *IPClaimReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	return ctrl.NewControllerManagedBy(mgr).
+		For(&seederv1alpha1.IPClaim{}).
+		Complete(r)
+}
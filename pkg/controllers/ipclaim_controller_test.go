@@ -0,0 +1,157 @@
+package controllers
+
+import (
+	"fmt"
+
+	seederv1alpha1 "github.com/harvester/seeder/pkg/api/v1alpha1"
+	"github.com/harvester/seeder/pkg/util"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+var _ = Describe("IPClaim controller tests", func() {
+	var a *seederv1alpha1.AddressPool
+	var dynamic, static, conflict *seederv1alpha1.IPClaim
+
+	BeforeEach(func() {
+		a = &seederv1alpha1.AddressPool{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "ipclaim-test",
+				Namespace: "default",
+			},
+			Spec: seederv1alpha1.AddressSpec{
+				CIDR:    "192.168.10.0/29",
+				Gateway: "192.168.10.1",
+			},
+		}
+
+		dynamic = &seederv1alpha1.IPClaim{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "ipclaim-dynamic",
+				Namespace: "default",
+			},
+			Spec: seederv1alpha1.IPClaimSpec{
+				PoolReference: seederv1alpha1.ObjectReference{
+					Name:      "ipclaim-test",
+					Namespace: "default",
+				},
+			},
+		}
+
+		static = &seederv1alpha1.IPClaim{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "ipclaim-static",
+				Namespace: "default",
+			},
+			Spec: seederv1alpha1.IPClaimSpec{
+				PoolReference: seederv1alpha1.ObjectReference{
+					Name:      "ipclaim-test",
+					Namespace: "default",
+				},
+				StaticAddress: "192.168.10.5",
+			},
+		}
+
+		conflict = static.DeepCopy()
+		conflict.Name = "ipclaim-conflict"
+
+		for _, obj := range []client.Object{a, dynamic, static} {
+			obj := obj
+			Eventually(func() error {
+				return k8sClient.Create(ctx, obj)
+			}, "30s", "5s").ShouldNot(HaveOccurred())
+		}
+	})
+
+	It("bind claims and derive pool allocation from claims", func() {
+		Eventually(func() error {
+			for _, claim := range []*seederv1alpha1.IPClaim{dynamic, static} {
+				obj := &seederv1alpha1.IPClaim{}
+				if err := k8sClient.Get(ctx, types.NamespacedName{Namespace: claim.Namespace, Name: claim.Name}, obj); err != nil {
+					return err
+				}
+				if obj.Status.Address == "" {
+					return fmt.Errorf("waiting for claim %s to be bound", claim.Name)
+				}
+				if claim.Spec.StaticAddress != "" && obj.Status.Address != claim.Spec.StaticAddress {
+					return fmt.Errorf("expected claim %s to be bound to %s but got %s", claim.Name, claim.Spec.StaticAddress, obj.Status.Address)
+				}
+			}
+
+			pool := &seederv1alpha1.AddressPool{}
+			if err := k8sClient.Get(ctx, types.NamespacedName{Namespace: a.Namespace, Name: a.Name}, pool); err != nil {
+				return err
+			}
+			if len(pool.Status.AddressAllocation) != 2 {
+				return fmt.Errorf("expected 2 allocations in pool but found %v", pool.Status.AddressAllocation)
+			}
+			ref, ok := pool.Status.AddressAllocation[static.Spec.StaticAddress]
+			if !ok || ref.Kind != seederv1alpha1.KindIPClaim || ref.Name != static.Name {
+				return fmt.Errorf("expected static address to be allocated to claim %s: %v", static.Name, pool.Status.AddressAllocation)
+			}
+			return nil
+		}, "30s", "5s").ShouldNot(HaveOccurred())
+	})
+
+	It("fail claims for an address allocated to another claim", func() {
+		Eventually(func() error {
+			return k8sClient.Create(ctx, conflict)
+		}, "30s", "5s").ShouldNot(HaveOccurred())
+
+		Eventually(func() error {
+			obj := &seederv1alpha1.IPClaim{}
+			if err := k8sClient.Get(ctx, types.NamespacedName{Namespace: conflict.Namespace, Name: conflict.Name}, obj); err != nil {
+				return err
+			}
+			if obj.Status.Address != "" {
+				return fmt.Errorf("expected conflicting claim to not be bound, but got %s", obj.Status.Address)
+			}
+			if !util.ConditionExists(obj.Status.Conditions, seederv1alpha1.IPClaimFailed) {
+				return fmt.Errorf("waiting for claim failed condition %v", obj.Status.Conditions)
+			}
+			return nil
+		}, "30s", "5s").ShouldNot(HaveOccurred())
+
+		// the address is released when the claim is deleted, allowing the conflicting claim to be bound
+		Eventually(func() error {
+			return k8sClient.Delete(ctx, static)
+		}, "30s", "5s").ShouldNot(HaveOccurred())
+
+		Eventually(func() error {
+			obj := &seederv1alpha1.IPClaim{}
+			if err := k8sClient.Get(ctx, types.NamespacedName{Namespace: conflict.Namespace, Name: conflict.Name}, obj); err != nil {
+				return err
+			}
+			if obj.Status.Address != conflict.Spec.StaticAddress {
+				return fmt.Errorf("waiting for conflicting claim to be bound")
+			}
+			return nil
+		}, "60s", "5s").ShouldNot(HaveOccurred())
+	})
+
+	AfterEach(func() {
+		for _, obj := range []client.Object{dynamic, static, conflict, a} {
+			obj := obj
+			Eventually(func() error {
+				err := k8sClient.Delete(ctx, obj)
+				if apierrors.IsNotFound(err) {
+					return nil
+				}
+				return err
+			}, "30s", "5s").ShouldNot(HaveOccurred())
+		}
+
+		Eventually(func() error {
+			pool := &seederv1alpha1.AddressPool{}
+			err := k8sClient.Get(ctx, types.NamespacedName{Namespace: a.Namespace, Name: a.Name}, pool)
+			if apierrors.IsNotFound(err) {
+				return nil
+			}
+			return fmt.Errorf("waiting for pool to be removed")
+		}, "30s", "5s").ShouldNot(HaveOccurred())
+	})
+})
@@ -0,0 +1,193 @@
+package controllers
+
+import (
+	"context"
+	"crypto/subtle"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"net/http"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/go-logr/logr"
+	seederv1alpha1 "github.com/harvester/seeder/pkg/api/v1alpha1"
+	"github.com/harvester/seeder/pkg/events"
+	"github.com/harvester/seeder/pkg/util"
+	certutil "github.com/rancher/dynamiclistener/cert"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/record"
+	"k8s.io/client-go/util/retry"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+const (
+	// RedfishEventPath is the path events are delivered to, followed by the namespace and name of the inventory
+	RedfishEventPath = "/redfish/events/"
+
+	// maxEventSize limits the size of an event payload accepted by the receiver
+	maxEventSize = 1 << 20
+	// receiverShutdownTimeout is the time allowed for in flight events to be recorded when the manager stops
+	receiverShutdownTimeout = 10 * time.Second
+)
+
+// RedfishEventReceiver is a HTTPS server receiving events delivered by the BMC to subscriptions registered by
+// the InventoryEventReconciller. Events are recorded on the inventory, and warning or critical alerts trigger an
+// immediate poll of the BMC
+type RedfishEventReceiver struct {
+	client.Client
+	logr.Logger
+	record.EventRecorder
+	// BindAddress is the address the receiver listens on
+	BindAddress string
+	// CertDir contains the tls.crt and tls.key served by the receiver. A self signed certificate is generated
+	// when it is empty
+	CertDir string
+}
+
+// Start implements manager.Runnable, and serves events until the context is cancelled
+func (r *RedfishEventReceiver) Start(ctx context.Context) error {
+	cert, err := r.certificate()
+	if err != nil {
+		return fmt.Errorf("error loading redfish event receiver certificate: %w", err)
+	}
+
+	srv := &http.Server{
+		Addr:              r.BindAddress,
+		Handler:           r,
+		ReadHeaderTimeout: 30 * time.Second,
+		TLSConfig: &tls.Config{
+			Certificates: []tls.Certificate{cert},
+			MinVersion:   tls.VersionTLS12,
+		},
+	}
+
+	go func() {
+		<-ctx.Done()
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), receiverShutdownTimeout)
+		defer cancel()
+		if err := srv.Shutdown(shutdownCtx); err != nil {
+			r.Error(err, "error shutting down redfish event receiver")
+		}
+	}()
+
+	r.Info("starting redfish event receiver", "address", r.BindAddress)
+	if err := srv.ListenAndServeTLS("", ""); err != nil && !errors.Is(err, http.ErrServerClosed) {
+		return err
+	}
+
+	return nil
+}
+
+// NeedLeaderElection implements manager.LeaderElectionRunnable. Events may be delivered to any replica
+func (r *RedfishEventReceiver) NeedLeaderElection() bool {
+	return false
+}
+
+func (r *RedfishEventReceiver) certificate() (tls.Certificate, error) {
+	if r.CertDir != "" {
+		return tls.LoadX509KeyPair(filepath.Join(r.CertDir, "tls.crt"), filepath.Join(r.CertDir, "tls.key"))
+	}
+
+	cert, key, err := certutil.GenerateSelfSignedCertKey("seeder", nil, nil)
+	if err != nil {
+		return tls.Certificate{}, err
+	}
+
+	return tls.X509KeyPair(cert, key)
+}
+
+// ServeHTTP records the events delivered to /redfish/events/<namespace>/<name>. Events are only accepted for
+// inventories with events enabled, and when the context of the event matches the subscription of the inventory
+func (r *RedfishEventReceiver) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	if req.Method != http.MethodPost {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	parts := strings.Split(strings.TrimPrefix(req.URL.Path, RedfishEventPath), "/")
+	if !strings.HasPrefix(req.URL.Path, RedfishEventPath) || len(parts) != 2 || parts[0] == "" || parts[1] == "" {
+		http.NotFound(w, req)
+		return
+	}
+
+	e, err := events.ParseEvent(http.MaxBytesReader(w, req.Body, maxEventSize))
+	if err != nil {
+		http.Error(w, fmt.Sprintf("error parsing event: %v", err), http.StatusBadRequest)
+		return
+	}
+
+	i := &seederv1alpha1.Inventory{}
+	err = r.Get(req.Context(), types.NamespacedName{Namespace: parts[0], Name: parts[1]}, i)
+	if err != nil {
+		if apierrors.IsNotFound(err) {
+			http.NotFound(w, req)
+			return
+		}
+		r.Error(err, "error fetching inventory for redfish event", "inventory", parts[1], "namespace", parts[0])
+		http.Error(w, "error fetching inventory", http.StatusInternalServerError)
+		return
+	}
+
+	if !i.Spec.Events.Enabled || i.Status.EventSubscription == nil {
+		http.NotFound(w, req)
+		return
+	}
+
+	if subtle.ConstantTimeCompare([]byte(e.Context), []byte(i.Status.EventSubscription.Context)) != 1 {
+		http.Error(w, "invalid event context", http.StatusUnauthorized)
+		return
+	}
+
+	if err := r.recordEvents(req.Context(), i, e); err != nil {
+		r.Error(err, "error recording redfish event", "inventory", i.Name, "namespace", i.Namespace)
+		http.Error(w, "error recording event", http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+}
+
+// recordEvents records each event on the inventory. The last warning or critical alert is kept in the
+// inventoryBMCAlert condition, and the next poll time of the inventory is removed to refresh its health
+func (r *RedfishEventReceiver) recordEvents(ctx context.Context, i *seederv1alpha1.Inventory, e *events.Event) error {
+	var alert string
+	for _, v := range e.Events {
+		message := v.Message
+		if v.MessageID != "" {
+			message = fmt.Sprintf("%s: %s", v.MessageID, v.Message)
+		}
+
+		if events.IsWarning(v) {
+			alert = message
+			r.Event(i, "Warning", "RedfishAlert", message)
+		} else {
+			r.Event(i, "Normal", "RedfishEvent", message)
+		}
+	}
+
+	if alert == "" {
+		return nil
+	}
+
+	return retry.RetryOnConflict(retry.DefaultRetry, func() error {
+		obj := &seederv1alpha1.Inventory{}
+		if err := r.Get(ctx, types.NamespacedName{Namespace: i.Namespace, Name: i.Name}, obj); err != nil {
+			return err
+		}
+
+		obj.Status.Conditions = util.CreateOrUpdateCondition(obj.Status.Conditions, seederv1alpha1.InventoryBMCAlert, alert)
+		if err := r.Status().Update(ctx, obj); err != nil {
+			return err
+		}
+
+		if _, ok := obj.Annotations[NextCheckTime]; !ok {
+			return nil
+		}
+
+		delete(obj.Annotations, NextCheckTime)
+		return r.Update(ctx, obj)
+	})
+}
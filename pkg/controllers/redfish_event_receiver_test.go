@@ -0,0 +1,143 @@
+package controllers
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+
+	seederv1alpha1 "github.com/harvester/seeder/pkg/api/v1alpha1"
+	"github.com/harvester/seeder/pkg/util"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/record"
+	ctrl "sigs.k8s.io/controller-runtime"
+)
+
+const criticalEvent = `{
+	"Context": "%s",
+	"Events": [
+		{
+			"EventType": "Alert",
+			"EventId": "2162",
+			"EventTimestamp": "2022-07-01T10:00:00-05:00",
+			"Severity": "Critical",
+			"Message": "The power input for power supply 1 is lost.",
+			"MessageId": "PSU0003",
+			"OriginOfCondition": {"@odata.id": "/redfish/v1/Chassis/System.Embedded.1"}
+		}
+	]
+}`
+
+var _ = Describe("Redfish event receiver tests", func() {
+	var i *seederv1alpha1.Inventory
+	var ns *corev1.Namespace
+	var receiver *RedfishEventReceiver
+	BeforeEach(func() {
+		ns = &corev1.Namespace{
+			ObjectMeta: metav1.ObjectMeta{
+				Name: "receiver-test",
+			},
+		}
+
+		i = &seederv1alpha1.Inventory{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "receiver",
+				Namespace: "receiver-test",
+				Annotations: map[string]string{
+					NextCheckTime: "2099-01-01T00:00:00Z",
+				},
+			},
+			Spec: seederv1alpha1.InventorySpec{
+				PrimaryDisk:                   "/dev/sda",
+				ManagementInterfaceMacAddress: "xx:xx:xx:xx:xx",
+				Events: seederv1alpha1.Events{
+					Enabled:         true,
+					PollingInterval: "1h",
+				},
+			},
+		}
+
+		receiver = &RedfishEventReceiver{
+			Client:        k8sClient,
+			Logger:        ctrl.Log.WithName("redfish-event-receiver"),
+			EventRecorder: record.NewFakeRecorder(10),
+		}
+
+		Eventually(func() error {
+			return k8sClient.Create(ctx, ns)
+		}, "60s", "5s").ShouldNot(HaveOccurred())
+
+		Eventually(func() error {
+			return k8sClient.Create(ctx, i)
+		}, "30s", "5s").ShouldNot(HaveOccurred())
+
+		Eventually(func() error {
+			iObj := &seederv1alpha1.Inventory{}
+			if err := k8sClient.Get(ctx, types.NamespacedName{Namespace: i.Namespace, Name: i.Name}, iObj); err != nil {
+				return err
+			}
+			iObj.Status.EventSubscription = &seederv1alpha1.EventSubscription{
+				URI:         "/redfish/v1/EventService/Subscriptions/1",
+				Destination: "https://seeder.example.com" + RedfishEventPath + "receiver-test/receiver",
+				Context:     "valid-context",
+			}
+			return k8sClient.Status().Update(ctx, iObj)
+		}, "30s", "5s").ShouldNot(HaveOccurred())
+	})
+
+	It("rejects events with an invalid context", func() {
+		req := httptest.NewRequest(http.MethodPost, RedfishEventPath+"receiver-test/receiver", strings.NewReader(fmt.Sprintf(criticalEvent, "invalid-context")))
+		resp := httptest.NewRecorder()
+		receiver.ServeHTTP(resp, req)
+		Expect(resp.Code).To(Equal(http.StatusUnauthorized))
+
+		req = httptest.NewRequest(http.MethodPost, RedfishEventPath+"receiver-test/missing", strings.NewReader(fmt.Sprintf(criticalEvent, "valid-context")))
+		resp = httptest.NewRecorder()
+		receiver.ServeHTTP(resp, req)
+		Expect(resp.Code).To(Equal(http.StatusNotFound))
+
+		req = httptest.NewRequest(http.MethodGet, RedfishEventPath+"receiver-test/receiver", nil)
+		resp = httptest.NewRecorder()
+		receiver.ServeHTTP(resp, req)
+		Expect(resp.Code).To(Equal(http.StatusMethodNotAllowed))
+	})
+
+	It("records critical alerts on the inventory", func() {
+		req := httptest.NewRequest(http.MethodPost, RedfishEventPath+"receiver-test/receiver", strings.NewReader(fmt.Sprintf(criticalEvent, "valid-context")))
+		resp := httptest.NewRecorder()
+		receiver.ServeHTTP(resp, req)
+		Expect(resp.Code).To(Equal(http.StatusOK))
+
+		iObj := &seederv1alpha1.Inventory{}
+		err := k8sClient.Get(ctx, types.NamespacedName{Namespace: i.Namespace, Name: i.Name}, iObj)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(util.ConditionMessage(iObj.Status.Conditions, seederv1alpha1.InventoryBMCAlert)).To(Equal("PSU0003: The power input for power supply 1 is lost."))
+		Expect(iObj.Annotations).ToNot(HaveKey(NextCheckTime))
+		Expect(receiver.EventRecorder.(*record.FakeRecorder).Events).To(Receive(ContainSubstring("RedfishAlert")))
+	})
+
+	AfterEach(func() {
+		Eventually(func() error {
+			return k8sClient.Delete(ctx, i)
+		}).ShouldNot(HaveOccurred())
+
+		Eventually(func() error {
+			err := k8sClient.Get(ctx, types.NamespacedName{Namespace: i.Namespace, Name: i.Name}, i)
+			if err != nil {
+				if apierrors.IsNotFound(err) {
+					return nil
+				}
+			}
+			return fmt.Errorf("waiting for inventory object to be not found")
+		}, "30s", "5s").ShouldNot(HaveOccurred())
+
+		Eventually(func() error {
+			return k8sClient.Delete(ctx, ns)
+		}).ShouldNot(HaveOccurred())
+	})
+})
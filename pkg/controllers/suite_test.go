@@ -26,7 +26,11 @@ import (
 	tinkv1alpha1 "github.com/tinkerbell/tink/pkg/apis/core/v1alpha1"
 
 	seederv1alpha1 "github.com/harvester/seeder/pkg/api/v1alpha1"
+	"github.com/harvester/seeder/pkg/ipam"
 	"github.com/harvester/seeder/pkg/mock"
+	"github.com/harvester/seeder/pkg/provisioner"
+	"github.com/harvester/seeder/pkg/redfish"
+	"github.com/harvester/seeder/pkg/util"
 	. "github.com/onsi/ginkgo/v2"
 	. "github.com/onsi/gomega"
 	rufio "github.com/tinkerbell/rufio/api/v1alpha1"
@@ -56,8 +60,15 @@ var (
 	pool        *dockertest.Pool
 	redfishPort string
 	redfishMock *dockertest.Resource
+	// fakeProvisioner is used by clusters with the fake provisioner
+	fakeProvisioner = provisioner.NewFake()
+	// fakeVirtualMedia is used by inventory booted from virtual media
+	fakeVirtualMedia = redfish.NewFake()
 )
 
+// virtualMediaURL is the url of the virtual media server config ISOs are inserted from
+const virtualMediaURL = "http://seeder.example.com:9446"
+
 func TestAPIs(t *testing.T) {
 	RegisterFailHandler(Fail)
 
@@ -105,9 +116,11 @@ var _ = BeforeSuite(func() {
 	Expect(err).NotTo(HaveOccurred())
 
 	err = (&InventoryReconciler{
-		Client: mgr.GetClient(),
-		Scheme: mgr.GetScheme(),
-		Logger: log.Log.WithName("controller.inventory"),
+		Client:                mgr.GetClient(),
+		Scheme:                mgr.GetScheme(),
+		Logger:                log.Log.WithName("controller.inventory"),
+		NewVirtualMediaClient: fakeVirtualMedia.NewClient,
+		VirtualMediaURL:       virtualMediaURL,
 	}).SetupWithManager(mgr)
 	Expect(err).NotTo(HaveOccurred())
 
@@ -126,16 +139,29 @@ var _ = BeforeSuite(func() {
 	Expect(err).NotTo(HaveOccurred())
 
 	err = (&AddressPoolReconciler{
-		Client: mgr.GetClient(),
-		Scheme: mgr.GetScheme(),
-		Logger: log.Log.WithName("controller.addresspool"),
+		Client:        mgr.GetClient(),
+		Scheme:        mgr.GetScheme(),
+		Logger:        log.Log.WithName("controller.addresspool"),
+		EventRecorder: mgr.GetEventRecorderFor("seeder"),
+		AuditInterval: 5 * time.Second,
+	}).SetupWithManager(mgr)
+	Expect(err).NotTo(HaveOccurred())
+
+	err = (&IPClaimReconciler{
+		Client:    mgr.GetClient(),
+		Scheme:    mgr.GetScheme(),
+		Logger:    log.Log.WithName("controller.ipclaim"),
+		Providers: ipam.NewDefaultProviders(mgr.GetClient()),
 	}).SetupWithManager(mgr)
 	Expect(err).NotTo(HaveOccurred())
 
+	provisioners := provisioner.NewDefaultProvisioners(mgr.GetClient(), mgr.GetScheme())
+	provisioners[provisioner.FakeProvisioner] = fakeProvisioner
 	err = (&ClusterReconciler{
-		Client: mgr.GetClient(),
-		Scheme: mgr.GetScheme(),
-		Logger: log.Log.WithName("controller.cluster"),
+		Client:       mgr.GetClient(),
+		Scheme:       mgr.GetScheme(),
+		Logger:       log.Log.WithName("controller.cluster"),
+		Provisioners: provisioners,
 	}).SetupWithManager(mgr)
 	Expect(err).NotTo(HaveOccurred())
 
@@ -144,13 +170,23 @@ var _ = BeforeSuite(func() {
 		Scheme:        mgr.GetScheme(),
 		Logger:        log.Log.WithName("controller.invenory-event"),
 		EventRecorder: mgr.GetEventRecorderFor("seeder"),
+		HardwareLabels: util.HardwareLabels{
+			Prefix: util.DefaultHardwareLabelPrefix,
+			Keys:   util.DefaultHardwareLabelKeys,
+		},
 	}).SetupWithManager(mgr)
 	Expect(err).NotTo(HaveOccurred())
 
 	err = (&ClusterEventReconciler{
-		Client: mgr.GetClient(),
-		Scheme: mgr.GetScheme(),
-		Logger: log.Log.WithName("controller.cluster-event"),
+		Client:        mgr.GetClient(),
+		Scheme:        mgr.GetScheme(),
+		Logger:        log.Log.WithName("controller.cluster-event"),
+		EventRecorder: mgr.GetEventRecorderFor("seeder"),
+		HardwareLabels: util.HardwareLabels{
+			Prefix: util.DefaultHardwareLabelPrefix,
+			Keys:   util.DefaultHardwareLabelKeys,
+		},
+		Provisioners: provisioners,
 	}).SetupWithManager(mgr)
 	Expect(err).NotTo(HaveOccurred())
 
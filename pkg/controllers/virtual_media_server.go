@@ -0,0 +1,141 @@
+package controllers
+
+import (
+	"bytes"
+	"context"
+	"crypto/subtle"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/go-logr/logr"
+	seederv1alpha1 "github.com/harvester/seeder/pkg/api/v1alpha1"
+	"github.com/harvester/seeder/pkg/iso"
+	"github.com/harvester/seeder/pkg/tink"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+const (
+	// VirtualMediaPath is the path config ISOs are served from, followed by the namespace, name and virtual media
+	// token of the inventory
+	VirtualMediaPath = "/virtualmedia/"
+	// VirtualMediaConfigISO is the file name of the config ISO
+	VirtualMediaConfigISO = "config.iso"
+
+	// configVolumeID is the volume label of config ISOs. The installer reads the user-data and meta-data
+	// files from the NoCloud config drive with this label
+	configVolumeID = "cidata"
+)
+
+// VirtualMediaServer is a HTTP server serving the install config ISOs inserted alongside the Harvester ISO
+// into the BMC of inventories booted using virtual media. BMCs fetch images over plain HTTP as most do not
+// trust the certificates of the seeder, so ISOs are only served to requests with the token of the inventory
+type VirtualMediaServer struct {
+	client.Client
+	logr.Logger
+	// BindAddress is the address the server listens on
+	BindAddress string
+}
+
+// Start implements manager.Runnable, and serves config ISOs until the context is cancelled
+func (s *VirtualMediaServer) Start(ctx context.Context) error {
+	srv := &http.Server{
+		Addr:              s.BindAddress,
+		Handler:           s,
+		ReadHeaderTimeout: 30 * time.Second,
+	}
+
+	go func() {
+		<-ctx.Done()
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), receiverShutdownTimeout)
+		defer cancel()
+		if err := srv.Shutdown(shutdownCtx); err != nil {
+			s.Error(err, "error shutting down virtual media server")
+		}
+	}()
+
+	s.Info("starting virtual media server", "address", s.BindAddress)
+	if err := srv.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+		return err
+	}
+
+	return nil
+}
+
+// NeedLeaderElection implements manager.LeaderElectionRunnable. ISOs may be fetched from any replica
+func (s *VirtualMediaServer) NeedLeaderElection() bool {
+	return false
+}
+
+// ServeHTTP serves the config ISO of the inventory at /virtualmedia/<namespace>/<name>/<token>/config.iso. The
+// token must match the virtual media token of the inventory, which is only set while virtual media is inserted
+func (s *VirtualMediaServer) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	if req.Method != http.MethodGet && req.Method != http.MethodHead {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	parts := strings.Split(strings.TrimPrefix(req.URL.Path, VirtualMediaPath), "/")
+	if !strings.HasPrefix(req.URL.Path, VirtualMediaPath) || len(parts) != 4 || parts[0] == "" || parts[1] == "" || parts[3] != VirtualMediaConfigISO {
+		http.NotFound(w, req)
+		return
+	}
+
+	i := &seederv1alpha1.Inventory{}
+	err := s.Get(req.Context(), types.NamespacedName{Namespace: parts[0], Name: parts[1]}, i)
+	if err != nil {
+		if apierrors.IsNotFound(err) {
+			http.NotFound(w, req)
+			return
+		}
+		s.Error(err, "error fetching inventory", "namespace", parts[0], "name", parts[1])
+		http.Error(w, "error fetching inventory", http.StatusInternalServerError)
+		return
+	}
+
+	// an unknown token is reported as not found, so requests cannot be used to discover inventories
+	if i.Status.VirtualMediaToken == "" || subtle.ConstantTimeCompare([]byte(i.Status.VirtualMediaToken), []byte(parts[2])) != 1 {
+		http.NotFound(w, req)
+		return
+	}
+
+	image, err := s.configISO(req.Context(), i)
+	if err != nil {
+		s.Error(err, "error generating config iso", "namespace", i.Namespace, "name", i.Name)
+		http.Error(w, "error generating config iso", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/octet-stream")
+	http.ServeContent(w, req, VirtualMediaConfigISO, i.CreationTimestamp.Time, bytes.NewReader(image))
+}
+
+// configISO generates the config ISO containing the install config of the inventory
+func (s *VirtualMediaServer) configISO(ctx context.Context, i *seederv1alpha1.Inventory) ([]byte, error) {
+	c := &seederv1alpha1.Cluster{}
+	if err := s.Get(ctx, types.NamespacedName{Namespace: i.Status.Cluster.Namespace, Name: i.Status.Cluster.Name}, c); err != nil {
+		return nil, err
+	}
+
+	userData, err := tink.GenerateInstallConfig(i, c)
+	if err != nil {
+		return nil, err
+	}
+
+	var out bytes.Buffer
+	err = iso.Write(&out, configVolumeID, []iso.File{
+		{Name: "user-data", Data: userData},
+		{Name: "meta-data", Data: []byte(fmt.Sprintf("instance-id: %s-%s\n", i.Namespace, i.Name))},
+	}, i.CreationTimestamp.Time)
+	return out.Bytes(), err
+}
+
+// VirtualMediaConfigURL returns the URL the config ISO of the inventory is served from
+func VirtualMediaConfigURL(serverURL string, i *seederv1alpha1.Inventory) string {
+	return fmt.Sprintf("%s%s%s/%s/%s/%s", strings.TrimSuffix(serverURL, "/"), VirtualMediaPath, i.Namespace, i.Name,
+		i.Status.VirtualMediaToken, VirtualMediaConfigISO)
+}
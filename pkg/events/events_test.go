@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"log"
 	"os"
+	"strings"
 	"testing"
 	"time"
 
@@ -58,3 +59,74 @@ func Test_GetInventory(t *testing.T) {
 	assert.Equal(health, "OK", "expected health to be ok")
 	ef.client.HTTPClient.CloseIdleConnections()
 }
+
+func Test_GetTelemetry(t *testing.T) {
+	assert := require.New(t)
+	telemetry, err := ef.GetTelemetry()
+	assert.NoErrorf(err, "expected no error during telemetry call")
+	assert.Len(telemetry.Temperatures, 4, "expected temperature readings of the system chassis")
+	assert.Equal("System.Embedded.1", telemetry.Temperatures[0].Chassis, "expected readings to reference their chassis")
+	assert.NotEmpty(telemetry.Fans, "expected fan readings")
+	assert.Len(telemetry.PowerSupplies, 2, "expected power supply status")
+	assert.Equal(float64(247), telemetry.PowerConsumedWatts["System.Embedded.1"], "expected power consumption of the system chassis")
+	ef.client.HTTPClient.CloseIdleConnections()
+}
+
+func Test_ParseEvent(t *testing.T) {
+	assert := require.New(t)
+	payload := `{"Context": "seeder", "Events": [
+		{"EventType": "Alert", "Severity": "Critical", "MessageId": "PSU0003", "Message": "The power input for power supply 1 is lost."},
+		{"EventType": "Alert", "MessageSeverity": "OK", "MessageId": "PSU0001", "Message": "Power supply 1 is operating normally."}
+	]}`
+	e, err := ParseEvent(strings.NewReader(payload))
+	assert.NoError(err, "expected no error parsing event")
+	assert.Equal("seeder", e.Context, "expected context of the subscription")
+	assert.Len(e.Events, 2, "expected two event records")
+	assert.True(IsWarning(e.Events[0]), "expected critical event to be a warning")
+	assert.False(IsWarning(e.Events[1]), "expected ok event not to be a warning")
+}
+
+func Test_GetLogEntries(t *testing.T) {
+	assert := require.New(t)
+	services, err := ef.GetLogEntries([]string{"sel"})
+	assert.NoError(err, "expected no error reading log services")
+	assert.Len(services, 1, "expected only the SEL log service")
+	assert.Len(services[0].Entries, 5, "expected all SEL entries")
+	assert.Equal("1", services[0].Entries[0].ID, "expected oldest entry first")
+	assert.Equal("Critical", services[0].Entries[3].Severity, "expected severity of entry 4")
+	ef.client.HTTPClient.CloseIdleConnections()
+}
+
+func Test_NewLogEntries(t *testing.T) {
+	assert := require.New(t)
+	now := time.Now().Truncate(time.Second)
+	entries := []LogEntry{
+		{ID: "1", Created: now.Add(-2 * time.Minute)},
+		{ID: "2", Created: now.Add(-time.Minute)},
+		{ID: "3", Created: now.Add(-time.Minute)},
+	}
+
+	newEntries, cursor := NewLogEntries(entries, LogCursor{})
+	assert.Len(newEntries, 3, "expected all entries to be new")
+	assert.Equal(LogCursor{Created: now.Add(-time.Minute), IDs: []string{"2", "3"}}, cursor, "expected cursor at newest entries")
+
+	newEntries, cursor = NewLogEntries(entries, cursor)
+	assert.Empty(newEntries, "expected no new entries")
+
+	entries = append(entries, LogEntry{ID: "4", Created: now.Add(-time.Minute)}, LogEntry{ID: "5", Created: now})
+	newEntries, cursor = NewLogEntries(entries, cursor)
+	assert.Len(newEntries, 2, "expected entries added after the cursor")
+	assert.Equal("4", newEntries[0].ID, "expected entry created at the cursor time to be new")
+	assert.Equal(LogCursor{Created: now, IDs: []string{"5"}}, cursor, "expected cursor to move to newest entry")
+}
+
+func Test_GetHardwareFacts(t *testing.T) {
+	assert := require.New(t)
+	facts, err := ef.GetHardwareFacts()
+	assert.NoError(err, "expected no error reading hardware facts")
+	assert.Equal("Intel(R) Xeon(R) CPU E5-2676 v3 @ 2.40GHz", facts[FactCPUModel], "expected processor model")
+	assert.Equal("512", facts[FactMemory], "expected total system memory")
+	assert.Equal("1000", facts[FactNICSpeed], "expected fastest link speed")
+	assert.Equal("false", facts[FactGPU], "expected no GPU")
+	ef.client.HTTPClient.CloseIdleConnections()
+}
@@ -0,0 +1,82 @@
+package events
+
+import (
+	"strconv"
+
+	"github.com/stmcginnis/gofish/redfish"
+)
+
+// Keys of the hardware facts read from the BMC
+const (
+	FactCPUModel = "cpuModel"
+	FactMemory   = "memory"
+	FactNICSpeed = "nicSpeed"
+	FactGPU      = "gpu"
+	FactRack     = "rack"
+)
+
+// GetHardwareFacts reads the processor model, total system memory in GiB, fastest Ethernet link speed in Mbps, GPU
+// presence and rack of the node. Facts which are not reported by the BMC are omitted
+func (ef *EventFetcher) GetHardwareFacts() (map[string]string, error) {
+	facts := make(map[string]string)
+	systems, err := ef.client.Service.Systems()
+	if err != nil {
+		return nil, err
+	}
+
+	var memory float32
+	var speed int
+	var gpu bool
+	for _, s := range systems {
+		if s.ProcessorSummary.Model != "" {
+			facts[FactCPUModel] = s.ProcessorSummary.Model
+		}
+		memory += s.MemorySummary.TotalSystemMemoryGiB
+
+		nics, err := s.EthernetInterfaces()
+		if err != nil {
+			return nil, err
+		}
+
+		for _, v := range nics {
+			if v.SpeedMbps > speed {
+				speed = v.SpeedMbps
+			}
+		}
+
+		processors, err := s.Processors()
+		if err != nil {
+			return nil, err
+		}
+
+		for _, v := range processors {
+			if v.ProcessorType == redfish.GPUProcessorType {
+				gpu = true
+			}
+		}
+	}
+
+	if memory > 0 {
+		facts[FactMemory] = strconv.FormatFloat(float64(memory), 'f', -1, 32)
+	}
+
+	if speed > 0 {
+		facts[FactNICSpeed] = strconv.Itoa(speed)
+	}
+
+	facts[FactGPU] = strconv.FormatBool(gpu)
+
+	chassis, err := ef.client.Service.Chassis()
+	if err != nil {
+		return nil, err
+	}
+
+	for _, c := range chassis {
+		if c.Location.Placement.Rack != "" {
+			facts[FactRack] = c.Location.Placement.Rack
+			break
+		}
+	}
+
+	return facts, nil
+}
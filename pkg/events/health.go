@@ -0,0 +1,106 @@
+package events
+
+import (
+	"github.com/stmcginnis/gofish/common"
+)
+
+// Health is the hardware health reported by the BMC as OK, Warning or Critical. Subsystems which are not reported
+// by the BMC have an empty health
+type Health struct {
+	// Status is the worst health of the chassis and its subsystems
+	Status     string
+	Processors string
+	Memory     string
+	Storage    string
+	Power      string
+	Fans       string
+}
+
+// healthRank orders the redfish health values, from unknown to Critical
+var healthRank = map[string]int{
+	string(common.OKHealth):       1,
+	string(common.WarningHealth):  2,
+	string(common.CriticalHealth): 3,
+}
+
+// GetHealth reads the health of the chassis, and the processors, memory, storage, power supplies and fans of
+// the node. The health of each subsystem is the worst health reported by its components
+func (ef *EventFetcher) GetHealth() (*Health, error) {
+	h := &Health{}
+	chassis, err := ef.client.Service.Chassis()
+	if err != nil {
+		return nil, err
+	}
+
+	var chassisHealth string
+	for _, c := range chassis {
+		chassisHealth = worst(chassisHealth, string(c.Status.Health))
+
+		thermal, err := c.Thermal()
+		if err != nil {
+			return nil, err
+		}
+
+		if thermal != nil {
+			for _, v := range thermal.Fans {
+				if enabled(v.Status) {
+					h.Fans = worst(h.Fans, string(v.Status.Health))
+				}
+			}
+		}
+
+		power, err := c.Power()
+		if err != nil {
+			return nil, err
+		}
+
+		if power != nil {
+			for _, v := range power.PowerSupplies {
+				if v.Status.State != common.AbsentState {
+					h.Power = worst(h.Power, string(v.Status.Health))
+				}
+			}
+		}
+	}
+
+	systems, err := ef.client.Service.Systems()
+	if err != nil {
+		return nil, err
+	}
+
+	for _, s := range systems {
+		h.Processors = worst(h.Processors, rollup(s.ProcessorSummary.Status))
+		h.Memory = worst(h.Memory, rollup(s.MemorySummary.Status))
+
+		storage, err := s.Storage()
+		if err != nil {
+			return nil, err
+		}
+
+		for _, v := range storage {
+			h.Storage = worst(h.Storage, rollup(v.Status))
+		}
+	}
+
+	h.Status = worst(chassisHealth, h.Processors, h.Memory, h.Storage, h.Power, h.Fans)
+	return h, nil
+}
+
+// rollup returns the health of a resource and its dependents, falling back to the health of the resource
+func rollup(status common.Status) string {
+	if status.HealthRollup != "" {
+		return string(status.HealthRollup)
+	}
+	return string(status.Health)
+}
+
+// worst returns the worst of the health values. Values which are not known redfish health values are ignored
+func worst(values ...string) string {
+	var result string
+	for _, v := range values {
+		if healthRank[v] > healthRank[result] {
+			result = v
+		}
+	}
+	return result
+}
@@ -0,0 +1,148 @@
+package events
+
+import (
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/stmcginnis/gofish/redfish"
+)
+
+// LogService is a BMC log service, such as the System Event Log, and its entries ordered oldest first
+type LogService struct {
+	ID      string
+	URI     string
+	Entries []LogEntry
+}
+
+// LogEntry is an entry read from a BMC log service
+type LogEntry struct {
+	ID string
+	// Created is truncated to seconds, the precision of the cursor stored in the inventory status
+	Created   time.Time
+	Severity  string
+	MessageID string
+	Message   string
+}
+
+// LogCursor identifies the newest entries read from a log service
+type LogCursor struct {
+	Created time.Time
+	// IDs of the entries created at Created
+	IDs []string
+}
+
+// GetLogEntries reads the entries of the manager and system log services whose ID matches one of ids, ignoring
+// case. Entries without a creation time cannot be ordered, and are skipped
+func (ef *EventFetcher) GetLogEntries(ids []string) ([]LogService, error) {
+	var services []*redfish.LogService
+	managers, err := ef.client.Service.Managers()
+	if err != nil {
+		return nil, err
+	}
+
+	for _, m := range managers {
+		ls, err := m.LogServices()
+		if err != nil {
+			return nil, err
+		}
+		services = append(services, ls...)
+	}
+
+	systems, err := ef.client.Service.Systems()
+	if err != nil {
+		return nil, err
+	}
+
+	for _, s := range systems {
+		ls, err := s.LogServices()
+		if err != nil {
+			return nil, err
+		}
+		services = append(services, ls...)
+	}
+
+	var result []LogService
+	for _, s := range services {
+		if !matchesID(s.ID, ids) {
+			continue
+		}
+
+		entries, err := s.Entries()
+		if err != nil {
+			return nil, err
+		}
+
+		l := LogService{ID: s.ID, URI: s.ODataID}
+		for _, e := range entries {
+			created, err := time.Parse(time.RFC3339, e.Created)
+			if err != nil {
+				continue
+			}
+
+			l.Entries = append(l.Entries, LogEntry{
+				ID:        e.ID,
+				Created:   created.Truncate(time.Second),
+				Severity:  string(e.Severity),
+				MessageID: e.MessageID,
+				Message:   e.Message,
+			})
+		}
+
+		sort.SliceStable(l.Entries, func(i, j int) bool {
+			return l.Entries[i].Created.Before(l.Entries[j].Created)
+		})
+		result = append(result, l)
+	}
+
+	return result, nil
+}
+
+// NewLogEntries returns the entries created after the cursor, skipping the entries created at the time of the cursor
+// which have already been read, along with the cursor identifying the newest entry. Entries are expected oldest first
+func NewLogEntries(entries []LogEntry, cursor LogCursor) ([]LogEntry, LogCursor) {
+	var result []LogEntry
+	next := LogCursor{
+		Created: cursor.Created,
+		IDs:     append([]string(nil), cursor.IDs...),
+	}
+
+	for _, e := range entries {
+		if e.Created.Before(cursor.Created) || (e.Created.Equal(cursor.Created) && containsID(cursor.IDs, e.ID)) {
+			continue
+		}
+
+		result = append(result, e)
+		switch {
+		case e.Created.After(next.Created):
+			next = LogCursor{Created: e.Created, IDs: []string{e.ID}}
+		case e.Created.Equal(next.Created):
+			next.IDs = append(next.IDs, e.ID)
+		}
+	}
+
+	return result, next
+}
+
+// IsWarningSeverity returns true for Warning and Critical severities
+func IsWarningSeverity(severity string) bool {
+	return severity == SeverityWarning || severity == SeverityCritical
+}
+
+func matchesID(id string, ids []string) bool {
+	for _, v := range ids {
+		if strings.EqualFold(id, v) {
+			return true
+		}
+	}
+	return false
+}
+
+func containsID(ids []string, id string) bool {
+	for _, v := range ids {
+		if v == id {
+			return true
+		}
+	}
+	return false
+}
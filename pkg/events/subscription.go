@@ -0,0 +1,116 @@
+package events
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+
+	"github.com/stmcginnis/gofish/common"
+	"github.com/stmcginnis/gofish/redfish"
+)
+
+// ErrEventServiceDisabled is returned when the BMC does not support or has disabled the redfish EventService
+var ErrEventServiceDisabled = errors.New("redfish event service is not enabled")
+
+// Severities of redfish events which are reported as warnings
+const (
+	SeverityWarning  = "Warning"
+	SeverityCritical = "Critical"
+)
+
+// Event is the payload delivered by the BMC to an event subscription
+type Event struct {
+	// Context is the context of the subscription which delivered the event
+	Context string        `json:"Context"`
+	Events  []EventRecord `json:"Events"`
+}
+
+// EventRecord is a single event delivered by the BMC
+type EventRecord struct {
+	EventType      string `json:"EventType"`
+	EventID        string `json:"EventId"`
+	EventTimestamp string `json:"EventTimestamp"`
+	Severity       string `json:"Severity"`
+	// MessageSeverity replaces Severity in newer versions of the redfish schema
+	MessageSeverity   string          `json:"MessageSeverity"`
+	Message           string          `json:"Message"`
+	MessageID         string          `json:"MessageId"`
+	OriginOfCondition json.RawMessage `json:"OriginOfCondition"`
+}
+
+// Subscribe registers a subscription on the BMC delivering alerts to destination, and returns the URI of the
+// subscription. An existing subscription to the same destination is reused if its context matches, and replaced
+// otherwise
+func (ef *EventFetcher) Subscribe(destination, context string) (string, error) {
+	es, err := ef.client.Service.EventService()
+	if err != nil {
+		return "", err
+	}
+
+	if !es.ServiceEnabled {
+		return "", ErrEventServiceDisabled
+	}
+
+	subscriptions, err := es.GetEventSubscriptions()
+	if err != nil {
+		return "", err
+	}
+
+	for _, s := range subscriptions {
+		if s.Destination != destination {
+			continue
+		}
+
+		if s.Context == context {
+			return s.ODataID, nil
+		}
+
+		if err := es.DeleteEventSubscription(s.ODataID); err != nil {
+			return "", fmt.Errorf("error removing stale subscription %s: %w", s.ODataID, err)
+		}
+	}
+
+	return es.CreateEventSubscription(destination, []redfish.EventType{redfish.AlertEventType}, nil,
+		redfish.RedfishEventDestinationProtocol, context, nil)
+}
+
+// Unsubscribe removes the subscription with the URI returned by Subscribe. Subscriptions which no longer exist,
+// such as after the BMC is reset, are treated as removed
+func (ef *EventFetcher) Unsubscribe(uri string) error {
+	es, err := ef.client.Service.EventService()
+	if err != nil {
+		return err
+	}
+
+	err = es.DeleteEventSubscription(uri)
+	var redfishErr *common.Error
+	if errors.As(err, &redfishErr) && redfishErr.HTTPReturnedStatusCode == http.StatusNotFound {
+		return nil
+	}
+	return err
+}
+
+// ParseEvent decodes an event delivered by the BMC
+func ParseEvent(r io.Reader) (*Event, error) {
+	e := &Event{}
+	if err := json.NewDecoder(r).Decode(e); err != nil {
+		return nil, err
+	}
+
+	return e, nil
+}
+
+// EventSeverity returns the severity of an event record, preferring MessageSeverity when it is set
+func EventSeverity(e EventRecord) string {
+	if e.MessageSeverity != "" {
+		return e.MessageSeverity
+	}
+	return e.Severity
+}
+
+// IsWarning returns true for events with a Warning or Critical severity
+func IsWarning(e EventRecord) bool {
+	return IsWarningSeverity(EventSeverity(e))
+}
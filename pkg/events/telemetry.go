@@ -0,0 +1,93 @@
+package events
+
+import (
+	"github.com/stmcginnis/gofish/common"
+)
+
+// Telemetry contains the sensor and power readings of a node
+type Telemetry struct {
+	Temperatures  []Reading
+	Fans          []Reading
+	PowerSupplies []PowerSupply
+	// PowerConsumedWatts is the power consumed by each chassis, keyed by chassis ID
+	PowerConsumedWatts map[string]float64
+}
+
+// Reading is a sensor reading. Sensor names are only unique within a chassis
+type Reading struct {
+	// Chassis is the ID of the chassis reporting the sensor
+	Chassis string
+	Name    string
+	Value   float64
+	Units   string
+}
+
+// PowerSupply is the status of a power supply
+type PowerSupply struct {
+	// Chassis is the ID of the chassis reporting the power supply
+	Chassis string
+	Name    string
+	Health  string
+	State   string
+}
+
+// GetTelemetry reads the temperatures, fan speeds, power supply status and power consumption from the
+// Thermal and Power resources of all chassis. Sensors which are absent or disabled are skipped
+func (ef *EventFetcher) GetTelemetry() (*Telemetry, error) {
+	chassis, err := ef.client.Service.Chassis()
+	if err != nil {
+		return nil, err
+	}
+
+	t := &Telemetry{
+		PowerConsumedWatts: make(map[string]float64),
+	}
+
+	for _, c := range chassis {
+		thermal, err := c.Thermal()
+		if err != nil {
+			return nil, err
+		}
+
+		if thermal != nil {
+			for _, v := range thermal.Temperatures {
+				if !enabled(v.Status) {
+					continue
+				}
+				t.Temperatures = append(t.Temperatures, Reading{Chassis: c.ID, Name: v.Name, Value: float64(v.ReadingCelsius), Units: "Cel"})
+			}
+
+			for _, v := range thermal.Fans {
+				if !enabled(v.Status) {
+					continue
+				}
+				t.Fans = append(t.Fans, Reading{Chassis: c.ID, Name: v.Name, Value: float64(v.Reading), Units: string(v.ReadingUnits)})
+			}
+		}
+
+		power, err := c.Power()
+		if err != nil {
+			return nil, err
+		}
+
+		if power != nil {
+			for _, v := range power.PowerSupplies {
+				if v.Status.State == common.AbsentState {
+					continue
+				}
+				t.PowerSupplies = append(t.PowerSupplies, PowerSupply{Chassis: c.ID, Name: v.Name, Health: string(v.Status.Health), State: string(v.Status.State)})
+			}
+
+			for _, v := range power.PowerControl {
+				t.PowerConsumedWatts[c.ID] += float64(v.PowerConsumedWatts)
+			}
+		}
+	}
+
+	return t, nil
+}
+
+// enabled returns false for sensors which are absent or disabled
+func enabled(status common.Status) bool {
+	return status.State != common.AbsentState && status.State != common.DisabledState
+}
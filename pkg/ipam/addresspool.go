@@ -0,0 +1,40 @@
+package ipam
+
+import (
+	"context"
+
+	seederv1alpha1 "github.com/harvester/seeder/pkg/api/v1alpha1"
+	"github.com/harvester/seeder/pkg/util"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// AddressPool is the built-in provider, which allocates addresses using the pool spec and the IPAddresses
+// already allocated from the pool
+type AddressPool struct {
+	client.Client
+}
+
+// NewAddressPool returns the built-in provider
+func NewAddressPool(c client.Client) *AddressPool {
+	return &AddressPool{
+		Client: c,
+	}
+}
+
+// Allocate allocates the static address of the claim, or the next free address of the pool. The allocation
+// of the pool is derived from its IPAddresses, as the pool status may not have caught up with recent allocations
+func (a *AddressPool) Allocate(ctx context.Context, pool *seederv1alpha1.AddressPool, claim *seederv1alpha1.IPClaim) (string, error) {
+	addresses, err := util.ListPoolAddresses(ctx, a.Client, pool)
+	if err != nil {
+		return "", err
+	}
+
+	poolCopy := pool.DeepCopy()
+	poolCopy.Status.AddressAllocation = util.AddressAllocation(addresses)
+	return util.AllocateAddressForConsumer(poolCopy, claim.Spec.StaticAddress, claim.Spec.Consumer)
+}
+
+// Release is a no-op, as addresses are released once their IPAddress is removed
+func (a *AddressPool) Release(ctx context.Context, pool *seederv1alpha1.AddressPool, claim *seederv1alpha1.IPClaim, address string) error {
+	return nil
+}
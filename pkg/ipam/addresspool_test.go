@@ -0,0 +1,48 @@
+package ipam
+
+import (
+	"context"
+	"testing"
+
+	seederv1alpha1 "github.com/harvester/seeder/pkg/api/v1alpha1"
+	"github.com/harvester/seeder/pkg/mock"
+	"github.com/stretchr/testify/require"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func Test_AddressPoolProvider(t *testing.T) {
+	assert := require.New(t)
+	ctx := context.TODO()
+
+	k8sClient, err := mock.GenerateFakeClient()
+	assert.NoError(err, "expected no error during fake client generation")
+
+	pool := &seederv1alpha1.AddressPool{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "builtin",
+			Namespace: "default",
+		},
+		Spec: seederv1alpha1.AddressSpec{
+			CIDR:    "192.168.1.0/29",
+			Gateway: "192.168.1.1",
+		},
+	}
+
+	claim := &seederv1alpha1.IPClaim{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "node1",
+			Namespace: "default",
+		},
+	}
+
+	p := NewAddressPool(k8sClient)
+	assert.Equal(seederv1alpha1.IPAMProviderAddressPool, Name(pool), "expected built-in provider to be the default")
+	address, err := p.Allocate(ctx, pool, claim)
+	assert.NoError(err, "expected no error allocating address")
+	assert.Equal("192.168.1.2", address)
+
+	claim.Spec.StaticAddress = "192.168.1.5"
+	address, err = p.Allocate(ctx, pool, claim)
+	assert.NoError(err, "expected no error allocating static address")
+	assert.Equal("192.168.1.5", address)
+}
@@ -0,0 +1,195 @@
+package ipam
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	seederv1alpha1 "github.com/harvester/seeder/pkg/api/v1alpha1"
+	"inet.af/netaddr"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+const (
+	// TokenKey is the key of the bearer token in the token secret of a pool
+	TokenKey = "token"
+	// maxResponseSize limits the size of responses read from the IPAM API
+	maxResponseSize = 1 << 20
+)
+
+// HTTP allocates addresses using a generic external IPAM API. Addresses are allocated by sending a POST request
+// to <url>/pools/<pool>/allocations, and released by sending a DELETE request to
+// <url>/pools/<pool>/allocations/<address>
+type HTTP struct {
+	client.Client
+	httpClient *http.Client
+}
+
+// AllocationRequest is the body of an allocation request sent to the IPAM API
+type AllocationRequest struct {
+	// Claim is the namespaced name of the claim
+	Claim string `json:"claim"`
+	// ClaimUID identifies the claim, so a retried request can return the address already allocated to it
+	ClaimUID string `json:"claimUID"`
+	// Address is the static address requested by the claim
+	Address  string                                  `json:"address,omitempty"`
+	Consumer *seederv1alpha1.ObjectReferenceWithKind `json:"consumer,omitempty"`
+}
+
+// AllocationResponse is the body of the response to an allocation request
+type AllocationResponse struct {
+	Address string `json:"address"`
+}
+
+// NewHTTP returns a provider using an external IPAM API
+func NewHTTP(c client.Client) *HTTP {
+	return &HTTP{
+		Client: c,
+		httpClient: &http.Client{
+			Timeout: 30 * time.Second,
+		},
+	}
+}
+
+// Allocate requests an address for the claim from the IPAM API. The address must be part of the pool CIDR
+func (h *HTTP) Allocate(ctx context.Context, pool *seederv1alpha1.AddressPool, claim *seederv1alpha1.IPClaim) (string, error) {
+	body, err := json.Marshal(AllocationRequest{
+		Claim:    fmt.Sprintf("%s/%s", claim.Namespace, claim.Name),
+		ClaimUID: string(claim.UID),
+		Address:  claim.Spec.StaticAddress,
+		Consumer: claim.Spec.Consumer,
+	})
+	if err != nil {
+		return "", err
+	}
+
+	resp, err := h.do(ctx, pool, http.MethodPost, "allocations", body)
+	if err != nil {
+		return "", err
+	}
+
+	allocation := &AllocationResponse{}
+	if err := json.Unmarshal(resp, allocation); err != nil {
+		return "", fmt.Errorf("error parsing ipam response for pool %s: %v", pool.Name, err)
+	}
+
+	ip, err := netaddr.ParseIP(allocation.Address)
+	if err != nil {
+		return "", fmt.Errorf("ipam returned invalid address for pool %s: %v", pool.Name, err)
+	}
+
+	prefix, err := netaddr.ParseIPPrefix(pool.Spec.CIDR)
+	if err != nil {
+		return "", err
+	}
+
+	if !prefix.Contains(ip) {
+		return "", h.releaseRejected(ctx, pool, claim, ip.String(),
+			fmt.Errorf("ipam returned address %s which is not part of pool %s cidr %s", ip, pool.Name, pool.Spec.CIDR))
+	}
+
+	if claim.Spec.StaticAddress != "" && claim.Spec.StaticAddress != ip.String() {
+		return "", h.releaseRejected(ctx, pool, claim, ip.String(),
+			fmt.Errorf("ipam returned address %s instead of requested address %s", ip, claim.Spec.StaticAddress))
+	}
+
+	return ip.String(), nil
+}
+
+// releaseRejected releases an address returned by the IPAM API which failed validation, so the allocation
+// is not leaked when the claim is retried, and returns the validation error
+func (h *HTTP) releaseRejected(ctx context.Context, pool *seederv1alpha1.AddressPool, claim *seederv1alpha1.IPClaim, address string, err error) error {
+	if releaseErr := h.Release(ctx, pool, claim, address); releaseErr != nil {
+		return fmt.Errorf("%v, and releasing it failed: %v", err, releaseErr)
+	}
+	return err
+}
+
+// Release releases the address in the IPAM API. Addresses which are not found are already released
+func (h *HTTP) Release(ctx context.Context, pool *seederv1alpha1.AddressPool, claim *seederv1alpha1.IPClaim, address string) error {
+	_, err := h.do(ctx, pool, http.MethodDelete, "allocations/"+url.PathEscape(address), nil)
+	if err != nil && !isNotFound(err) {
+		return err
+	}
+	return nil
+}
+
+type statusError struct {
+	code    int
+	message string
+}
+
+func (e *statusError) Error() string {
+	return fmt.Sprintf("ipam request failed with status %d: %s", e.code, e.message)
+}
+
+func isNotFound(err error) bool {
+	statusErr, ok := err.(*statusError)
+	return ok && statusErr.code == http.StatusNotFound
+}
+
+// do sends a request to path under the pool in the IPAM API, and returns the response body
+func (h *HTTP) do(ctx context.Context, pool *seederv1alpha1.AddressPool, method, path string, body []byte) ([]byte, error) {
+	if pool.Spec.HTTP == nil || pool.Spec.HTTP.URL == "" {
+		return nil, fmt.Errorf("pool %s does not specify an ipam url", pool.Name)
+	}
+
+	poolName := pool.Spec.HTTP.Pool
+	if poolName == "" {
+		poolName = pool.Name
+	}
+
+	endpoint := fmt.Sprintf("%s/pools/%s/%s", strings.TrimSuffix(pool.Spec.HTTP.URL, "/"), url.PathEscape(poolName), path)
+	req, err := http.NewRequestWithContext(ctx, method, endpoint, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	if pool.Spec.HTTP.TokenSecret != nil {
+		token, err := h.token(ctx, pool.Spec.HTTP.TokenSecret)
+		if err != nil {
+			return nil, err
+		}
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+
+	resp, err := h.httpClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	respBody, err := io.ReadAll(io.LimitReader(resp.Body, maxResponseSize))
+	if err != nil {
+		return nil, err
+	}
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return nil, &statusError{code: resp.StatusCode, message: strings.TrimSpace(string(respBody))}
+	}
+
+	return respBody, nil
+}
+
+// token reads the bearer token from the token secret
+func (h *HTTP) token(ctx context.Context, ref *seederv1alpha1.ObjectReference) (string, error) {
+	secret := &corev1.Secret{}
+	if err := h.Get(ctx, types.NamespacedName{Namespace: ref.Namespace, Name: ref.Name}, secret); err != nil {
+		return "", fmt.Errorf("error fetching ipam token secret %s/%s: %v", ref.Namespace, ref.Name, err)
+	}
+
+	token, ok := secret.Data[TokenKey]
+	if !ok {
+		return "", fmt.Errorf("ipam token secret %s/%s has no %s key", ref.Namespace, ref.Name, TokenKey)
+	}
+	return string(token), nil
+}
@@ -0,0 +1,172 @@
+package ipam
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	seederv1alpha1 "github.com/harvester/seeder/pkg/api/v1alpha1"
+	"github.com/harvester/seeder/pkg/mock"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// fakeIPAM is a stand-in for an external IPAM API, which allocates addresses from a fixed list
+type fakeIPAM struct {
+	sync.Mutex
+	token     string
+	free      []string
+	allocated map[string]string
+	released  []string
+}
+
+func (f *fakeIPAM) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	f.Lock()
+	defer f.Unlock()
+
+	if r.Header.Get("Authorization") != "Bearer "+f.token {
+		http.Error(w, "unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	switch {
+	case r.Method == http.MethodPost && r.URL.Path == "/api/pools/external/allocations":
+		req := &AllocationRequest{}
+		if err := json.NewDecoder(r.Body).Decode(req); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		// retried requests return the address already allocated to the claim
+		for address, uid := range f.allocated {
+			if uid == req.ClaimUID {
+				_ = json.NewEncoder(w).Encode(AllocationResponse{Address: address})
+				return
+			}
+		}
+
+		if len(f.free) == 0 {
+			http.Error(w, "pool exhausted", http.StatusConflict)
+			return
+		}
+		address := f.free[0]
+		f.free = f.free[1:]
+		f.allocated[address] = req.ClaimUID
+		w.WriteHeader(http.StatusCreated)
+		_ = json.NewEncoder(w).Encode(AllocationResponse{Address: address})
+	case r.Method == http.MethodDelete && strings.HasPrefix(r.URL.Path, "/api/pools/external/allocations/"):
+		address := strings.TrimPrefix(r.URL.Path, "/api/pools/external/allocations/")
+		if _, ok := f.allocated[address]; !ok {
+			http.Error(w, "not found", http.StatusNotFound)
+			return
+		}
+		delete(f.allocated, address)
+		f.free = append(f.free, address)
+		f.released = append(f.released, address)
+		w.WriteHeader(http.StatusNoContent)
+	default:
+		http.Error(w, "not found", http.StatusNotFound)
+	}
+}
+
+func Test_HTTPProvider(t *testing.T) {
+	assert := require.New(t)
+	ctx := context.TODO()
+
+	k8sClient, err := mock.GenerateFakeClient()
+	assert.NoError(err, "expected no error during fake client generation")
+	err = k8sClient.Create(ctx, &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "ipam-token",
+			Namespace: "default",
+		},
+		Data: map[string][]byte{
+			TokenKey: []byte("secret-token"),
+		},
+	})
+	assert.NoError(err, "expected no error creating token secret")
+
+	ipam := &fakeIPAM{
+		token:     "secret-token",
+		free:      []string{"192.168.1.10", "10.0.0.1", "192.168.1.11"},
+		allocated: make(map[string]string),
+	}
+	server := httptest.NewServer(ipam)
+	defer server.Close()
+
+	pool := &seederv1alpha1.AddressPool{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "http-pool",
+			Namespace: "default",
+		},
+		Spec: seederv1alpha1.AddressSpec{
+			CIDR:     "192.168.1.0/24",
+			Gateway:  "192.168.1.1",
+			Provider: seederv1alpha1.IPAMProviderHTTP,
+			HTTP: &seederv1alpha1.HTTPIPAMSpec{
+				URL:  server.URL + "/api/",
+				Pool: "external",
+				TokenSecret: &seederv1alpha1.ObjectReference{
+					Name:      "ipam-token",
+					Namespace: "default",
+				},
+			},
+		},
+	}
+
+	claim := &seederv1alpha1.IPClaim{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "node1",
+			Namespace: "default",
+			UID:       "claim-1",
+		},
+	}
+
+	providers := NewDefaultProviders(k8sClient)
+	p, ok := providers[Name(pool)]
+	assert.True(ok, "expected http provider to be available")
+
+	address, err := p.Allocate(ctx, pool, claim)
+	assert.NoError(err, "expected no error allocating address")
+	assert.Equal("192.168.1.10", address)
+
+	address, err = p.Allocate(ctx, pool, claim)
+	assert.NoError(err, "expected no error retrying allocation")
+	assert.Equal("192.168.1.10", address, "expected retried allocation to return the same address")
+
+	// addresses outside of the pool cidr are rejected
+	other := claim.DeepCopy()
+	other.UID = "claim-2"
+	_, err = p.Allocate(ctx, pool, other)
+	assert.ErrorContains(err, "not part of pool", "expected error for address outside of pool cidr")
+	assert.Equal([]string{"10.0.0.1"}, ipam.released, "expected address outside of pool cidr to be released")
+	assert.Empty(ipam.allocated["10.0.0.1"], "expected address outside of pool cidr to be released")
+
+	// addresses other than the requested static address are rejected
+	static := claim.DeepCopy()
+	static.UID = "claim-3"
+	static.Spec.StaticAddress = "192.168.1.20"
+	_, err = p.Allocate(ctx, pool, static)
+	assert.ErrorContains(err, "instead of requested address", "expected error for address other than the static address")
+	assert.Equal([]string{"10.0.0.1", "192.168.1.11"}, ipam.released, "expected address other than the static address to be released")
+	assert.Empty(ipam.allocated["192.168.1.11"], "expected address other than the static address to be released")
+
+	err = p.Release(ctx, pool, claim, "192.168.1.10")
+	assert.NoError(err, "expected no error releasing address")
+	err = p.Release(ctx, pool, claim, "192.168.1.10")
+	assert.NoError(err, "expected no error releasing address which is already released")
+	assert.Empty(ipam.allocated["192.168.1.10"], "expected address to be released")
+
+	pool.Spec.HTTP.TokenSecret.Name = "missing"
+	_, err = p.Allocate(ctx, pool, claim)
+	assert.Error(err, "expected error with missing token secret")
+
+	pool.Spec.HTTP.TokenSecret = nil
+	_, err = p.Allocate(ctx, pool, claim)
+	assert.ErrorContains(err, "status 401", "expected error for unauthorized request")
+}
@@ -0,0 +1,39 @@
+package ipam
+
+import (
+	"context"
+
+	seederv1alpha1 "github.com/harvester/seeder/pkg/api/v1alpha1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+const (
+	// DefaultProvider is used by pools which do not specify a provider
+	DefaultProvider = seederv1alpha1.IPAMProviderAddressPool
+)
+
+// Provider allocates addresses from an address pool to claims. The caller records each allocation in an
+// IPAddress, which ensures an address is only allocated once regardless of the provider
+type Provider interface {
+	// Allocate returns the address allocated to the claim. The static address of the claim is allocated if set
+	Allocate(ctx context.Context, pool *seederv1alpha1.AddressPool, claim *seederv1alpha1.IPClaim) (string, error)
+	// Release releases the address allocated to the claim. Releasing an address which is not allocated
+	// is not an error
+	Release(ctx context.Context, pool *seederv1alpha1.AddressPool, claim *seederv1alpha1.IPClaim, address string) error
+}
+
+// NewDefaultProviders returns the providers available to address pools
+func NewDefaultProviders(c client.Client) map[seederv1alpha1.IPAMProvider]Provider {
+	return map[seederv1alpha1.IPAMProvider]Provider{
+		seederv1alpha1.IPAMProviderAddressPool: NewAddressPool(c),
+		seederv1alpha1.IPAMProviderHTTP:        NewHTTP(c),
+	}
+}
+
+// Name returns the name of the provider used by a pool
+func Name(pool *seederv1alpha1.AddressPool) seederv1alpha1.IPAMProvider {
+	if pool.Spec.Provider != "" {
+		return pool.Spec.Provider
+	}
+	return DefaultProvider
+}
@@ -0,0 +1,224 @@
+package iso
+
+import (
+	"encoding/binary"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"time"
+)
+
+const (
+	sectorSize = 2048
+	// systemAreaSectors are reserved at the start of the image
+	systemAreaSectors = 16
+	// the volume descriptors, path tables and root directory are written in the sectors after the system area
+	primaryDescriptorSector = systemAreaSectors
+	terminatorSector        = primaryDescriptorSector + 1
+	lPathTableSector        = terminatorSector + 1
+	mPathTableSector        = lPathTableSector + 1
+	rootDirectorySector     = mPathTableSector + 1
+	firstFileSector         = rootDirectorySector + 1
+
+	// rootPathTableSize is the size of a path table holding only the root directory
+	rootPathTableSize = 10
+)
+
+// File is a file written to the root directory of an image
+type File struct {
+	// Name is an ISO9660 file name, which is written in upper case. Linux shows the names in lower case
+	// when mounting images without Rock Ridge or Joliet extensions
+	Name string
+	Data []byte
+}
+
+// Write writes an ISO9660 image containing the files in its root directory, such as a config drive for an
+// installer. Images are not bootable
+func Write(w io.Writer, volumeID string, files []File, modTime time.Time) error {
+	sorted := make([]File, len(files))
+	copy(sorted, files)
+	for i := range sorted {
+		sorted[i].Name = strings.ToUpper(sorted[i].Name)
+		if err := validName(sorted[i].Name); err != nil {
+			return err
+		}
+	}
+	sort.Slice(sorted, func(i, j int) bool {
+		return sorted[i].Name < sorted[j].Name
+	})
+
+	// place each file in the sectors following the root directory
+	extents := make([]uint32, len(sorted))
+	next := uint32(firstFileSector)
+	for i, f := range sorted {
+		extents[i] = next
+		next += sectors(len(f.Data))
+	}
+	totalSectors := next
+
+	root := directoryRecord(rootDirectorySector, sectorSize, true, []byte{0}, modTime)
+	dir := make([]byte, 0, sectorSize)
+	dir = append(dir, root...)
+	dir = append(dir, directoryRecord(rootDirectorySector, sectorSize, true, []byte{1}, modTime)...)
+	for i, f := range sorted {
+		dir = append(dir, directoryRecord(extents[i], uint32(len(f.Data)), false, fileIdentifier(f.Name), modTime)...)
+	}
+	if len(dir) > sectorSize {
+		return fmt.Errorf("too many files for the root directory of the image")
+	}
+
+	image := make([]byte, 0, int(firstFileSector)*sectorSize)
+	image = append(image, make([]byte, systemAreaSectors*sectorSize)...)
+	image = append(image, primaryDescriptor(volumeID, totalSectors, root, modTime)...)
+	image = append(image, terminator()...)
+	image = append(image, pad(pathTable(binary.LittleEndian))...)
+	image = append(image, pad(pathTable(binary.BigEndian))...)
+	image = append(image, pad(dir)...)
+	if _, err := w.Write(image); err != nil {
+		return err
+	}
+
+	for _, f := range sorted {
+		if _, err := w.Write(pad(f.Data)); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// validName checks the name only contains characters which can be used in a file name, and fits in a
+// directory record
+func validName(name string) error {
+	if name == "" || len(name) > 30 {
+		return fmt.Errorf("invalid file name %q: names must be between 1 and 30 characters", name)
+	}
+
+	for _, c := range name {
+		if !(c >= 'A' && c <= 'Z' || c >= '0' && c <= '9' || c == '_' || c == '-' || c == '.') {
+			return fmt.Errorf("invalid file name %q: names may only contain letters, digits, '_', '-' and '.'", name)
+		}
+	}
+
+	if strings.Count(name, ".") > 1 {
+		return fmt.Errorf("invalid file name %q: names may only contain a single '.'", name)
+	}
+	return nil
+}
+
+// fileIdentifier returns the identifier of a file with the version suffix
+func fileIdentifier(name string) []byte {
+	if !strings.Contains(name, ".") {
+		name += "."
+	}
+	return []byte(name + ";1")
+}
+
+func primaryDescriptor(volumeID string, totalSectors uint32, root []byte, modTime time.Time) []byte {
+	d := make([]byte, sectorSize)
+	d[0] = 1
+	copy(d[1:6], "CD001")
+	d[6] = 1
+	copy(d[8:40], padString("", 32))
+	copy(d[40:72], padString(strings.ToUpper(volumeID), 32))
+	bothEndian32(d[80:88], totalSectors)
+	bothEndian16(d[120:124], 1)
+	bothEndian16(d[124:128], 1)
+	bothEndian16(d[128:132], sectorSize)
+	bothEndian32(d[132:140], rootPathTableSize)
+	binary.LittleEndian.PutUint32(d[140:144], lPathTableSector)
+	binary.BigEndian.PutUint32(d[148:152], mPathTableSector)
+	copy(d[156:190], root)
+	// volume set, publisher, preparer, application, copyright, abstract and bibliographic identifiers
+	copy(d[190:813], padString("", 623))
+	copy(d[813:830], decimalTime(modTime))
+	copy(d[830:847], decimalTime(modTime))
+	copy(d[847:864], decimalTime(time.Time{}))
+	copy(d[864:881], decimalTime(time.Time{}))
+	d[881] = 1
+	return d
+}
+
+func terminator() []byte {
+	d := make([]byte, sectorSize)
+	d[0] = 255
+	copy(d[1:6], "CD001")
+	d[6] = 1
+	return d
+}
+
+// pathTable returns a path table containing only the root directory
+func pathTable(order binary.ByteOrder) []byte {
+	t := make([]byte, rootPathTableSize)
+	t[0] = 1
+	order.PutUint32(t[2:6], rootDirectorySector)
+	order.PutUint16(t[6:8], 1)
+	return t
+}
+
+func directoryRecord(extent, size uint32, dir bool, identifier []byte, modTime time.Time) []byte {
+	length := 33 + len(identifier)
+	if length%2 != 0 {
+		length++
+	}
+
+	r := make([]byte, length)
+	r[0] = byte(length)
+	bothEndian32(r[2:10], extent)
+	bothEndian32(r[10:18], size)
+	copy(r[18:25], recordingTime(modTime))
+	if dir {
+		r[25] = 2
+	}
+	bothEndian16(r[28:32], 1)
+	r[32] = byte(len(identifier))
+	copy(r[33:], identifier)
+	return r
+}
+
+func recordingTime(t time.Time) []byte {
+	t = t.UTC()
+	return []byte{byte(t.Year() - 1900), byte(t.Month()), byte(t.Day()), byte(t.Hour()), byte(t.Minute()), byte(t.Second()), 0}
+}
+
+// decimalTime returns the date and time format used by volume descriptors. The zero time is written as unset
+func decimalTime(t time.Time) []byte {
+	if t.IsZero() {
+		return append([]byte(strings.Repeat("0", 16)), 0)
+	}
+	t = t.UTC()
+	return append([]byte(fmt.Sprintf("%04d%02d%02d%02d%02d%02d%02d", t.Year(), t.Month(), t.Day(), t.Hour(), t.Minute(), t.Second(), t.Nanosecond()/10000000)), 0)
+}
+
+func bothEndian16(b []byte, v uint16) {
+	binary.LittleEndian.PutUint16(b[0:2], v)
+	binary.BigEndian.PutUint16(b[2:4], v)
+}
+
+func bothEndian32(b []byte, v uint32) {
+	binary.LittleEndian.PutUint32(b[0:4], v)
+	binary.BigEndian.PutUint32(b[4:8], v)
+}
+
+func padString(s string, length int) string {
+	if len(s) > length {
+		return s[:length]
+	}
+	return s + strings.Repeat(" ", length-len(s))
+}
+
+// pad pads the data to a whole number of sectors
+func pad(data []byte) []byte {
+	padded := make([]byte, int(sectors(len(data)))*sectorSize)
+	copy(padded, data)
+	return padded
+}
+
+// sectors returns the number of sectors needed for the data. Empty files still use a sector
+func sectors(size int) uint32 {
+	if size == 0 {
+		return 1
+	}
+	return uint32((size + sectorSize - 1) / sectorSize)
+}
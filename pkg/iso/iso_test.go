@@ -0,0 +1,78 @@
+package iso
+
+import (
+	"bytes"
+	"encoding/binary"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+// readRootFiles returns the files in the root directory of an image, keyed by their name without the version
+func readRootFiles(t *testing.T, image []byte) map[string][]byte {
+	assert := require.New(t)
+	pvd := image[primaryDescriptorSector*sectorSize:]
+	root := pvd[156:190]
+	rootExtent := binary.LittleEndian.Uint32(root[2:6])
+	rootSize := binary.LittleEndian.Uint32(root[10:14])
+	assert.Equal(uint32(rootDirectorySector), rootExtent, "unexpected root directory location")
+
+	files := make(map[string][]byte)
+	dir := image[rootExtent*sectorSize : rootExtent*sectorSize+rootSize]
+	for len(dir) > 0 && dir[0] != 0 {
+		record := dir[:dir[0]]
+		dir = dir[dir[0]:]
+		if record[25]&2 != 0 {
+			continue
+		}
+
+		extent := binary.LittleEndian.Uint32(record[2:6])
+		size := binary.LittleEndian.Uint32(record[10:14])
+		name := strings.TrimSuffix(strings.TrimSuffix(string(record[33:33+record[32]]), ";1"), ".")
+		files[name] = image[extent*sectorSize : extent*sectorSize+size]
+	}
+	return files
+}
+
+func Test_Write(t *testing.T) {
+	assert := require.New(t)
+	var out bytes.Buffer
+	largeFile := bytes.Repeat([]byte("a"), 3*sectorSize+1)
+	err := Write(&out, "cidata", []File{
+		{Name: "user-data", Data: []byte("scheme_version: 1\n")},
+		{Name: "meta-data", Data: []byte("instance-id: node\n")},
+		{Name: "large.img", Data: largeFile},
+	}, time.Now())
+	assert.NoError(err, "expected no error writing image")
+
+	image := out.Bytes()
+	assert.Zero(len(image)%sectorSize, "expected image to be a whole number of sectors")
+
+	pvd := image[primaryDescriptorSector*sectorSize:]
+	assert.Equal(byte(1), pvd[0], "expected primary volume descriptor")
+	assert.Equal("CD001", string(pvd[1:6]), "expected iso9660 signature")
+	assert.Equal("CIDATA", strings.TrimSpace(string(pvd[40:72])), "expected volume id to be written in upper case")
+	assert.Equal(uint32(len(image)/sectorSize), binary.LittleEndian.Uint32(pvd[80:84]), "expected volume size to match image")
+	assert.Equal(uint32(len(image)/sectorSize), binary.BigEndian.Uint32(pvd[84:88]), "expected volume size to match image")
+
+	terminator := image[terminatorSector*sectorSize:]
+	assert.Equal(byte(255), terminator[0], "expected volume descriptor set terminator")
+
+	files := readRootFiles(t, image)
+	assert.Len(files, 3, "expected all files in root directory")
+	assert.Equal("scheme_version: 1\n", string(files["USER-DATA"]), "expected user-data contents")
+	assert.Equal("instance-id: node\n", string(files["META-DATA"]), "expected meta-data contents")
+	assert.Equal(largeFile, files["LARGE.IMG"], "expected contents spanning multiple sectors")
+}
+
+func Test_WriteInvalidName(t *testing.T) {
+	assert := require.New(t)
+	var out bytes.Buffer
+	err := Write(&out, "cidata", []File{{Name: "config/user-data"}}, time.Now())
+	assert.Error(err, "expected error for name with a directory")
+
+	err = Write(&out, "cidata", []File{{Name: "user.data.yaml"}}, time.Now())
+	assert.Error(err, "expected error for name with multiple extensions")
+}
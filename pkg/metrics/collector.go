@@ -0,0 +1,179 @@
+package metrics
+
+import (
+	"context"
+	"time"
+
+	seederv1alpha1 "github.com/harvester/seeder/pkg/api/v1alpha1"
+	"github.com/harvester/seeder/pkg/util"
+	"github.com/prometheus/client_golang/prometheus"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/metrics"
+)
+
+const (
+	// HealthUnknown is reported for inventory whose health has not been polled
+	HealthUnknown = "unknown"
+
+	// collectTimeout limits the time spent listing objects during a scrape
+	collectTimeout = 10 * time.Second
+)
+
+// Inventory phases reported by the inventory metrics
+const (
+	PhasePending            = "pending"
+	PhaseReady              = "ready"
+	PhaseProvisioning       = "provisioning"
+	PhaseProvisioned        = "provisioned"
+	PhaseProvisioningFailed = "provisioningFailed"
+	PhaseWiping             = "wiping"
+	PhaseDeleting           = "deleting"
+)
+
+var (
+	inventoryPhaseDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, "inventory", "phase"),
+		"Number of inventories in each phase",
+		[]string{"namespace", "phase"}, nil,
+	)
+
+	inventoryHealthDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, "inventory", "health"),
+		"Number of inventories with each health reported by the BMC",
+		[]string{"namespace", "health"}, nil,
+	)
+
+	poolAvailableDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, "addresspool", "available_addresses"),
+		"Number of addresses which can be allocated from the pool",
+		[]string{"namespace", "pool"}, nil,
+	)
+
+	poolAllocatedDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, "addresspool", "allocated_addresses"),
+		"Number of addresses allocated from the pool",
+		[]string{"namespace", "pool"}, nil,
+	)
+
+	poolUtilizationDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, "addresspool", "utilization_ratio"),
+		"Ratio of allocated to available addresses of the pool",
+		[]string{"namespace", "pool"}, nil,
+	)
+)
+
+// Collector reports metrics derived from the current state of inventories and address pools when scraped
+type Collector struct {
+	client.Reader
+}
+
+// NewCollector returns a collector reading objects using c, which is expected to be backed by the manager cache
+func NewCollector(c client.Reader) *Collector {
+	return &Collector{
+		Reader: c,
+	}
+}
+
+// RegisterCollector registers a collector reading objects using c with the controller-runtime registry
+func RegisterCollector(c client.Reader) error {
+	return metrics.Registry.Register(NewCollector(c))
+}
+
+// Describe implements prometheus.Collector
+func (c *Collector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- inventoryPhaseDesc
+	ch <- inventoryHealthDesc
+	ch <- poolAvailableDesc
+	ch <- poolAllocatedDesc
+	ch <- poolUtilizationDesc
+}
+
+// Collect implements prometheus.Collector
+func (c *Collector) Collect(ch chan<- prometheus.Metric) {
+	ctx, cancel := context.WithTimeout(context.Background(), collectTimeout)
+	defer cancel()
+	c.collectInventory(ctx, ch)
+	c.collectAddressPools(ctx, ch)
+}
+
+func (c *Collector) collectInventory(ctx context.Context, ch chan<- prometheus.Metric) {
+	inventoryList := &seederv1alpha1.InventoryList{}
+	if err := c.List(ctx, inventoryList); err != nil {
+		ch <- prometheus.NewInvalidMetric(inventoryPhaseDesc, err)
+		ch <- prometheus.NewInvalidMetric(inventoryHealthDesc, err)
+		return
+	}
+
+	phases := make(map[[2]string]int)
+	health := make(map[[2]string]int)
+	for _, i := range inventoryList.Items {
+		phases[[2]string{i.Namespace, InventoryPhase(&i)}]++
+		health[[2]string{i.Namespace, InventoryHealth(&i)}]++
+	}
+
+	for k, v := range phases {
+		ch <- prometheus.MustNewConstMetric(inventoryPhaseDesc, prometheus.GaugeValue, float64(v), k[0], k[1])
+	}
+
+	for k, v := range health {
+		ch <- prometheus.MustNewConstMetric(inventoryHealthDesc, prometheus.GaugeValue, float64(v), k[0], k[1])
+	}
+}
+
+func (c *Collector) collectAddressPools(ctx context.Context, ch chan<- prometheus.Metric) {
+	poolList := &seederv1alpha1.AddressPoolList{}
+	if err := c.List(ctx, poolList); err != nil {
+		ch <- prometheus.NewInvalidMetric(poolAvailableDesc, err)
+		return
+	}
+
+	for _, pool := range poolList.Items {
+		// pools are reported once their status has been generated
+		if pool.Status.Status == "" {
+			continue
+		}
+
+		allocated, err := util.AllocatedAddresses(&pool)
+		if err != nil {
+			ch <- prometheus.NewInvalidMetric(poolAllocatedDesc, err)
+			continue
+		}
+
+		var utilization float64
+		if pool.Status.AvailableAddresses > 0 {
+			utilization = float64(allocated) / float64(pool.Status.AvailableAddresses)
+		}
+
+		ch <- prometheus.MustNewConstMetric(poolAvailableDesc, prometheus.GaugeValue, float64(pool.Status.AvailableAddresses), pool.Namespace, pool.Name)
+		ch <- prometheus.MustNewConstMetric(poolAllocatedDesc, prometheus.GaugeValue, float64(allocated), pool.Namespace, pool.Name)
+		ch <- prometheus.MustNewConstMetric(poolUtilizationDesc, prometheus.GaugeValue, utilization, pool.Namespace, pool.Name)
+	}
+}
+
+// InventoryPhase summarises the status and conditions of an inventory as a single phase
+func InventoryPhase(i *seederv1alpha1.Inventory) string {
+	switch {
+	case !i.DeletionTimestamp.IsZero():
+		return PhaseDeleting
+	case util.ConditionExists(i.Status.Conditions, seederv1alpha1.InventoryWiping):
+		return PhaseWiping
+	case util.ConditionExists(i.Status.Conditions, seederv1alpha1.InventoryProvisioningFailed):
+		return PhaseProvisioningFailed
+	case util.ConditionExists(i.Status.Conditions, seederv1alpha1.InventoryProvisioned):
+		return PhaseProvisioned
+	case util.ConditionExists(i.Status.Conditions, seederv1alpha1.InventoryAllocatedToCluster):
+		return PhaseProvisioning
+	case i.Status.Status == seederv1alpha1.InventoryReady:
+		return PhaseReady
+	default:
+		return PhasePending
+	}
+}
+
+// InventoryHealth returns the health of an inventory reported by the BMC
+func InventoryHealth(i *seederv1alpha1.Inventory) string {
+	if i.Status.Health != nil && i.Status.Health.Status != "" {
+		return string(i.Status.Health.Status)
+	}
+	return HealthUnknown
+}
@@ -0,0 +1,102 @@
+package metrics
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	seederv1alpha1 "github.com/harvester/seeder/pkg/api/v1alpha1"
+	"github.com/harvester/seeder/pkg/mock"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/require"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func Test_Collector(t *testing.T) {
+	assert := require.New(t)
+	ctx := context.TODO()
+
+	c, err := mock.GenerateFakeClient()
+	assert.NoError(err, "expected no error during fake client generation")
+
+	objs := []*seederv1alpha1.Inventory{
+		{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "metrics-ready",
+				Namespace: "metrics",
+			},
+			Status: seederv1alpha1.InventoryStatus{
+				Status: seederv1alpha1.InventoryReady,
+				Health: &seederv1alpha1.HardwareHealth{Status: seederv1alpha1.HealthOK},
+			},
+		},
+		{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "metrics-provisioned",
+				Namespace: "metrics",
+			},
+			Status: seederv1alpha1.InventoryStatus{
+				Status: seederv1alpha1.InventoryReady,
+				Health: &seederv1alpha1.HardwareHealth{Status: seederv1alpha1.HealthWarning, Memory: seederv1alpha1.HealthWarning},
+				Conditions: []seederv1alpha1.Conditions{
+					{Type: seederv1alpha1.InventoryAllocatedToCluster},
+					{Type: seederv1alpha1.InventoryProvisioned},
+				},
+			},
+		},
+		{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "metrics-pending",
+				Namespace: "metrics",
+			},
+		},
+	}
+
+	for _, v := range objs {
+		assert.NoError(c.Create(ctx, v), "expected no error creating inventory")
+	}
+
+	pool := &seederv1alpha1.AddressPool{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "metrics-pool",
+			Namespace: "metrics",
+		},
+		Spec: seederv1alpha1.AddressSpec{
+			CIDR:    "192.168.1.0/29",
+			Gateway: "192.168.1.1",
+		},
+		Status: seederv1alpha1.AddressStatus{
+			Status:             seederv1alpha1.PoolReady,
+			AvailableAddresses: 5,
+			AddressAllocation: map[string]seederv1alpha1.ObjectReferenceWithKind{
+				"192.168.1.2": {ObjectReference: seederv1alpha1.ObjectReference{Namespace: "metrics", Name: "metrics-provisioned"}, Kind: seederv1alpha1.KindInventory},
+				"192.168.1.3": {ObjectReference: seederv1alpha1.ObjectReference{Namespace: "metrics", Name: "vip"}, Kind: seederv1alpha1.KindCluster},
+			},
+		},
+	}
+	assert.NoError(c.Create(ctx, pool), "expected no error creating pool")
+
+	expected := `
+# HELP seeder_addresspool_allocated_addresses Number of addresses allocated from the pool
+# TYPE seeder_addresspool_allocated_addresses gauge
+seeder_addresspool_allocated_addresses{namespace="metrics",pool="metrics-pool"} 2
+# HELP seeder_addresspool_utilization_ratio Ratio of allocated to available addresses of the pool
+# TYPE seeder_addresspool_utilization_ratio gauge
+seeder_addresspool_utilization_ratio{namespace="metrics",pool="metrics-pool"} 0.4
+# HELP seeder_inventory_health Number of inventories with each health reported by the BMC
+# TYPE seeder_inventory_health gauge
+seeder_inventory_health{health="OK",namespace="metrics"} 1
+seeder_inventory_health{health="Warning",namespace="metrics"} 1
+seeder_inventory_health{health="unknown",namespace="metrics"} 1
+# HELP seeder_inventory_phase Number of inventories in each phase
+# TYPE seeder_inventory_phase gauge
+seeder_inventory_phase{namespace="metrics",phase="pending"} 1
+seeder_inventory_phase{namespace="metrics",phase="provisioned"} 1
+seeder_inventory_phase{namespace="metrics",phase="ready"} 1
+`
+
+	err = testutil.CollectAndCompare(NewCollector(c), strings.NewReader(expected),
+		"seeder_addresspool_allocated_addresses", "seeder_addresspool_utilization_ratio",
+		"seeder_inventory_health", "seeder_inventory_phase")
+	assert.NoError(err, "expected collected metrics to match")
+}
@@ -0,0 +1,112 @@
+package metrics
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+	"sigs.k8s.io/controller-runtime/pkg/metrics"
+)
+
+const (
+	namespace = "seeder"
+)
+
+var (
+	// OrphanedAllocations is the number of orphaned addresses found by the last audit of each pool
+	OrphanedAllocations = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Namespace: namespace,
+			Subsystem: "addresspool",
+			Name:      "orphaned_allocations",
+			Help:      "Number of addresses allocated from the pool whose claim or consumer no longer uses them",
+		},
+		[]string{"namespace", "pool"},
+	)
+
+	// ReleasedOrphanedAllocations counts the orphaned addresses released back to each pool
+	ReleasedOrphanedAllocations = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Namespace: namespace,
+			Subsystem: "addresspool",
+			Name:      "orphaned_allocations_released_total",
+			Help:      "Number of orphaned addresses released back to the pool",
+		},
+		[]string{"namespace", "pool"},
+	)
+
+	// BMCJobs counts the outcome of BMCJobs submitted by seeder
+	BMCJobs = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Namespace: namespace,
+			Subsystem: "bmcjob",
+			Name:      "total",
+			Help:      "Number of BMCJobs which completed or failed",
+		},
+		[]string{"outcome"},
+	)
+
+	// BMCJobDuration is the time from the creation of a BMCJob to its outcome
+	BMCJobDuration = prometheus.NewHistogramVec(
+		prometheus.HistogramOpts{
+			Namespace: namespace,
+			Subsystem: "bmcjob",
+			Name:      "duration_seconds",
+			Help:      "Time from the creation of a BMCJob until it completed or failed",
+			Buckets:   prometheus.ExponentialBuckets(1, 2, 12),
+		},
+		[]string{"outcome"},
+	)
+
+	// ClusterTimeToRunning is the time from the creation of a cluster until it is first running
+	ClusterTimeToRunning = prometheus.NewHistogram(
+		prometheus.HistogramOpts{
+			Namespace: namespace,
+			Subsystem: "cluster",
+			Name:      "time_to_running_seconds",
+			Help:      "Time from the creation of a cluster until it is marked clusterRunning",
+			Buckets:   prometheus.ExponentialBuckets(60, 2, 10),
+		},
+	)
+
+	// NodeProvisioningDuration is the time from the allocation of an inventory to a cluster until it is provisioned.
+	// Inventories of provisioners which do not report install completion are provisioned once their node joins the cluster
+	NodeProvisioningDuration = prometheus.NewHistogramVec(
+		prometheus.HistogramOpts{
+			Namespace: namespace,
+			Subsystem: "node",
+			Name:      "provisioning_duration_seconds",
+			Help:      "Time from the allocation of an inventory to a cluster until it is provisioned, or its node joins the cluster if the provisioner does not report completion",
+			Buckets:   prometheus.ExponentialBuckets(60, 2, 10),
+		},
+		[]string{"provisioner"},
+	)
+
+	// RedfishPollErrors counts the failed attempts to poll the BMC of an inventory. Series are removed once events
+	// are disabled or the inventory is deleted
+	RedfishPollErrors = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Namespace: namespace,
+			Subsystem: "redfish",
+			Name:      "poll_errors_total",
+			Help:      "Number of failed attempts to poll the BMC of an inventory using redfish",
+		},
+		[]string{"namespace", "inventory"},
+	)
+)
+
+const (
+	OutcomeCompleted = "completed"
+	OutcomeFailed    = "failed"
+)
+
+// metrics are registered with the controller-runtime registry, and are served on the manager metrics endpoint
+func init() {
+	metrics.Registry.MustRegister(
+		OrphanedAllocations,
+		ReleasedOrphanedAllocations,
+		BMCJobs,
+		BMCJobDuration,
+		ClusterTimeToRunning,
+		NodeProvisioningDuration,
+		RedfishPollErrors,
+		Telemetry,
+	)
+}
@@ -0,0 +1,123 @@
+package metrics
+
+import (
+	"sync"
+
+	"github.com/harvester/seeder/pkg/events"
+	"github.com/prometheus/client_golang/prometheus"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+var (
+	temperatureDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, "inventory", "temperature_celsius"),
+		"Temperature reported by a sensor of the inventory BMC",
+		[]string{"namespace", "inventory", "cluster", "chassis", "sensor"}, nil,
+	)
+
+	fanSpeedDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, "inventory", "fan_speed"),
+		"Speed of a fan reported by the inventory BMC, in the units of the units label",
+		[]string{"namespace", "inventory", "cluster", "chassis", "fan", "units"}, nil,
+	)
+
+	powerSupplyDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, "inventory", "power_supply_status"),
+		"Status of a power supply reported by the inventory BMC. The value is always 1",
+		[]string{"namespace", "inventory", "cluster", "chassis", "power_supply", "health", "state"}, nil,
+	)
+
+	powerConsumedDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, "inventory", "power_consumed_watts"),
+		"Power consumed by a chassis reported by the inventory BMC",
+		[]string{"namespace", "inventory", "cluster", "chassis"}, nil,
+	)
+
+	// Telemetry holds the last telemetry polled from each inventory
+	Telemetry = NewTelemetryCollector()
+)
+
+type inventoryTelemetry struct {
+	cluster   string
+	telemetry *events.Telemetry
+}
+
+// TelemetryCollector reports the last telemetry polled from the BMC of each inventory
+type TelemetryCollector struct {
+	sync.RWMutex
+	inventory map[types.NamespacedName]inventoryTelemetry
+}
+
+// NewTelemetryCollector returns an empty telemetry collector
+func NewTelemetryCollector() *TelemetryCollector {
+	return &TelemetryCollector{
+		inventory: make(map[types.NamespacedName]inventoryTelemetry),
+	}
+}
+
+// Update replaces the telemetry of an inventory. Sensors which are no longer reported are removed
+func (t *TelemetryCollector) Update(name types.NamespacedName, cluster string, telemetry *events.Telemetry) {
+	t.Lock()
+	defer t.Unlock()
+	t.inventory[name] = inventoryTelemetry{cluster: cluster, telemetry: telemetry}
+}
+
+// Delete removes the telemetry of an inventory
+func (t *TelemetryCollector) Delete(name types.NamespacedName) {
+	t.Lock()
+	defer t.Unlock()
+	delete(t.inventory, name)
+}
+
+// Describe implements prometheus.Collector
+func (t *TelemetryCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- temperatureDesc
+	ch <- fanSpeedDesc
+	ch <- powerSupplyDesc
+	ch <- powerConsumedDesc
+}
+
+// Collect implements prometheus.Collector. Readings are labelled with their chassis, as sensor names are only
+// unique within a chassis. Sensors sharing a name within a chassis are reported once, as duplicate series would
+// fail the whole scrape
+func (t *TelemetryCollector) Collect(ch chan<- prometheus.Metric) {
+	t.RLock()
+	defer t.RUnlock()
+
+	for name, v := range t.inventory {
+		temperatures := make(map[[2]string]bool)
+		for _, r := range v.telemetry.Temperatures {
+			if firstReading(temperatures, r.Chassis, r.Name) {
+				ch <- prometheus.MustNewConstMetric(temperatureDesc, prometheus.GaugeValue, r.Value, name.Namespace, name.Name, v.cluster, r.Chassis, r.Name)
+			}
+		}
+
+		fans := make(map[[2]string]bool)
+		for _, r := range v.telemetry.Fans {
+			if firstReading(fans, r.Chassis, r.Name) {
+				ch <- prometheus.MustNewConstMetric(fanSpeedDesc, prometheus.GaugeValue, r.Value, name.Namespace, name.Name, v.cluster, r.Chassis, r.Name, r.Units)
+			}
+		}
+
+		powerSupplies := make(map[[2]string]bool)
+		for _, p := range v.telemetry.PowerSupplies {
+			if firstReading(powerSupplies, p.Chassis, p.Name) {
+				ch <- prometheus.MustNewConstMetric(powerSupplyDesc, prometheus.GaugeValue, 1, name.Namespace, name.Name, v.cluster, p.Chassis, p.Name, p.Health, p.State)
+			}
+		}
+
+		for chassis, watts := range v.telemetry.PowerConsumedWatts {
+			ch <- prometheus.MustNewConstMetric(powerConsumedDesc, prometheus.GaugeValue, watts, name.Namespace, name.Name, v.cluster, chassis)
+		}
+	}
+}
+
+// firstReading records the sensor of the chassis as seen, and returns false if it was already seen
+func firstReading(seen map[[2]string]bool, chassis, sensor string) bool {
+	key := [2]string{chassis, sensor}
+	if seen[key] {
+		return false
+	}
+	seen[key] = true
+	return true
+}
@@ -0,0 +1,98 @@
+package metrics
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/harvester/seeder/pkg/events"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/require"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+func Test_TelemetryCollector(t *testing.T) {
+	assert := require.New(t)
+	c := NewTelemetryCollector()
+	name := types.NamespacedName{Namespace: "default", Name: "node1"}
+
+	c.Update(name, "cluster1", &events.Telemetry{
+		Temperatures: []events.Reading{
+			{Chassis: "System.Embedded.1", Name: "CPU1 Temp", Value: 45, Units: "Cel"},
+		},
+		Fans: []events.Reading{
+			{Chassis: "System.Embedded.1", Name: "Fan1", Value: 5880, Units: "RPM"},
+		},
+		PowerSupplies: []events.PowerSupply{
+			{Chassis: "System.Embedded.1", Name: "PS1", Health: "OK", State: "Enabled"},
+		},
+		PowerConsumedWatts: map[string]float64{
+			"System.Embedded.1": 247,
+		},
+	})
+
+	expected := `
+# HELP seeder_inventory_fan_speed Speed of a fan reported by the inventory BMC, in the units of the units label
+# TYPE seeder_inventory_fan_speed gauge
+seeder_inventory_fan_speed{chassis="System.Embedded.1",cluster="cluster1",fan="Fan1",inventory="node1",namespace="default",units="RPM"} 5880
+# HELP seeder_inventory_power_consumed_watts Power consumed by a chassis reported by the inventory BMC
+# TYPE seeder_inventory_power_consumed_watts gauge
+seeder_inventory_power_consumed_watts{chassis="System.Embedded.1",cluster="cluster1",inventory="node1",namespace="default"} 247
+# HELP seeder_inventory_power_supply_status Status of a power supply reported by the inventory BMC. The value is always 1
+# TYPE seeder_inventory_power_supply_status gauge
+seeder_inventory_power_supply_status{chassis="System.Embedded.1",cluster="cluster1",health="OK",inventory="node1",namespace="default",power_supply="PS1",state="Enabled"} 1
+# HELP seeder_inventory_temperature_celsius Temperature reported by a sensor of the inventory BMC
+# TYPE seeder_inventory_temperature_celsius gauge
+seeder_inventory_temperature_celsius{chassis="System.Embedded.1",cluster="cluster1",inventory="node1",namespace="default",sensor="CPU1 Temp"} 45
+`
+	err := testutil.CollectAndCompare(c, strings.NewReader(expected))
+	assert.NoError(err, "expected collected telemetry to match")
+
+	c.Delete(name)
+	assert.Equal(0, testutil.CollectAndCount(c), "expected telemetry to be removed")
+}
+
+func Test_TelemetryCollectorDuplicateNames(t *testing.T) {
+	assert := require.New(t)
+	c := NewTelemetryCollector()
+	name := types.NamespacedName{Namespace: "default", Name: "node1"}
+
+	// blade chassis commonly report sensors with the same names
+	c.Update(name, "cluster1", &events.Telemetry{
+		Temperatures: []events.Reading{
+			{Chassis: "Chassis.1", Name: "Inlet Temp", Value: 21, Units: "Cel"},
+			{Chassis: "Chassis.2", Name: "Inlet Temp", Value: 23, Units: "Cel"},
+			{Chassis: "Chassis.2", Name: "Inlet Temp", Value: 24, Units: "Cel"},
+		},
+		Fans: []events.Reading{
+			{Chassis: "Chassis.1", Name: "Fan1", Value: 5880, Units: "RPM"},
+			{Chassis: "Chassis.2", Name: "Fan1", Value: 6000, Units: "RPM"},
+		},
+		PowerSupplies: []events.PowerSupply{
+			{Chassis: "Chassis.1", Name: "PS1", Health: "OK", State: "Enabled"},
+			{Chassis: "Chassis.2", Name: "PS1", Health: "Critical", State: "Enabled"},
+		},
+	})
+
+	expected := `
+# HELP seeder_inventory_fan_speed Speed of a fan reported by the inventory BMC, in the units of the units label
+# TYPE seeder_inventory_fan_speed gauge
+seeder_inventory_fan_speed{chassis="Chassis.1",cluster="cluster1",fan="Fan1",inventory="node1",namespace="default",units="RPM"} 5880
+seeder_inventory_fan_speed{chassis="Chassis.2",cluster="cluster1",fan="Fan1",inventory="node1",namespace="default",units="RPM"} 6000
+# HELP seeder_inventory_power_supply_status Status of a power supply reported by the inventory BMC. The value is always 1
+# TYPE seeder_inventory_power_supply_status gauge
+seeder_inventory_power_supply_status{chassis="Chassis.1",cluster="cluster1",health="OK",inventory="node1",namespace="default",power_supply="PS1",state="Enabled"} 1
+seeder_inventory_power_supply_status{chassis="Chassis.2",cluster="cluster1",health="Critical",inventory="node1",namespace="default",power_supply="PS1",state="Enabled"} 1
+# HELP seeder_inventory_temperature_celsius Temperature reported by a sensor of the inventory BMC
+# TYPE seeder_inventory_temperature_celsius gauge
+seeder_inventory_temperature_celsius{chassis="Chassis.1",cluster="cluster1",inventory="node1",namespace="default",sensor="Inlet Temp"} 21
+seeder_inventory_temperature_celsius{chassis="Chassis.2",cluster="cluster1",inventory="node1",namespace="default",sensor="Inlet Temp"} 23
+`
+	err := testutil.CollectAndCompare(c, strings.NewReader(expected))
+	assert.NoError(err, "expected readings with duplicate names to be collected without error")
+
+	reg := prometheus.NewPedanticRegistry()
+	assert.NoError(reg.Register(c), "expected collector to be registered")
+	_, err = reg.Gather()
+	assert.NoError(err, "expected gather not to fail on duplicate names")
+}
@@ -0,0 +1,84 @@
+package provisioner
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	seederv1alpha1 "github.com/harvester/seeder/pkg/api/v1alpha1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+const (
+	FakeProvisioner = "fake"
+)
+
+// Fake implements an in-memory provisioning backend for integration testing
+type Fake struct {
+	mu    sync.Mutex
+	nodes map[string]Progress
+}
+
+func NewFake() *Fake {
+	return &Fake{
+		nodes: make(map[string]Progress),
+	}
+}
+
+func (f *Fake) Render(i *seederv1alpha1.Inventory, c *seederv1alpha1.Cluster) ([]client.Object, error) {
+	return nil, nil
+}
+
+func (f *Fake) Apply(ctx context.Context, i *seederv1alpha1.Inventory, c *seederv1alpha1.Cluster) (bool, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if _, ok := f.nodes[fakeKey(i)]; ok {
+		return false, nil
+	}
+	f.nodes[fakeKey(i)] = Progress{Phase: PhaseProvisioning}
+	return true, nil
+}
+
+func (f *Fake) Progress(ctx context.Context, i *seederv1alpha1.Inventory) (Progress, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	p, ok := f.nodes[fakeKey(i)]
+	if !ok {
+		return Progress{Phase: PhasePending}, nil
+	}
+	return p, nil
+}
+
+func (f *Fake) ReportsCompletion(i *seederv1alpha1.Inventory) bool {
+	return true
+}
+
+func (f *Fake) Teardown(ctx context.Context, i *seederv1alpha1.Inventory) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	delete(f.nodes, fakeKey(i))
+	return nil
+}
+
+func (f *Fake) WatchTypes() []client.Object {
+	return nil
+}
+
+// SetProgress overrides the progress reported for a node
+func (f *Fake) SetProgress(i *seederv1alpha1.Inventory, p Progress) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.nodes[fakeKey(i)] = p
+}
+
+// Provisioned checks if the node has been applied and not torn down
+func (f *Fake) Provisioned(i *seederv1alpha1.Inventory) bool {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	_, ok := f.nodes[fakeKey(i)]
+	return ok
+}
+
+func fakeKey(i *seederv1alpha1.Inventory) string {
+	return fmt.Sprintf("%s/%s", i.Namespace, i.Name)
+}
@@ -0,0 +1,64 @@
+package provisioner
+
+import (
+	"context"
+
+	seederv1alpha1 "github.com/harvester/seeder/pkg/api/v1alpha1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+const (
+	// DefaultProvisioner is used by clusters which do not specify a provisioner
+	DefaultProvisioner = TinkerbellProvisioner
+)
+
+// Phase is the provisioning phase of a node
+type Phase string
+
+const (
+	PhasePending      Phase = "Pending"
+	PhaseProvisioning Phase = "Provisioning"
+	PhaseCompleted    Phase = "Completed"
+	PhaseFailed       Phase = "Failed"
+)
+
+// Progress reports the provisioning progress of a node
+type Progress struct {
+	Phase   Phase
+	Message string
+}
+
+// Provisioner is a backend used to install Harvester on inventory allocated to a cluster
+type Provisioner interface {
+	// Render generates the objects needed to provision the node, without applying them
+	Render(i *seederv1alpha1.Inventory, c *seederv1alpha1.Cluster) ([]client.Object, error)
+	// Apply creates or updates the objects needed to provision the node. Returns true if the objects were
+	// created and the node needs to be rebooted to start the install. Apply may add conditions to the
+	// inventory status, which are persisted by the caller
+	Apply(ctx context.Context, i *seederv1alpha1.Inventory, c *seederv1alpha1.Cluster) (bool, error)
+	// Progress reports the provisioning progress of the node
+	Progress(ctx context.Context, i *seederv1alpha1.Inventory) (Progress, error)
+	// ReportsCompletion returns true if Progress reports PhaseCompleted once the node has been provisioned.
+	// Nodes which are never reported as completed remain in PhaseProvisioning
+	ReportsCompletion(i *seederv1alpha1.Inventory) bool
+	// Teardown removes all objects created to provision the node
+	Teardown(ctx context.Context, i *seederv1alpha1.Inventory) error
+	// WatchTypes returns the types of objects created by the provisioner which are owned by the cluster
+	WatchTypes() []client.Object
+}
+
+// NewDefaultProvisioners returns the provisioners available to clusters
+func NewDefaultProvisioners(c client.Client, scheme *runtime.Scheme) map[string]Provisioner {
+	return map[string]Provisioner{
+		TinkerbellProvisioner: NewTinkerbell(c, scheme),
+	}
+}
+
+// Name returns the name of the provisioner used by a cluster
+func Name(c *seederv1alpha1.Cluster) string {
+	if c.Spec.Provisioner != "" {
+		return c.Spec.Provisioner
+	}
+	return DefaultProvisioner
+}
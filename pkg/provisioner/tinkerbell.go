@@ -0,0 +1,188 @@
+package provisioner
+
+import (
+	"context"
+	"fmt"
+
+	seederv1alpha1 "github.com/harvester/seeder/pkg/api/v1alpha1"
+	"github.com/harvester/seeder/pkg/tink"
+	"github.com/harvester/seeder/pkg/util"
+	tinkv1alpha1 "github.com/tinkerbell/tink/pkg/apis/core/v1alpha1"
+	"k8s.io/apimachinery/pkg/api/equality"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+)
+
+const (
+	TinkerbellProvisioner = "tinkerbell"
+)
+
+// Tinkerbell provisions nodes by generating tinkerbell Hardware, which is used by boots to PXE boot
+// the node into the Harvester installer, and optionally a Template and Workflow run before the install
+type Tinkerbell struct {
+	client.Client
+	Scheme *runtime.Scheme
+}
+
+func NewTinkerbell(c client.Client, scheme *runtime.Scheme) *Tinkerbell {
+	return &Tinkerbell{
+		Client: c,
+		Scheme: scheme,
+	}
+}
+
+// Render generates the Hardware for the node. Workflow objects are generated from the template library when applied
+func (t *Tinkerbell) Render(i *seederv1alpha1.Inventory, c *seederv1alpha1.Cluster) ([]client.Object, error) {
+	hw, err := tink.GenerateHWRequest(i, c)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := controllerutil.SetOwnerReference(c, hw, t.Scheme); err != nil {
+		return nil, err
+	}
+	return []client.Object{hw}, nil
+}
+
+// Apply creates the Hardware for the node, or updates an existing Hardware. Changes to an existing
+// Hardware only apply to future installs and do not need a reboot of the node. The workflow is only
+// created with the Hardware, as it runs before the install, so a workflow template added to a provisioned
+// node is flagged as config drift and runs once the node is reprovisioned
+func (t *Tinkerbell) Apply(ctx context.Context, i *seederv1alpha1.Inventory, c *seederv1alpha1.Cluster) (bool, error) {
+	objs, err := t.Render(i, c)
+	if err != nil {
+		return false, err
+	}
+	hw := objs[0].(*tinkv1alpha1.Hardware)
+
+	lookupHw := &tinkv1alpha1.Hardware{}
+	err = t.Get(ctx, types.NamespacedName{Namespace: hw.Namespace, Name: hw.Name}, lookupHw)
+	if err != nil {
+		if !apierrors.IsNotFound(err) {
+			return false, err
+		}
+
+		// workflow is created first, as the node is only booted once the hardware exists
+		if i.Spec.WorkflowTemplate != nil {
+			if err := t.createWorkflow(ctx, i, c); err != nil {
+				return false, err
+			}
+			i.Status.Conditions = util.CreateOrUpdateCondition(i.Status.Conditions, seederv1alpha1.TinkWorkflowCreated,
+				fmt.Sprintf("tink workflow %s created", tink.WorkflowName(i)))
+		}
+
+		if err := t.Create(ctx, hw); err != nil {
+			return false, err
+		}
+		return true, nil
+	}
+
+	if !equality.Semantic.DeepEqual(lookupHw.Spec, hw.Spec) {
+		lookupHw.Spec = hw.Spec
+		if err := t.Update(ctx, lookupHw); err != nil {
+			return false, err
+		}
+	}
+	return false, nil
+}
+
+// Progress reports the state of the workflow for nodes installed with a workflow. Completion of the Harvester
+// install is not reported by boots, so nodes without a workflow remain in provisioning phase
+func (t *Tinkerbell) Progress(ctx context.Context, i *seederv1alpha1.Inventory) (Progress, error) {
+	hw := &tinkv1alpha1.Hardware{}
+	if err := t.Get(ctx, types.NamespacedName{Namespace: i.Namespace, Name: i.Name}, hw); err != nil {
+		if apierrors.IsNotFound(err) {
+			return Progress{Phase: PhasePending}, nil
+		}
+		return Progress{}, err
+	}
+
+	if !t.ReportsCompletion(i) {
+		return Progress{Phase: PhaseProvisioning}, nil
+	}
+
+	wf := &tinkv1alpha1.Workflow{}
+	if err := t.Get(ctx, types.NamespacedName{Namespace: i.Namespace, Name: tink.WorkflowName(i)}, wf); err != nil {
+		if apierrors.IsNotFound(err) {
+			return Progress{Phase: PhasePending}, nil
+		}
+		return Progress{}, err
+	}
+
+	switch wf.Status.State {
+	case tinkv1alpha1.WorkflowStateSuccess:
+		return Progress{Phase: PhaseCompleted}, nil
+	case tinkv1alpha1.WorkflowStateFailed, tinkv1alpha1.WorkflowStateTimeout:
+		return Progress{Phase: PhaseFailed, Message: tink.WorkflowMessage(wf)}, nil
+	}
+	return Progress{Phase: PhaseProvisioning, Message: string(wf.Status.State)}, nil
+}
+
+// ReportsCompletion returns true for nodes installed with a workflow, as only the workflow state is reported
+func (t *Tinkerbell) ReportsCompletion(i *seederv1alpha1.Inventory) bool {
+	return i.Spec.WorkflowTemplate != nil && util.ConditionExists(i.Status.Conditions, seederv1alpha1.TinkWorkflowCreated)
+}
+
+// Teardown removes the Hardware, and the Templates and Workflows generated for the node
+func (t *Tinkerbell) Teardown(ctx context.Context, i *seederv1alpha1.Inventory) error {
+	objs := []client.Object{
+		&tinkv1alpha1.Workflow{ObjectMeta: metav1.ObjectMeta{Name: tink.WorkflowName(i), Namespace: i.Namespace}},
+		&tinkv1alpha1.Template{ObjectMeta: metav1.ObjectMeta{Name: tink.WorkflowName(i), Namespace: i.Namespace}},
+		&tinkv1alpha1.Workflow{ObjectMeta: metav1.ObjectMeta{Name: tink.PostInstallWorkflowName(i), Namespace: i.Namespace}},
+		&tinkv1alpha1.Template{ObjectMeta: metav1.ObjectMeta{Name: tink.PostInstallWorkflowName(i), Namespace: i.Namespace}},
+	}
+
+	// hardware may have been replaced by the inventory controller to wipe the node
+	hw := &tinkv1alpha1.Hardware{}
+	err := t.Get(ctx, types.NamespacedName{Namespace: i.Namespace, Name: i.Name}, hw)
+	if err != nil && !apierrors.IsNotFound(err) {
+		return err
+	}
+	if err == nil && isOwnedByCluster(hw) {
+		objs = append(objs, hw)
+	}
+
+	for _, obj := range objs {
+		if err := t.Delete(ctx, obj); err != nil && !apierrors.IsNotFound(err) {
+			return err
+		}
+	}
+	return nil
+}
+
+func (t *Tinkerbell) WatchTypes() []client.Object {
+	return []client.Object{&tinkv1alpha1.Hardware{}}
+}
+
+// createWorkflow creates the tink Template and Workflow for an inventory from the template library
+func (t *Tinkerbell) createWorkflow(ctx context.Context, i *seederv1alpha1.Inventory, c *seederv1alpha1.Cluster) error {
+	data, err := util.FetchWorkflowTemplate(ctx, t.Client, i.Spec.WorkflowTemplate)
+	if err != nil {
+		return err
+	}
+
+	// workflow objects are owned by the inventory, which tracks the workflow state
+	objs := []client.Object{tink.GenerateTemplate(i, data), tink.GenerateWorkflow(i, c)}
+	for _, obj := range objs {
+		if err := controllerutil.SetOwnerReference(i, obj, t.Scheme); err != nil {
+			return err
+		}
+		if err := t.Create(ctx, obj); err != nil && !apierrors.IsAlreadyExists(err) {
+			return err
+		}
+	}
+	return nil
+}
+
+func isOwnedByCluster(obj client.Object) bool {
+	for _, o := range obj.GetOwnerReferences() {
+		if o.Kind == "Cluster" && o.APIVersion == seederv1alpha1.GroupVersion.String() {
+			return true
+		}
+	}
+	return false
+}
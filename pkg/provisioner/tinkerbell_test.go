@@ -0,0 +1,209 @@
+package provisioner
+
+import (
+	"context"
+	"testing"
+
+	seederv1alpha1 "github.com/harvester/seeder/pkg/api/v1alpha1"
+	"github.com/harvester/seeder/pkg/mock"
+	"github.com/harvester/seeder/pkg/tink"
+	"github.com/harvester/seeder/pkg/util"
+	"github.com/stretchr/testify/require"
+	tinkv1alpha1 "github.com/tinkerbell/tink/pkg/apis/core/v1alpha1"
+	v1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+var (
+	i = &seederv1alpha1.Inventory{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "provision-node",
+			Namespace: "default",
+		},
+		Spec: seederv1alpha1.InventorySpec{
+			PrimaryDisk:                   "/dev/sda",
+			ManagementInterfaceMacAddress: "xx:xx:xx:xx:xx",
+		},
+		Status: seederv1alpha1.InventoryStatus{
+			GeneratedPassword: "password",
+			PXEBootInterface: seederv1alpha1.PXEBootInterface{
+				Address: "192.168.1.129",
+				Netmask: "255.255.255.0",
+				Gateway: "192.168.1.1",
+			},
+		},
+	}
+
+	c = &seederv1alpha1.Cluster{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "provision-cluster",
+			Namespace: "default",
+		},
+		Spec: seederv1alpha1.ClusterSpec{
+			HarvesterVersion: "v1.0.3",
+			ClusterConfig: seederv1alpha1.ClusterConfig{
+				SSHKeys: []string{"abc"},
+			},
+		},
+		Status: seederv1alpha1.ClusterStatus{
+			ClusterToken:   "token",
+			ClusterAddress: "192.168.1.100",
+		},
+	}
+)
+
+func Test_TinkerbellApply(t *testing.T) {
+	assert := require.New(t)
+	k8sClient, err := mock.GenerateFakeClient()
+	assert.NoError(err, "expected no error during fake client generation")
+	p := NewTinkerbell(k8sClient, k8sClient.Scheme())
+	ctx := context.TODO()
+
+	created, err := p.Apply(ctx, i, c)
+	assert.NoError(err, "expected no error during apply")
+	assert.True(created, "expected hardware to be created")
+
+	progress, err := p.Progress(ctx, i)
+	assert.NoError(err, "expected no error during progress lookup")
+	assert.Equal(PhaseProvisioning, progress.Phase)
+	assert.False(p.ReportsCompletion(i), "expected completion not to be reported without a workflow")
+
+	clusterCopy := c.DeepCopy()
+	clusterCopy.Spec.ClusterConfig.SSHKeys = []string{"def"}
+	created, err = p.Apply(ctx, i, clusterCopy)
+	assert.NoError(err, "expected no error during apply")
+	assert.False(created, "expected existing hardware to be updated")
+
+	hw := &tinkv1alpha1.Hardware{}
+	err = k8sClient.Get(ctx, types.NamespacedName{Namespace: i.Namespace, Name: i.Name}, hw)
+	assert.NoError(err, "expected no error during hardware lookup")
+	assert.Contains(hw.Spec.Metadata.Instance.Userdata, "def", "expected hardware to contain new ssh key")
+
+	err = p.Teardown(ctx, i)
+	assert.NoError(err, "expected no error during teardown")
+	err = k8sClient.Get(ctx, types.NamespacedName{Namespace: i.Namespace, Name: i.Name}, hw)
+	assert.True(apierrors.IsNotFound(err), "expected hardware to be removed")
+
+	progress, err = p.Progress(ctx, i)
+	assert.NoError(err, "expected no error during progress lookup")
+	assert.Equal(PhasePending, progress.Phase)
+}
+
+func Test_TinkerbellApplyWithWorkflow(t *testing.T) {
+	assert := require.New(t)
+	k8sClient, err := mock.GenerateFakeClient()
+	assert.NoError(err, "expected no error during fake client generation")
+	p := NewTinkerbell(k8sClient, k8sClient.Scheme())
+	ctx := context.TODO()
+
+	library := &v1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "templates",
+			Namespace: "default",
+		},
+		Data: map[string]string{
+			"firmware": "version: \"0.1\"",
+		},
+	}
+	err = k8sClient.Create(ctx, library)
+	assert.NoError(err, "expected no error during library creation")
+
+	inventoryCopy := i.DeepCopy()
+	inventoryCopy.Spec.WorkflowTemplate = &seederv1alpha1.WorkflowTemplateReference{
+		Library: seederv1alpha1.ObjectReference{
+			Name:      "templates",
+			Namespace: "default",
+		},
+		Name: "missing",
+	}
+	_, err = p.Apply(ctx, inventoryCopy, c)
+	assert.Error(err, "expected error for missing template")
+
+	inventoryCopy.Spec.WorkflowTemplate.Name = "firmware"
+	created, err := p.Apply(ctx, inventoryCopy, c)
+	assert.NoError(err, "expected no error during apply")
+	assert.True(created, "expected hardware to be created")
+	assert.True(util.ConditionExists(inventoryCopy.Status.Conditions, seederv1alpha1.TinkWorkflowCreated), "expected workflow condition")
+	assert.True(p.ReportsCompletion(inventoryCopy), "expected completion of the workflow to be reported")
+
+	wf := &tinkv1alpha1.Workflow{}
+	err = k8sClient.Get(ctx, types.NamespacedName{Namespace: i.Namespace, Name: "provision-node-workflow"}, wf)
+	assert.NoError(err, "expected no error during workflow lookup")
+	wf.Status.State = tinkv1alpha1.WorkflowStateFailed
+	err = k8sClient.Update(ctx, wf)
+	assert.NoError(err, "expected no error during workflow update")
+
+	progress, err := p.Progress(ctx, inventoryCopy)
+	assert.NoError(err, "expected no error during progress lookup")
+	assert.Equal(PhaseFailed, progress.Phase)
+
+	// post-install objects are created by the inventory controller, and removed on teardown
+	postInstall := tink.GeneratePostInstallWorkflow(inventoryCopy, c)
+	err = k8sClient.Create(ctx, postInstall)
+	assert.NoError(err, "expected no error during post-install workflow creation")
+	err = p.Teardown(ctx, inventoryCopy)
+	assert.NoError(err, "expected no error during teardown")
+	for _, name := range []string{"provision-node-workflow", "provision-node-post-install"} {
+		err = k8sClient.Get(ctx, types.NamespacedName{Namespace: i.Namespace, Name: name}, wf)
+		assert.True(apierrors.IsNotFound(err), "expected workflow %s to be removed", name)
+	}
+}
+
+func Test_TinkerbellApplyWorkflowAddedToProvisionedNode(t *testing.T) {
+	assert := require.New(t)
+	k8sClient, err := mock.GenerateFakeClient()
+	assert.NoError(err, "expected no error during fake client generation")
+	p := NewTinkerbell(k8sClient, k8sClient.Scheme())
+	ctx := context.TODO()
+
+	library := &v1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "templates",
+			Namespace: "default",
+		},
+		Data: map[string]string{
+			"firmware": "version: \"0.1\"",
+		},
+	}
+	err = k8sClient.Create(ctx, library)
+	assert.NoError(err, "expected no error during library creation")
+
+	inventoryCopy := i.DeepCopy()
+	created, err := p.Apply(ctx, inventoryCopy, c)
+	assert.NoError(err, "expected no error during apply")
+	assert.True(created, "expected hardware to be created")
+
+	// the workflow runs before the install, so it is not created for a node which has already been provisioned
+	inventoryCopy.Spec.WorkflowTemplate = &seederv1alpha1.WorkflowTemplateReference{
+		Library: seederv1alpha1.ObjectReference{
+			Name:      "templates",
+			Namespace: "default",
+		},
+		Name: "firmware",
+	}
+	created, err = p.Apply(ctx, inventoryCopy, c)
+	assert.NoError(err, "expected no error during apply")
+	assert.False(created, "expected existing hardware to be updated")
+	assert.False(util.ConditionExists(inventoryCopy.Status.Conditions, seederv1alpha1.TinkWorkflowCreated), "expected no workflow condition")
+	assert.False(p.ReportsCompletion(inventoryCopy), "expected completion not to be reported without a workflow")
+
+	wf := &tinkv1alpha1.Workflow{}
+	err = k8sClient.Get(ctx, types.NamespacedName{Namespace: i.Namespace, Name: "provision-node-workflow"}, wf)
+	assert.True(apierrors.IsNotFound(err), "expected no workflow to be created")
+
+	progress, err := p.Progress(ctx, inventoryCopy)
+	assert.NoError(err, "expected no error during progress lookup")
+	assert.Equal(PhaseProvisioning, progress.Phase, "expected node without a workflow to remain provisioning")
+
+	// the workflow is created when the node is reprovisioned
+	err = p.Teardown(ctx, inventoryCopy)
+	assert.NoError(err, "expected no error during teardown")
+	created, err = p.Apply(ctx, inventoryCopy, c)
+	assert.NoError(err, "expected no error during apply")
+	assert.True(created, "expected hardware to be created")
+	assert.True(util.ConditionExists(inventoryCopy.Status.Conditions, seederv1alpha1.TinkWorkflowCreated), "expected workflow condition")
+	err = k8sClient.Get(ctx, types.NamespacedName{Namespace: i.Namespace, Name: "provision-node-workflow"}, wf)
+	assert.NoError(err, "expected workflow to be created")
+}
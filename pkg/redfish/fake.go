@@ -0,0 +1,68 @@
+package redfish
+
+import (
+	"context"
+	"sync"
+)
+
+// Fake implements an in-memory virtual CD drive and config media device per BMC endpoint for integration testing
+type Fake struct {
+	mu     sync.Mutex
+	media  map[string]string
+	config map[string]string
+}
+
+func NewFake() *Fake {
+	return &Fake{
+		media:  make(map[string]string),
+		config: make(map[string]string),
+	}
+}
+
+// NewClient can be used as a NewVirtualMediaClientFunc
+func (f *Fake) NewClient(ctx context.Context, username, password, endpoint string, insecure bool) (VirtualMediaClient, error) {
+	return &fakeClient{fake: f, endpoint: endpoint}, nil
+}
+
+// Image returns the image inserted into the BMC at endpoint
+func (f *Fake) Image(endpoint string) string {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.media[endpoint]
+}
+
+// ConfigImage returns the config media image inserted into the BMC at endpoint
+func (f *Fake) ConfigImage(endpoint string) string {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.config[endpoint]
+}
+
+type fakeClient struct {
+	fake     *Fake
+	endpoint string
+}
+
+func (c *fakeClient) InsertMedia(image string) error {
+	c.fake.mu.Lock()
+	defer c.fake.mu.Unlock()
+	c.fake.media[c.endpoint] = image
+	return nil
+}
+
+func (c *fakeClient) InsertConfigMedia(image string) error {
+	c.fake.mu.Lock()
+	defer c.fake.mu.Unlock()
+	c.fake.config[c.endpoint] = image
+	return nil
+}
+
+func (c *fakeClient) EjectMedia() error {
+	c.fake.mu.Lock()
+	defer c.fake.mu.Unlock()
+	delete(c.fake.media, c.endpoint)
+	delete(c.fake.config, c.endpoint)
+	return nil
+}
+
+func (c *fakeClient) Close() {}
@@ -0,0 +1,141 @@
+package redfish
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/stmcginnis/gofish"
+	gofishredfish "github.com/stmcginnis/gofish/redfish"
+)
+
+// VirtualMediaClient manages the virtual CD drive of a BMC
+type VirtualMediaClient interface {
+	// InsertMedia inserts the image into the virtual CD drive, replacing any other inserted image
+	InsertMedia(image string) error
+	// InsertConfigMedia inserts the image into a second virtual media device, replacing any other inserted image.
+	// Used for config drives read by the installer booted from the virtual CD drive
+	InsertConfigMedia(image string) error
+	// EjectMedia ejects the images from the virtual CD drive and the config media device
+	EjectMedia() error
+	// Close releases the connections to the BMC
+	Close()
+}
+
+// NewVirtualMediaClientFunc is used to connect to the BMC of an inventory. The certificate of the BMC is not verified
+// when insecure is true
+type NewVirtualMediaClientFunc func(ctx context.Context, username, password, endpoint string, insecure bool) (VirtualMediaClient, error)
+
+// Client implements VirtualMediaClient using the first virtual CD drive on the managers of the BMC, and the next
+// device accepting CD images or USB sticks for config media
+type Client struct {
+	client *gofish.APIClient
+}
+
+func NewClient(ctx context.Context, username, password, endpoint string, insecure bool) (VirtualMediaClient, error) {
+	cfg := gofish.ClientConfig{
+		Username:  username,
+		Password:  password,
+		Endpoint:  endpoint,
+		Insecure:  insecure,
+		BasicAuth: true,
+	}
+
+	apiClient, err := gofish.ConnectContext(ctx, cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Client{client: apiClient}, nil
+}
+
+func (c *Client) InsertMedia(image string) error {
+	vm, _, err := c.devices()
+	if err != nil {
+		return err
+	}
+
+	return insert(vm, image)
+}
+
+func (c *Client) InsertConfigMedia(image string) error {
+	_, config, err := c.devices()
+	if err != nil {
+		return err
+	}
+
+	if config == nil {
+		return fmt.Errorf("no second virtual media device found for config media")
+	}
+	return insert(config, image)
+}
+
+func (c *Client) EjectMedia() error {
+	vm, config, err := c.devices()
+	if err != nil {
+		return err
+	}
+
+	for _, v := range []*gofishredfish.VirtualMedia{vm, config} {
+		if v == nil || !v.Inserted {
+			continue
+		}
+		if err := v.EjectMedia(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (c *Client) Close() {
+	c.client.HTTPClient.CloseIdleConnections()
+}
+
+// devices finds the virtual media device which accepts CD images, and the next device accepting CD images or
+// USB sticks which is used for config media. The config media device is nil when the BMC has a single device
+func (c *Client) devices() (vm *gofishredfish.VirtualMedia, config *gofishredfish.VirtualMedia, err error) {
+	managers, err := c.client.Service.Managers()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	for _, m := range managers {
+		media, err := m.VirtualMedia()
+		if err != nil {
+			return nil, nil, err
+		}
+		for _, v := range media {
+			if !v.SupportsMediaInsert {
+				continue
+			}
+			for _, t := range v.MediaTypes {
+				if vm == nil && (t == gofishredfish.CDMediaType || t == gofishredfish.DVDMediaType) {
+					vm = v
+					break
+				}
+				if config == nil && (t == gofishredfish.CDMediaType || t == gofishredfish.DVDMediaType || t == gofishredfish.USBStickMediaType) {
+					config = v
+					break
+				}
+			}
+		}
+	}
+
+	if vm == nil {
+		return nil, nil, fmt.Errorf("no virtual media device supporting CD images found")
+	}
+	return vm, config, nil
+}
+
+// insert inserts the image into the device, ejecting any other inserted image
+func insert(vm *gofishredfish.VirtualMedia, image string) error {
+	if vm.Inserted {
+		if vm.Image == image {
+			return nil
+		}
+		if err := vm.EjectMedia(); err != nil {
+			return fmt.Errorf("error ejecting image %s: %v", vm.Image, err)
+		}
+	}
+
+	return vm.InsertMedia(image, true, true)
+}
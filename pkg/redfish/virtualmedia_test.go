@@ -0,0 +1,188 @@
+package redfish
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+// mockDevice is the state of a virtual media device on the mockBMC
+type mockDevice struct {
+	image    string
+	inserted bool
+	ejects   int
+}
+
+// mockBMC serves the minimal redfish tree needed to manage virtual media, with a USB stick device and a CD drive
+type mockBMC struct {
+	mu     sync.Mutex
+	floppy mockDevice
+	cd     mockDevice
+}
+
+func (m *mockBMC) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	devices := map[string]*mockDevice{
+		"Floppy": &m.floppy,
+		"CD":     &m.cd,
+	}
+	device := func(name string, mediaTypes []string) map[string]interface{} {
+		path := "/redfish/v1/Managers/1/VirtualMedia/" + name
+		return map[string]interface{}{
+			"@odata.id":  path,
+			"MediaTypes": mediaTypes,
+			"Image":      devices[name].image,
+			"Inserted":   devices[name].inserted,
+			"Actions": map[string]interface{}{
+				"#VirtualMedia.InsertMedia": map[string]string{"target": path + "/Actions/VirtualMedia.InsertMedia"},
+				"#VirtualMedia.EjectMedia":  map[string]string{"target": path + "/Actions/VirtualMedia.EjectMedia"},
+			},
+		}
+	}
+
+	resources := map[string]interface{}{
+		"/redfish/v1/": map[string]interface{}{
+			"@odata.id": "/redfish/v1/",
+			"Managers":  map[string]string{"@odata.id": "/redfish/v1/Managers"},
+		},
+		"/redfish/v1/Managers": map[string]interface{}{
+			"Members@odata.count": 1,
+			"Members":             []map[string]string{{"@odata.id": "/redfish/v1/Managers/1"}},
+		},
+		"/redfish/v1/Managers/1": map[string]interface{}{
+			"@odata.id":    "/redfish/v1/Managers/1",
+			"VirtualMedia": map[string]string{"@odata.id": "/redfish/v1/Managers/1/VirtualMedia"},
+		},
+		"/redfish/v1/Managers/1/VirtualMedia": map[string]interface{}{
+			"Members@odata.count": 2,
+			"Members": []map[string]string{
+				{"@odata.id": "/redfish/v1/Managers/1/VirtualMedia/Floppy"},
+				{"@odata.id": "/redfish/v1/Managers/1/VirtualMedia/CD"},
+			},
+		},
+		"/redfish/v1/Managers/1/VirtualMedia/Floppy": device("Floppy", []string{"Floppy", "USBStick"}),
+		"/redfish/v1/Managers/1/VirtualMedia/CD":     device("CD", []string{"CD", "DVD"}),
+	}
+
+	if r.Method == http.MethodPost {
+		// actions are posted to /redfish/v1/Managers/1/VirtualMedia/<device>/Actions/<action>
+		parts := strings.Split(strings.TrimPrefix(r.URL.Path, "/redfish/v1/Managers/1/VirtualMedia/"), "/")
+		if len(parts) != 3 || parts[1] != "Actions" || devices[parts[0]] == nil {
+			http.NotFound(w, r)
+			return
+		}
+		d := devices[parts[0]]
+		switch parts[2] {
+		case "VirtualMedia.InsertMedia":
+			body := struct {
+				Image    string
+				Inserted bool
+			}{}
+			if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+				http.Error(w, err.Error(), http.StatusBadRequest)
+				return
+			}
+			if d.inserted {
+				http.Error(w, "media already inserted", http.StatusConflict)
+				return
+			}
+			d.image = body.Image
+			d.inserted = body.Inserted
+		case "VirtualMedia.EjectMedia":
+			d.image = ""
+			d.inserted = false
+			d.ejects++
+		default:
+			http.NotFound(w, r)
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+		return
+	}
+
+	resource, ok := resources[r.URL.Path]
+	if !ok {
+		http.NotFound(w, r)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(resource)
+}
+
+func Test_VirtualMedia(t *testing.T) {
+	assert := require.New(t)
+	bmc := &mockBMC{}
+	server := httptest.NewTLSServer(bmc)
+	defer server.Close()
+
+	c, err := NewClient(context.TODO(), "root", "calvin", server.URL, true)
+	assert.NoError(err, "expected no error connecting to bmc")
+	defer c.Close()
+
+	err = c.InsertMedia("http://localhost/harvester.iso")
+	assert.NoError(err, "expected no error inserting media")
+	assert.True(bmc.cd.inserted, "expected media to be inserted")
+	assert.Equal("http://localhost/harvester.iso", bmc.cd.image, "expected image to be inserted")
+
+	// inserting the same image is a no-op
+	err = c.InsertMedia("http://localhost/harvester.iso")
+	assert.NoError(err, "expected no error inserting the same media")
+	assert.Equal(0, bmc.cd.ejects, "expected inserted media to be left in place")
+
+	// a different image replaces the inserted image
+	err = c.InsertMedia("http://localhost/config.iso")
+	assert.NoError(err, "expected no error replacing media")
+	assert.Equal(1, bmc.cd.ejects, "expected inserted media to be ejected")
+	assert.Equal("http://localhost/config.iso", bmc.cd.image, "expected image to be replaced")
+
+	err = c.InsertConfigMedia("http://localhost/node-config.iso")
+	assert.NoError(err, "expected no error inserting config media")
+	assert.True(bmc.floppy.inserted, "expected config media to be inserted")
+	assert.Equal("http://localhost/node-config.iso", bmc.floppy.image, "expected config image to be inserted into the second device")
+	assert.Equal("http://localhost/config.iso", bmc.cd.image, "expected cd image to be left in place")
+
+	err = c.EjectMedia()
+	assert.NoError(err, "expected no error ejecting media")
+	assert.False(bmc.cd.inserted, "expected media to be ejected")
+	assert.False(bmc.floppy.inserted, "expected config media to be ejected")
+
+	// ejecting an empty drive is a no-op
+	err = c.EjectMedia()
+	assert.NoError(err, "expected no error ejecting empty drive")
+	assert.Equal(2, bmc.cd.ejects, "expected empty drive to be left in place")
+	assert.Equal(1, bmc.floppy.ejects, "expected empty config device to be left in place")
+}
+
+func Test_VirtualMediaSecureConnection(t *testing.T) {
+	assert := require.New(t)
+	server := httptest.NewTLSServer(&mockBMC{})
+	defer server.Close()
+
+	_, err := NewClient(context.TODO(), "root", "calvin", server.URL, false)
+	assert.Error(err, "expected error verifying the self signed certificate of the bmc")
+}
+
+func Test_FakeVirtualMedia(t *testing.T) {
+	assert := require.New(t)
+	f := NewFake()
+	endpoint := fmt.Sprintf("https://%s", "172.16.1.52")
+
+	c, err := f.NewClient(context.TODO(), "root", "calvin", endpoint, false)
+	assert.NoError(err, "expected no error creating fake client")
+	assert.NoError(c.InsertMedia("http://localhost/harvester.iso"), "expected no error inserting media")
+	assert.Equal("http://localhost/harvester.iso", f.Image(endpoint), "expected image to be inserted")
+	assert.NoError(c.InsertConfigMedia("http://localhost/config.iso"), "expected no error inserting config media")
+	assert.Equal("http://localhost/config.iso", f.ConfigImage(endpoint), "expected config image to be inserted")
+	assert.NoError(c.EjectMedia(), "expected no error ejecting media")
+	assert.Empty(f.Image(endpoint), "expected image to be ejected")
+	assert.Empty(f.ConfigImage(endpoint), "expected config image to be ejected")
+}
@@ -0,0 +1,137 @@
+package tink
+
+import (
+	"fmt"
+	"strings"
+
+	seederv1alpha1 "github.com/harvester/seeder/pkg/api/v1alpha1"
+	"github.com/harvester/seeder/pkg/util"
+	"sigs.k8s.io/yaml"
+)
+
+// harvesterConfig is the Harvester install configuration read by the installer. It contains the same
+// settings as the kernel arguments in the Hardware metadata
+type harvesterConfig struct {
+	SchemeVersion int              `json:"scheme_version,omitempty"`
+	ServerURL     string           `json:"server_url,omitempty"`
+	Token         string           `json:"token"`
+	OS            harvesterOS      `json:"os"`
+	Install       harvesterInstall `json:"install"`
+}
+
+type harvesterOS struct {
+	SSHAuthorizedKeys []string `json:"ssh_authorized_keys,omitempty"`
+	Password          string   `json:"password"`
+	DNSNameservers    []string `json:"dns_nameservers,omitempty"`
+}
+
+type harvesterInstall struct {
+	Mode string `json:"mode"`
+	// ManagementInterface is used by v1.1 and later
+	ManagementInterface *harvesterNetwork `json:"management_interface,omitempty"`
+	// Networks is used by v1.0
+	Networks  map[string]harvesterNetwork `json:"networks,omitempty"`
+	Device    string                      `json:"device"`
+	ISOURL    string                      `json:"iso_url"`
+	VIP       string                      `json:"vip"`
+	VIPMode   string                      `json:"vip_mode"`
+	VIPv6     string                      `json:"vip_ipv6,omitempty"`
+	ConfigURL string                      `json:"config_url,omitempty"`
+	Automatic bool                        `json:"automatic"`
+}
+
+type harvesterNetwork struct {
+	Interfaces       []harvesterInterface `json:"interfaces"`
+	Method           string               `json:"method"`
+	IP               string               `json:"ip,omitempty"`
+	SubnetMask       string               `json:"subnet_mask,omitempty"`
+	Gateway          string               `json:"gateway,omitempty"`
+	BondOptions      harvesterBondOptions `json:"bond_options"`
+	MTU              int                  `json:"mtu,omitempty"`
+	VLANID           int                  `json:"vlan_id,omitempty"`
+	IPv6             string               `json:"ipv6,omitempty"`
+	IPv6PrefixLength int                  `json:"ipv6_prefix_length,omitempty"`
+	IPv6Gateway      string               `json:"ipv6_gateway,omitempty"`
+}
+
+type harvesterBondOptions struct {
+	Mode   string `json:"mode"`
+	MiiMon int    `json:"miimon"`
+}
+
+type harvesterInterface struct {
+	HWAddr string `json:"hwAddr"`
+}
+
+// GenerateInstallConfig generates the Harvester install configuration of the node, for installers which are not
+// booted with the kernel arguments in the Hardware metadata, such as nodes booted from virtual media. The node is
+// installed automatically using the configuration
+func GenerateInstallConfig(i *seederv1alpha1.Inventory, c *seederv1alpha1.Cluster) ([]byte, error) {
+	network, err := generateManagementNetwork(i, c)
+	if err != nil {
+		return nil, err
+	}
+
+	mode := "join"
+	if util.ConditionExists(i.Status.Conditions, seederv1alpha1.HarvesterCreateNode) {
+		mode = "create"
+	}
+
+	mgmt := harvesterNetwork{
+		Interfaces: []harvesterInterface{{HWAddr: network.HWAddress}},
+		Method:     "dhcp",
+		BondOptions: harvesterBondOptions{
+			Mode:   network.BondMode,
+			MiiMon: 100,
+		},
+		MTU:              network.MTU,
+		VLANID:           network.VLANID,
+		IPv6:             network.IPv6Address,
+		IPv6PrefixLength: network.IPv6PrefixLength,
+		IPv6Gateway:      network.IPv6Gateway,
+	}
+	for _, v := range network.Members {
+		mgmt.Interfaces = append(mgmt.Interfaces, harvesterInterface{HWAddr: v.MacAddress})
+	}
+	if network.Static {
+		mgmt.Method = string(seederv1alpha1.NetworkMethodStatic)
+		mgmt.IP = network.Address
+		mgmt.SubnetMask = network.Netmask
+		mgmt.Gateway = network.Gateway
+	}
+
+	config := harvesterConfig{
+		Token: c.Status.ClusterToken,
+		OS: harvesterOS{
+			SSHAuthorizedKeys: c.Spec.ClusterConfig.SSHKeys,
+			Password:          i.Status.GeneratedPassword,
+			DNSNameservers:    c.Spec.ClusterConfig.Nameservers,
+		},
+		Install: harvesterInstall{
+			Mode:      mode,
+			Device:    i.Spec.PrimaryDisk,
+			ISOURL:    util.GenerateISOURL(c.Spec.ImageURL, c.Spec.HarvesterVersion, util.Arch(i)),
+			VIP:       c.Status.ClusterAddress,
+			VIPMode:   "static",
+			VIPv6:     c.Status.ClusterIPv6Address,
+			ConfigURL: c.Spec.ConfigURL,
+			Automatic: true,
+		},
+	}
+
+	// the management network and api port of the cluster changed in v1.1
+	if strings.Contains(c.Spec.HarvesterVersion, "v1.1") {
+		config.SchemeVersion = 1
+		config.Install.ManagementInterface = &mgmt
+		if mode == "join" {
+			config.ServerURL = fmt.Sprintf("https://%s:443", c.Status.ClusterAddress)
+		}
+	} else {
+		config.Install.Networks = map[string]harvesterNetwork{"harvester-mgmt": mgmt}
+		if mode == "join" {
+			config.ServerURL = fmt.Sprintf("https://%s:8443", c.Status.ClusterAddress)
+		}
+	}
+
+	return yaml.Marshal(config)
+}
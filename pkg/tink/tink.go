@@ -2,8 +2,11 @@ package tink
 
 import (
 	"bytes"
+	"crypto/sha256"
+	"encoding/json"
 	"fmt"
 	"html/template"
+	"net"
 	"strings"
 
 	seederv1alpha1 "github.com/harvester/seeder/pkg/api/v1alpha1"
@@ -16,9 +19,10 @@ import (
 const (
 	defaultLeaseTime    = 86400
 	defaultArch         = "x86_64"
+	arm64Arch           = "aarch64"
 	defaultFacilityCode = "on_prem"
 	defaultDistro       = "harvester"
-	defaultISOURL       = "https://releases.rancher.com/harvester/"
+	defaultBondMode     = "balance-tlb"
 )
 
 // GenerateHWRequest will generate the tinkerbell Hardware type object
@@ -30,18 +34,32 @@ func GenerateHWRequest(i *seederv1alpha1.Inventory, c *seederv1alpha1.Cluster) (
 		mode = "create"
 	}
 
+	network, err := generateManagementNetwork(i, c)
+	if err != nil {
+		return nil, err
+	}
+
 	var m string
 	if strings.Contains(c.Spec.HarvesterVersion, "v1.1") {
-		m, err = generateMetaDataV11(c.Spec.ConfigURL, c.Spec.HarvesterVersion, i.Spec.ManagementInterfaceMacAddress, mode,
-			i.Spec.PrimaryDisk, c.Status.ClusterAddress, c.Status.ClusterToken, i.Status.GeneratedPassword, c.Spec.ImageURL, c.Spec.ClusterConfig.Nameservers, c.Spec.ClusterConfig.SSHKeys)
+		m, err = generateMetaDataV11(c.Spec.ConfigURL, c.Spec.HarvesterVersion, network, mode,
+			i.Spec.PrimaryDisk, c.Status.ClusterAddress, c.Status.ClusterIPv6Address, c.Status.ClusterToken, i.Status.GeneratedPassword, c.Spec.ImageURL, util.Arch(i), c.Spec.ClusterConfig.Nameservers, c.Spec.ClusterConfig.SSHKeys)
 	} else {
-		m, err = generateMetaDataV10(c.Spec.ConfigURL, c.Spec.HarvesterVersion, i.Spec.ManagementInterfaceMacAddress, mode,
-			i.Spec.PrimaryDisk, c.Status.ClusterAddress, c.Status.ClusterToken, i.Status.GeneratedPassword, c.Spec.ImageURL, c.Spec.ClusterConfig.Nameservers, c.Spec.ClusterConfig.SSHKeys)
+		m, err = generateMetaDataV10(c.Spec.ConfigURL, c.Spec.HarvesterVersion, network, mode,
+			i.Spec.PrimaryDisk, c.Status.ClusterAddress, c.Status.ClusterIPv6Address, c.Status.ClusterToken, i.Status.GeneratedPassword, c.Spec.ImageURL, util.Arch(i), c.Spec.ClusterConfig.Nameservers, c.Spec.ClusterConfig.SSHKeys)
 	}
 	if err != nil {
 		return nil, errors.Wrap(err, "error during metadata generation")
 	}
 
+	// workflows are only run on nodes with a workflow template
+	var allowWorkflow *bool
+	if i.Spec.WorkflowTemplate != nil || i.Spec.PostInstallWorkflowTemplate != nil {
+		allowWorkflow = &[]bool{true}[0]
+	}
+
+	// nodes booted from virtual media should not be netbooted by boots
+	allowPXE := util.BootMethod(i, c) == seederv1alpha1.BootMethodPXE
+
 	hw = &tinkv1alpha1.Hardware{
 		ObjectMeta: metav1.ObjectMeta{
 			Name:      i.Name,
@@ -51,7 +69,8 @@ func GenerateHWRequest(i *seederv1alpha1.Inventory, c *seederv1alpha1.Cluster) (
 			Interfaces: []tinkv1alpha1.Interface{
 				{
 					Netboot: &tinkv1alpha1.Netboot{
-						AllowPXE: &[]bool{true}[0],
+						AllowPXE:      &allowPXE,
+						AllowWorkflow: allowWorkflow,
 						OSIE: &tinkv1alpha1.OSIE{
 							BaseURL: c.Spec.ImageURL,
 						},
@@ -60,8 +79,8 @@ func GenerateHWRequest(i *seederv1alpha1.Inventory, c *seederv1alpha1.Cluster) (
 						MAC:       i.Spec.ManagementInterfaceMacAddress,
 						Hostname:  fmt.Sprintf("%s-%s", i.Name, i.Namespace),
 						LeaseTime: defaultLeaseTime,
-						Arch:      defaultArch,
-						UEFI:      true,
+						Arch:      dhcpArch(i),
+						UEFI:      util.IsUEFI(i),
 						IP: &tinkv1alpha1.IP{
 							Address: i.Status.Address,
 							Netmask: i.Status.Netmask,
@@ -90,18 +109,211 @@ func GenerateHWRequest(i *seederv1alpha1.Inventory, c *seederv1alpha1.Cluster) (
 		},
 	}
 
+	// dual-stack nodes publish both management addresses in the instance metadata, as DHCP only serves
+	// the IPv4 address
+	if i.Status.IPv6Address != "" {
+		hw.Spec.Metadata.Instance.Ips = []*tinkv1alpha1.MetadataInstanceIP{
+			{
+				Address:    i.Status.Address,
+				Netmask:    i.Status.Netmask,
+				Gateway:    i.Status.Gateway,
+				Family:     4,
+				Management: true,
+			},
+			{
+				Address:    i.Status.IPv6Address,
+				Netmask:    i.Status.IPv6Netmask,
+				Gateway:    i.Status.IPv6Gateway,
+				Family:     6,
+				Management: true,
+			},
+		}
+	}
+
+	// bond members are registered without an address, and are not netbooted
+	for _, nic := range network.Members {
+		hw.Spec.Interfaces = append(hw.Spec.Interfaces, tinkv1alpha1.Interface{
+			Netboot: &tinkv1alpha1.Netboot{
+				AllowPXE:      &[]bool{false}[0],
+				AllowWorkflow: &[]bool{false}[0],
+			},
+			DHCP: &tinkv1alpha1.DHCP{
+				MAC:       nic.MacAddress,
+				Hostname:  fmt.Sprintf("%s-%s", i.Name, i.Namespace),
+				LeaseTime: defaultLeaseTime,
+				Arch:      dhcpArch(i),
+				UEFI:      util.IsUEFI(i),
+			},
+		})
+	}
+
 	return hw, nil
 }
 
+// GenerateInstallConfigHash returns a hash of the configuration which is baked into a node during install.
+// ImageURL and HarvesterVersion are not included as they only affect future installs, and version changes
+// are handled by upgrades
+func GenerateInstallConfigHash(i *seederv1alpha1.Inventory, c *seederv1alpha1.Cluster) string {
+	installConfig := struct {
+		MacAddress  string
+		PrimaryDisk string
+		Address     string
+		Netmask     string
+		Gateway     string
+		VIP         string
+		Token       string
+		Password    string
+		ConfigURL   string
+		Nameservers []string
+		SSHKeys     []string
+		// management network settings are only set when used, so the hash of existing nodes is unchanged
+		NetworkMethod seederv1alpha1.NetworkMethod `json:",omitempty"`
+		BondMembers   []string                     `json:",omitempty"`
+		BondMode      string                       `json:",omitempty"`
+		MTU           int                          `json:",omitempty"`
+		VLANID        int                          `json:",omitempty"`
+		IPv6Address   string                       `json:",omitempty"`
+		IPv6Netmask   string                       `json:",omitempty"`
+		IPv6Gateway   string                       `json:",omitempty"`
+		VIPv6         string                       `json:",omitempty"`
+		// boot mode and arch are only set when not the defaults, so the hash of existing nodes is unchanged
+		BootMode seederv1alpha1.BootMode     `json:",omitempty"`
+		Arch     seederv1alpha1.Architecture `json:",omitempty"`
+		// the workflow template is only set when used, so the hash of existing nodes is unchanged
+		WorkflowTemplate *seederv1alpha1.WorkflowTemplateReference `json:",omitempty"`
+	}{
+		MacAddress:  i.Spec.ManagementInterfaceMacAddress,
+		PrimaryDisk: i.Spec.PrimaryDisk,
+		Address:     i.Status.Address,
+		Netmask:     i.Status.Netmask,
+		Gateway:     i.Status.Gateway,
+		VIP:         c.Status.ClusterAddress,
+		Token:       c.Status.ClusterToken,
+		Password:    i.Status.GeneratedPassword,
+		ConfigURL:   c.Spec.ConfigURL,
+		Nameservers: c.Spec.ClusterConfig.Nameservers,
+		SSHKeys:     c.Spec.ClusterConfig.SSHKeys,
+		BondMode:    c.Spec.ManagementNetwork.BondMode,
+		MTU:         c.Spec.ManagementNetwork.MTU,
+		VLANID:      c.Spec.ManagementNetwork.VLANID,
+		IPv6Address: i.Status.IPv6Address,
+		IPv6Netmask: i.Status.IPv6Netmask,
+		IPv6Gateway: i.Status.IPv6Gateway,
+		VIPv6:       c.Status.ClusterIPv6Address,
+		// a template added to a provisioned node only runs once the node is reinstalled
+		WorkflowTemplate: i.Spec.WorkflowTemplate,
+	}
+	for _, name := range c.Spec.ManagementNetwork.Interfaces {
+		for _, nic := range i.Spec.Interfaces {
+			if nic.Name == name {
+				installConfig.BondMembers = append(installConfig.BondMembers, nic.MacAddress)
+			}
+		}
+	}
+	if c.Spec.ManagementNetwork.Method == seederv1alpha1.NetworkMethodStatic {
+		installConfig.NetworkMethod = seederv1alpha1.NetworkMethodStatic
+	}
+	if !util.IsUEFI(i) {
+		installConfig.BootMode = seederv1alpha1.BootModeLegacy
+	}
+	if util.Arch(i) != seederv1alpha1.ArchitectureAMD64 {
+		installConfig.Arch = util.Arch(i)
+	}
+
+	// marshalling a struct of strings cannot fail
+	out, _ := json.Marshal(installConfig)
+	return fmt.Sprintf("%x", sha256.Sum256(out))
+}
+
+// managementNetwork is the management interface configuration rendered into the Harvester install config
+type managementNetwork struct {
+	HWAddress string
+	// Members are the additional interfaces bonded with the management interface
+	Members  []seederv1alpha1.NetworkInterface
+	Static   bool
+	Address  string
+	Netmask  string
+	Gateway  string
+	BondMode string
+	MTU      int
+	VLANID   int
+	// IPv6 management address of dual-stack nodes, which is always static as DHCP only serves the IPv4 address
+	IPv6Address      string
+	IPv6PrefixLength int
+	IPv6Gateway      string
+}
+
+// generateManagementNetwork generates the management interface configuration for the inventory. The installer
+// always uses DHCP, and static nodes are configured with the address allocated to the node once installed
+func generateManagementNetwork(i *seederv1alpha1.Inventory, c *seederv1alpha1.Cluster) (managementNetwork, error) {
+	n := managementNetwork{
+		HWAddress: i.Spec.ManagementInterfaceMacAddress,
+		Static:    c.Spec.ManagementNetwork.Method == seederv1alpha1.NetworkMethodStatic,
+		Address:   i.Status.Address,
+		Netmask:   i.Status.Netmask,
+		Gateway:   i.Status.Gateway,
+		BondMode:  defaultBondMode,
+		MTU:       c.Spec.ManagementNetwork.MTU,
+		VLANID:    c.Spec.ManagementNetwork.VLANID,
+	}
+
+	if c.Spec.ManagementNetwork.BondMode != "" {
+		n.BondMode = c.Spec.ManagementNetwork.BondMode
+	}
+
+	if i.Status.IPv6Address != "" {
+		n.IPv6Address = i.Status.IPv6Address
+		n.IPv6PrefixLength = prefixLength(i.Status.IPv6Netmask)
+		n.IPv6Gateway = i.Status.IPv6Gateway
+	}
+
+	for _, name := range c.Spec.ManagementNetwork.Interfaces {
+		var found bool
+		for _, nic := range i.Spec.Interfaces {
+			if nic.Name == name {
+				n.Members = append(n.Members, nic)
+				found = true
+				break
+			}
+		}
+		if !found {
+			return n, fmt.Errorf("inventory %s has no interface %s for management network", i.Name, name)
+		}
+	}
+
+	return n, nil
+}
+
+// prefixLength returns the prefix length of an IPv6 netmask in its expanded form, as recorded in the inventory status
+func prefixLength(netmask string) int {
+	ip := net.ParseIP(netmask)
+	if ip == nil {
+		return 0
+	}
+	ones, _ := net.IPMask(ip.To16()).Size()
+	return ones
+}
+
+// dhcpArch returns the architecture of the inventory in the format used by tinkerbell. Boots uses
+// the architecture to select the OSIE and installer artifacts served to the node
+func dhcpArch(i *seederv1alpha1.Inventory) string {
+	if util.Arch(i) == seederv1alpha1.ArchitectureARM64 {
+		return arm64Arch
+	}
+	return defaultArch
+}
+
 // generateMetaDataV10 is a wrapper to generate metadata for nodes to create or join a cluster
-func generateMetaDataV10(configURL, version, hwAddress, mode, disk, vip, token, password, imageurl string, Nameservers, SSHKeys []string) (metadata string, err error) {
+func generateMetaDataV10(configURL, version string, network managementNetwork, mode, disk, vip, vipv6, token, password, imageurl string, arch seederv1alpha1.Architecture, Nameservers, SSHKeys []string) (metadata string, err error) {
 
 	var tmpStruct struct {
 		ConfigURL   string
 		HWAddress   string
+		Network     managementNetwork
 		Mode        string
 		Disk        string
 		VIP         string
+		VIPv6       string
 		Token       string
 		SSHKeys     []string
 		Nameservers []string
@@ -110,22 +322,20 @@ func generateMetaDataV10(configURL, version, hwAddress, mode, disk, vip, token,
 	}
 	var output bytes.Buffer
 	tmpStruct.ConfigURL = configURL
-	tmpStruct.HWAddress = hwAddress
+	tmpStruct.HWAddress = network.HWAddress
+	tmpStruct.Network = network
 	tmpStruct.Mode = mode
 	tmpStruct.Disk = disk
 	tmpStruct.VIP = vip
+	tmpStruct.VIPv6 = vipv6
 	tmpStruct.Token = token
 	tmpStruct.Password = password
 	tmpStruct.SSHKeys = SSHKeys
 	tmpStruct.Nameservers = Nameservers
 	tmpStruct.Password = password
-	endpoint := defaultISOURL
-	if imageurl != "" {
-		endpoint = imageurl
-	}
-	tmpStruct.IsoURL = fmt.Sprintf("%s/%s/harvester-%s-amd64.iso", endpoint, version, version)
+	tmpStruct.IsoURL = util.GenerateISOURL(imageurl, version, arch)
 
-	var metaDataStruct = `{{ if ne .ConfigURL ""}}harvester.install.config_url={{ .ConfigURL }}{{end}} harvester.install.networks.harvester-mgmt.interfaces="hwAddr:{{ .HWAddress }}" ip=dhcp harvester.install.networks.harvester-mgmt.method=dhcp harvester.install.networks.harvester-mgmt.bond_options.mode=balance-tlb harvester.install.networks.harvester-mgmt.bond_options.miimon=100 console=ttyS1,115200  harvester.install.mode={{ .Mode }} harvester.token={{ .Token }} harvester.os.password={{ .Password }} {{ range $v := .SSHKeys}}harvester.os.ssh_authorized_keys=\"- {{ $v }} \ "{{ end }}{{range $v := .Nameservers}}harvester.os.dns_nameservers={{ $v }} {{end}} harvester.install.vip={{ .VIP }} harvester.install.vip_mode=static harvester.install.iso_url={{ .IsoURL }} harvester.install.device={{ .Disk }} {{if eq .Mode "join"}}harvester.server_url={{ printf "https://%s:8443" .VIP }}{{end}}`
+	var metaDataStruct = `{{ if ne .ConfigURL ""}}harvester.install.config_url={{ .ConfigURL }}{{end}} harvester.install.networks.harvester-mgmt.interfaces="hwAddr:{{ .HWAddress }}" {{ range $v := .Network.Members }}harvester.install.networks.harvester-mgmt.interfaces="hwAddr:{{ $v.MacAddress }}" {{ end }}ip=dhcp {{ if .Network.Static }}harvester.install.networks.harvester-mgmt.method=static harvester.install.networks.harvester-mgmt.ip={{ .Network.Address }} harvester.install.networks.harvester-mgmt.subnet_mask={{ .Network.Netmask }} harvester.install.networks.harvester-mgmt.gateway={{ .Network.Gateway }}{{ else }}harvester.install.networks.harvester-mgmt.method=dhcp{{ end }}{{ if .Network.IPv6Address }} harvester.install.networks.harvester-mgmt.ipv6={{ .Network.IPv6Address }} harvester.install.networks.harvester-mgmt.ipv6_prefix_length={{ .Network.IPv6PrefixLength }}{{ if .Network.IPv6Gateway }} harvester.install.networks.harvester-mgmt.ipv6_gateway={{ .Network.IPv6Gateway }}{{ end }}{{ end }} harvester.install.networks.harvester-mgmt.bond_options.mode={{ .Network.BondMode }} harvester.install.networks.harvester-mgmt.bond_options.miimon=100 {{ if .Network.MTU }}harvester.install.networks.harvester-mgmt.mtu={{ .Network.MTU }} {{ end }}{{ if .Network.VLANID }}harvester.install.networks.harvester-mgmt.vlan_id={{ .Network.VLANID }} {{ end }} console=ttyS1,115200  harvester.install.mode={{ .Mode }} harvester.token={{ .Token }} harvester.os.password={{ .Password }} {{ range $v := .SSHKeys}}harvester.os.ssh_authorized_keys=\"- {{ $v }} \ "{{ end }}{{range $v := .Nameservers}}harvester.os.dns_nameservers={{ $v }} {{end}} harvester.install.vip={{ .VIP }} harvester.install.vip_mode=static{{ if .VIPv6 }} harvester.install.vip_ipv6={{ .VIPv6 }}{{ end }} harvester.install.iso_url={{ .IsoURL }} harvester.install.device={{ .Disk }} {{if eq .Mode "join"}}harvester.server_url={{ printf "https://%s:8443" .VIP }}{{end}}`
 
 	metadataTmpl := template.Must(template.New("MetaData").Parse(metaDataStruct))
 
@@ -139,14 +349,16 @@ func generateMetaDataV10(configURL, version, hwAddress, mode, disk, vip, token,
 	return metadata, nil
 }
 
-func generateMetaDataV11(configURL, version, hwAddress, mode, disk, vip, token, password, imageurl string, Nameservers, SSHKeys []string) (metadata string, err error) {
+func generateMetaDataV11(configURL, version string, network managementNetwork, mode, disk, vip, vipv6, token, password, imageurl string, arch seederv1alpha1.Architecture, Nameservers, SSHKeys []string) (metadata string, err error) {
 
 	var tmpStruct struct {
 		ConfigURL   string
 		HWAddress   string
+		Network     managementNetwork
 		Mode        string
 		Disk        string
 		VIP         string
+		VIPv6       string
 		Token       string
 		SSHKeys     []string
 		Nameservers []string
@@ -155,22 +367,20 @@ func generateMetaDataV11(configURL, version, hwAddress, mode, disk, vip, token,
 	}
 	var output bytes.Buffer
 	tmpStruct.ConfigURL = configURL
-	tmpStruct.HWAddress = hwAddress
+	tmpStruct.HWAddress = network.HWAddress
+	tmpStruct.Network = network
 	tmpStruct.Mode = mode
 	tmpStruct.Disk = disk
 	tmpStruct.VIP = vip
+	tmpStruct.VIPv6 = vipv6
 	tmpStruct.Token = token
 	tmpStruct.Password = password
 	tmpStruct.SSHKeys = SSHKeys
 	tmpStruct.Nameservers = Nameservers
 	tmpStruct.Password = password
-	endpoint := defaultISOURL
-	if imageurl != "" {
-		endpoint = imageurl
-	}
-	tmpStruct.IsoURL = fmt.Sprintf("%s/%s/harvester-%s-amd64.iso", endpoint, version, version)
+	tmpStruct.IsoURL = util.GenerateISOURL(imageurl, version, arch)
 
-	var metaDataStruct = `{{ if ne .ConfigURL ""}}harvester.install.config_url={{ .ConfigURL }}{{end}} harvester.install.management_interface.interfaces="hwAddr:{{ .HWAddress }}" ip=dhcp harvester.install.management_interface.method=dhcp harvester.management_interface.bond_options.mode=balance-tlb harvester.install.management_interface.bond_options.miimon=100 console=ttyS1,115200  harvester.install.mode={{ .Mode }} harvester.token={{ .Token }} harvester.os.password={{ .Password }} {{ range $v := .SSHKeys}}harvester.os.ssh_authorized_keys=\"- {{ $v }} \ "{{ end }}{{range $v := .Nameservers}}harvester.os.dns_nameservers={{ $v }} {{end}} harvester.install.vip={{ .VIP }} harvester.install.vip_mode=static harvester.install.iso_url={{ .IsoURL }} harvester.install.device={{ .Disk }} {{if eq .Mode "join"}}harvester.server_url={{ printf "https://%s:443" .VIP }}{{end}} harvester.scheme_version=1`
+	var metaDataStruct = `{{ if ne .ConfigURL ""}}harvester.install.config_url={{ .ConfigURL }}{{end}} harvester.install.management_interface.interfaces="hwAddr:{{ .HWAddress }}" {{ range $v := .Network.Members }}harvester.install.management_interface.interfaces="hwAddr:{{ $v.MacAddress }}" {{ end }}ip=dhcp {{ if .Network.Static }}harvester.install.management_interface.method=static harvester.install.management_interface.ip={{ .Network.Address }} harvester.install.management_interface.subnet_mask={{ .Network.Netmask }} harvester.install.management_interface.gateway={{ .Network.Gateway }}{{ else }}harvester.install.management_interface.method=dhcp{{ end }}{{ if .Network.IPv6Address }} harvester.install.management_interface.ipv6={{ .Network.IPv6Address }} harvester.install.management_interface.ipv6_prefix_length={{ .Network.IPv6PrefixLength }}{{ if .Network.IPv6Gateway }} harvester.install.management_interface.ipv6_gateway={{ .Network.IPv6Gateway }}{{ end }}{{ end }} harvester.install.management_interface.bond_options.mode={{ .Network.BondMode }} harvester.install.management_interface.bond_options.miimon=100 {{ if .Network.MTU }}harvester.install.management_interface.mtu={{ .Network.MTU }} {{ end }}{{ if .Network.VLANID }}harvester.install.management_interface.vlan_id={{ .Network.VLANID }} {{ end }} console=ttyS1,115200  harvester.install.mode={{ .Mode }} harvester.token={{ .Token }} harvester.os.password={{ .Password }} {{ range $v := .SSHKeys}}harvester.os.ssh_authorized_keys=\"- {{ $v }} \ "{{ end }}{{range $v := .Nameservers}}harvester.os.dns_nameservers={{ $v }} {{end}} harvester.install.vip={{ .VIP }} harvester.install.vip_mode=static{{ if .VIPv6 }} harvester.install.vip_ipv6={{ .VIPv6 }}{{ end }} harvester.install.iso_url={{ .IsoURL }} harvester.install.device={{ .Disk }} {{if eq .Mode "join"}}harvester.server_url={{ printf "https://%s:443" .VIP }}{{end}} harvester.scheme_version=1`
 
 	metadataTmpl := template.Must(template.New("MetaData").Parse(metaDataStruct))
 
@@ -1,34 +1,53 @@
 package tink
 
 import (
+	"fmt"
 	"testing"
 
 	seederv1alpha1 "github.com/harvester/seeder/pkg/api/v1alpha1"
 	"github.com/harvester/seeder/pkg/util"
 	"github.com/stretchr/testify/require"
 	rufio "github.com/tinkerbell/rufio/api/v1alpha1"
+	tinkv1alpha1 "github.com/tinkerbell/tink/pkg/apis/core/v1alpha1"
 	v1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/yaml"
 )
 
 func Test_generateMetaDataV10(t *testing.T) {
 	assert := require.New(t)
-	m, err := generateMetaDataV10("http://localhost", "v1.0.1", "xx:xx:xx:xx:xx", "create",
-		"/dev/sda", "192.168.1.100", "token", "password", "v1.0.2", []string{"8.8.8.8"}, []string{"abc"})
+	m, err := generateMetaDataV10("http://localhost", "v1.0.1", managementNetwork{HWAddress: "xx:xx:xx:xx:xx"}, "create",
+		"/dev/sda", "192.168.1.100", "", "token", "password", "v1.0.2", seederv1alpha1.ArchitectureAMD64, []string{"8.8.8.8"}, []string{"abc"})
 	assert.NoError(err, "no error should have occured")
 	assert.Contains(m, "harvester.install.mode=create", "expected to find create mode in metadata")
 	assert.Contains(m, "hwAddr:xx:xx:xx:xx:xx", "expected to find mac address in metadata")
 	assert.NotContains(m, "scheme_version", "expected to not find scheme_version")
+	assert.NotContains(m, "ipv6", "expected no ipv6 settings for single-stack nodes")
+
+	network := managementNetwork{HWAddress: "xx:xx:xx:xx:xx", IPv6Address: "fd00:1::10", IPv6PrefixLength: 64, IPv6Gateway: "fd00:1::1"}
+	m, err = generateMetaDataV10("http://localhost", "v1.0.1", network, "join",
+		"/dev/sda", "192.168.1.100", "fd00:1::100", "token", "password", "v1.0.2", seederv1alpha1.ArchitectureAMD64, []string{"8.8.8.8"}, []string{"abc"})
+	assert.NoError(err, "no error should have occured")
+	assert.Contains(m, "harvester.install.networks.harvester-mgmt.ipv6=fd00:1::10 harvester.install.networks.harvester-mgmt.ipv6_prefix_length=64 harvester.install.networks.harvester-mgmt.ipv6_gateway=fd00:1::1", "expected ipv6 address in metadata")
+	assert.Contains(m, "harvester.install.vip_ipv6=fd00:1::100", "expected ipv6 vip in metadata")
 }
 
 func Test_generateMetaDataV11(t *testing.T) {
 	assert := require.New(t)
-	m, err := generateMetaDataV11("http://localhost", "v1.0.1", "xx:xx:xx:xx:xx", "create",
-		"/dev/sda", "192.168.1.100", "token", "password", "v1.0.2", []string{"8.8.8.8"}, []string{"abc"})
+	m, err := generateMetaDataV11("http://localhost", "v1.0.1", managementNetwork{HWAddress: "xx:xx:xx:xx:xx"}, "create",
+		"/dev/sda", "192.168.1.100", "", "token", "password", "v1.0.2", seederv1alpha1.ArchitectureAMD64, []string{"8.8.8.8"}, []string{"abc"})
 	assert.NoError(err, "no error should have occured")
 	assert.Contains(m, "harvester.install.mode=create", "expected to find create mode in metadata")
 	assert.Contains(m, "hwAddr:xx:xx:xx:xx:xx", "expected to find mac address in metadata")
 	assert.Contains(m, "scheme_version", "expected to find scheme_version")
+	assert.NotContains(m, "ipv6", "expected no ipv6 settings for single-stack nodes")
+
+	network := managementNetwork{HWAddress: "xx:xx:xx:xx:xx", IPv6Address: "fd00:1::10", IPv6PrefixLength: 64, IPv6Gateway: "fd00:1::1"}
+	m, err = generateMetaDataV11("http://localhost", "v1.0.1", network, "join",
+		"/dev/sda", "192.168.1.100", "fd00:1::100", "token", "password", "v1.0.2", seederv1alpha1.ArchitectureAMD64, []string{"8.8.8.8"}, []string{"abc"})
+	assert.NoError(err, "no error should have occured")
+	assert.Contains(m, "harvester.install.management_interface.ipv6=fd00:1::10 harvester.install.management_interface.ipv6_prefix_length=64 harvester.install.management_interface.ipv6_gateway=fd00:1::1", "expected ipv6 address in metadata")
+	assert.Contains(m, "harvester.install.vip_ipv6=fd00:1::100", "expected ipv6 vip in metadata")
 }
 
 var (
@@ -181,3 +200,296 @@ func Test_GenerateHWRequestWithJoinV11(t *testing.T) {
 	assert.NoError(err, "no error should occur during hardware generation")
 	assert.Contains(hw.Spec.Metadata.Instance.Userdata, "harvester.server_url=https://192.168.1.100", "expected to find join url")
 }
+
+func Test_GenerateHWRequestVirtualMedia(t *testing.T) {
+	assert := require.New(t)
+	hw, err := GenerateHWRequest(i, c)
+	assert.NoError(err, "no error should occur during hardware generation")
+	assert.True(*hw.Spec.Interfaces[0].Netboot.AllowPXE, "expected pxe boot to be allowed by default")
+
+	clusterCopy := c.DeepCopy()
+	clusterCopy.Spec.BootMethod = seederv1alpha1.BootMethodVirtualMedia
+	hw, err = GenerateHWRequest(i, clusterCopy)
+	assert.NoError(err, "no error should occur during hardware generation")
+	assert.False(*hw.Spec.Interfaces[0].Netboot.AllowPXE, "expected pxe boot to be disabled for virtual media boot")
+}
+
+func Test_GenerateHWRequestBootModeAndArch(t *testing.T) {
+	assert := require.New(t)
+	hw, err := GenerateHWRequest(i, c)
+	assert.NoError(err, "no error should occur during hardware generation")
+	assert.Equal("x86_64", hw.Spec.Interfaces[0].DHCP.Arch, "expected x86_64 by default")
+	assert.True(hw.Spec.Interfaces[0].DHCP.UEFI, "expected uefi by default")
+	assert.Contains(hw.Spec.Metadata.Instance.Userdata, "-amd64.iso", "expected amd64 iso")
+
+	inventoryCopy := i.DeepCopy()
+	inventoryCopy.Spec.Arch = seederv1alpha1.ArchitectureARM64
+	inventoryCopy.Spec.BootMode = seederv1alpha1.BootModeLegacy
+	hw, err = GenerateHWRequest(inventoryCopy, c)
+	assert.NoError(err, "no error should occur during hardware generation")
+	assert.Equal("aarch64", hw.Spec.Interfaces[0].DHCP.Arch, "expected aarch64 for arm64 inventory")
+	assert.False(hw.Spec.Interfaces[0].DHCP.UEFI, "expected legacy boot")
+	assert.Contains(hw.Spec.Metadata.Instance.Userdata, "-arm64.iso", "expected arm64 iso")
+}
+
+func Test_GenerateHWRequestStaticNetwork(t *testing.T) {
+	assert := require.New(t)
+	clusterCopy := c.DeepCopy()
+	clusterCopy.Spec.ManagementNetwork.Method = seederv1alpha1.NetworkMethodStatic
+	hw, err := GenerateHWRequest(i, clusterCopy)
+	assert.NoError(err, "no error should occur during hardware generation")
+	assert.Contains(hw.Spec.Metadata.Instance.Userdata, "harvester.install.networks.harvester-mgmt.method=static", "expected static method")
+	assert.Contains(hw.Spec.Metadata.Instance.Userdata, fmt.Sprintf("harvester.install.networks.harvester-mgmt.ip=%s", i.Status.Address), "expected static address")
+	assert.Contains(hw.Spec.Metadata.Instance.Userdata, fmt.Sprintf("harvester.install.networks.harvester-mgmt.subnet_mask=%s", i.Status.Netmask), "expected static netmask")
+	assert.Contains(hw.Spec.Metadata.Instance.Userdata, fmt.Sprintf("harvester.install.networks.harvester-mgmt.gateway=%s", i.Status.Gateway), "expected static gateway")
+	assert.NotContains(hw.Spec.Metadata.Instance.Userdata, "harvester-mgmt.method=dhcp", "expected dhcp method to be replaced")
+
+	clusterCopy.Spec.HarvesterVersion = "v1.1.0"
+	hw, err = GenerateHWRequest(i, clusterCopy)
+	assert.NoError(err, "no error should occur during hardware generation")
+	assert.Contains(hw.Spec.Metadata.Instance.Userdata, "harvester.install.management_interface.method=static", "expected static method")
+	assert.Contains(hw.Spec.Metadata.Instance.Userdata, fmt.Sprintf("harvester.install.management_interface.ip=%s", i.Status.Address), "expected static address")
+	assert.NotContains(hw.Spec.Metadata.Instance.Userdata, "management_interface.method=dhcp", "expected dhcp method to be replaced")
+
+	// static nodes need to be reinstalled to switch to static configuration
+	assert.NotEqual(GenerateInstallConfigHash(i, c), GenerateInstallConfigHash(i, clusterCopy), "expected network method to change the hash")
+}
+
+func Test_GenerateHWRequestBondedNetwork(t *testing.T) {
+	assert := require.New(t)
+	inventoryCopy := i.DeepCopy()
+	inventoryCopy.Spec.Interfaces = []seederv1alpha1.NetworkInterface{
+		{
+			Name:       "eth1",
+			MacAddress: "yy:yy:yy:yy:yy",
+		},
+	}
+	clusterCopy := c.DeepCopy()
+	clusterCopy.Spec.HarvesterVersion = "v1.1.0"
+	clusterCopy.Spec.ManagementNetwork = seederv1alpha1.ManagementNetwork{
+		Interfaces: []string{"eth1"},
+		BondMode:   "802.3ad",
+		MTU:        9000,
+		VLANID:     100,
+	}
+
+	hw, err := GenerateHWRequest(inventoryCopy, clusterCopy)
+	assert.NoError(err, "no error should occur during hardware generation")
+	assert.Len(hw.Spec.Interfaces, 2, "expected bond member to be added to hardware interfaces")
+	assert.Equal("yy:yy:yy:yy:yy", hw.Spec.Interfaces[1].DHCP.MAC, "expected bond member mac address")
+	assert.False(*hw.Spec.Interfaces[1].Netboot.AllowPXE, "expected bond member to not be netbooted")
+	assert.Nil(hw.Spec.Interfaces[1].DHCP.IP, "expected bond member to have no address")
+	assert.Contains(hw.Spec.Metadata.Instance.Userdata, "harvester.install.management_interface.interfaces=\"hwAddr:xx:xx:xx:xx:xx\"", "expected management interface in bond")
+	assert.Contains(hw.Spec.Metadata.Instance.Userdata, "harvester.install.management_interface.interfaces=\"hwAddr:yy:yy:yy:yy:yy\"", "expected bond member in bond")
+	assert.Contains(hw.Spec.Metadata.Instance.Userdata, "harvester.install.management_interface.bond_options.mode=802.3ad", "expected bond mode")
+	assert.Contains(hw.Spec.Metadata.Instance.Userdata, "harvester.install.management_interface.mtu=9000", "expected mtu")
+	assert.Contains(hw.Spec.Metadata.Instance.Userdata, "harvester.install.management_interface.vlan_id=100", "expected vlan id")
+
+	clusterCopy.Spec.HarvesterVersion = "v1.0.3"
+	hw, err = GenerateHWRequest(inventoryCopy, clusterCopy)
+	assert.NoError(err, "no error should occur during hardware generation")
+	assert.Contains(hw.Spec.Metadata.Instance.Userdata, "harvester.install.networks.harvester-mgmt.interfaces=\"hwAddr:yy:yy:yy:yy:yy\"", "expected bond member in bond")
+	assert.Contains(hw.Spec.Metadata.Instance.Userdata, "harvester.install.networks.harvester-mgmt.bond_options.mode=802.3ad", "expected bond mode")
+	assert.Contains(hw.Spec.Metadata.Instance.Userdata, "harvester.install.networks.harvester-mgmt.vlan_id=100", "expected vlan id")
+
+	// default bond mode is used when unset
+	hw, err = GenerateHWRequest(i, c)
+	assert.NoError(err, "no error should occur during hardware generation")
+	assert.Contains(hw.Spec.Metadata.Instance.Userdata, "bond_options.mode=balance-tlb", "expected default bond mode")
+	assert.NotContains(hw.Spec.Metadata.Instance.Userdata, "vlan_id", "expected no vlan id by default")
+
+	// bond members need to exist on the inventory
+	clusterCopy.Spec.ManagementNetwork.Interfaces = []string{"eth2"}
+	_, err = GenerateHWRequest(inventoryCopy, clusterCopy)
+	assert.Error(err, "expected error for missing bond member")
+}
+
+func Test_GenerateInstallConfigHash(t *testing.T) {
+	assert := require.New(t)
+	hash := GenerateInstallConfigHash(i, c)
+	assert.NotEmpty(hash, "expected hash to be generated")
+
+	clusterCopy := c.DeepCopy()
+	clusterCopy.Spec.HarvesterVersion = "v1.1.0"
+	clusterCopy.Spec.ImageURL = "http://localhost/iso"
+	assert.Equal(hash, GenerateInstallConfigHash(i, clusterCopy), "expected version and image changes to not change hash")
+
+	clusterCopy.Spec.ClusterConfig.SSHKeys = []string{"ghi"}
+	assert.NotEqual(hash, GenerateInstallConfigHash(i, clusterCopy), "expected ssh key change to change hash")
+
+	inventoryCopy := i.DeepCopy()
+	inventoryCopy.Spec.ManagementInterfaceMacAddress = "yy:yy:yy:yy:yy"
+	assert.NotEqual(hash, GenerateInstallConfigHash(inventoryCopy, c), "expected mac address change to change hash")
+
+	inventoryCopy = i.DeepCopy()
+	inventoryCopy.Spec.BootMode = seederv1alpha1.BootModeUEFI
+	inventoryCopy.Spec.Arch = seederv1alpha1.ArchitectureAMD64
+	assert.Equal(hash, GenerateInstallConfigHash(inventoryCopy, c), "expected default boot mode and arch to not change hash")
+
+	inventoryCopy.Spec.BootMode = seederv1alpha1.BootModeLegacy
+	legacyHash := GenerateInstallConfigHash(inventoryCopy, c)
+	assert.NotEqual(hash, legacyHash, "expected boot mode change to change hash")
+
+	inventoryCopy.Spec.Arch = seederv1alpha1.ArchitectureARM64
+	assert.NotEqual(legacyHash, GenerateInstallConfigHash(inventoryCopy, c), "expected arch change to change hash")
+
+	inventoryCopy = i.DeepCopy()
+	inventoryCopy.Spec.WorkflowTemplate = &seederv1alpha1.WorkflowTemplateReference{Name: "firmware"}
+	assert.NotEqual(hash, GenerateInstallConfigHash(inventoryCopy, c), "expected workflow template change to change hash")
+}
+
+func Test_GenerateWipeWorkflow(t *testing.T) {
+	assert := require.New(t)
+	tmpl := GenerateWipeTemplate(i)
+	assert.Equal("firstnode-wipe", tmpl.Name, "expected wipe template name")
+	assert.Contains(*tmpl.Spec.Data, "worker: \"{{.device_1}}\"", "expected worker to be substituted by tink")
+	assert.Contains(*tmpl.Spec.Data, "blkdiscard --secure /dev/sda", "expected primary disk to be wiped")
+
+	wf := GenerateWipeWorkflow(i)
+	assert.Equal(tmpl.Name, wf.Spec.TemplateRef, "expected workflow to reference wipe template")
+	assert.Equal(i.Spec.ManagementInterfaceMacAddress, wf.Spec.HardwareMap["device_1"], "expected workflow to target inventory")
+
+	hw := GenerateWipeHardware(i)
+	assert.True(*hw.Spec.Interfaces[0].Netboot.AllowWorkflow, "expected workflows to be allowed")
+	assert.Nil(hw.Spec.Metadata.Instance, "expected no install metadata")
+}
+
+func Test_GenerateWorkflow(t *testing.T) {
+	assert := require.New(t)
+	hw, err := GenerateHWRequest(i, c)
+	assert.NoError(err, "no error should occur during hardware generation")
+	assert.Nil(hw.Spec.Interfaces[0].Netboot.AllowWorkflow, "expected workflows to not be allowed without a template")
+
+	inventoryCopy := i.DeepCopy()
+	inventoryCopy.Spec.WorkflowTemplate = &seederv1alpha1.WorkflowTemplateReference{
+		Library: seederv1alpha1.ObjectReference{
+			Name:      "templates",
+			Namespace: "default",
+		},
+		Name: "firmware",
+	}
+	hw, err = GenerateHWRequest(inventoryCopy, c)
+	assert.NoError(err, "no error should occur during hardware generation")
+	assert.True(*hw.Spec.Interfaces[0].Netboot.AllowWorkflow, "expected workflows to be allowed with a template")
+
+	tmpl := GenerateTemplate(inventoryCopy, "version: \"0.1\"")
+	assert.Equal("firstnode-workflow", tmpl.Name)
+	wf := GenerateWorkflow(inventoryCopy, c)
+	assert.Equal(tmpl.Name, wf.Spec.TemplateRef, "expected workflow to reference template")
+	assert.Equal("/dev/sda", wf.Spec.HardwareMap["primary_disk"])
+	assert.Equal("https://releases.rancher.com/harvester/v1.0.1/harvester-v1.0.1-amd64.iso", wf.Spec.HardwareMap["iso_url"])
+
+	wf.Status.State = tinkv1alpha1.WorkflowStateFailed
+	wf.Status.Tasks = []tinkv1alpha1.Task{
+		{
+			Name: "firmware",
+			Actions: []tinkv1alpha1.Action{
+				{Name: "flash", Status: tinkv1alpha1.WorkflowStateFailed, Message: "flash failed"},
+			},
+		},
+	}
+	assert.Equal("action flash in state STATE_FAILED: flash failed", WorkflowMessage(wf))
+}
+
+func Test_GenerateHWRequestDualStack(t *testing.T) {
+	assert := require.New(t)
+	inventoryCopy := i.DeepCopy()
+	inventoryCopy.Status.IPv6Address = "fd00:1::10"
+	inventoryCopy.Status.IPv6Netmask = "ffff:ffff:ffff:ffff::"
+	inventoryCopy.Status.IPv6Gateway = "fd00:1::1"
+
+	hw, err := GenerateHWRequest(i, c)
+	assert.NoError(err, "no error should occur during hardware generation")
+	assert.Empty(hw.Spec.Metadata.Instance.Ips, "expected no instance addresses for single-stack nodes")
+
+	hw, err = GenerateHWRequest(inventoryCopy, c)
+	assert.NoError(err, "no error should occur during hardware generation")
+	assert.Len(hw.Spec.Metadata.Instance.Ips, 2, "expected both management addresses in instance metadata")
+	assert.Equal(int64(4), hw.Spec.Metadata.Instance.Ips[0].Family)
+	assert.Equal(i.Status.Address, hw.Spec.Metadata.Instance.Ips[0].Address)
+	assert.Equal(int64(6), hw.Spec.Metadata.Instance.Ips[1].Family)
+	assert.Equal("fd00:1::10", hw.Spec.Metadata.Instance.Ips[1].Address)
+	assert.Equal("fd00:1::1", hw.Spec.Metadata.Instance.Ips[1].Gateway)
+	assert.True(hw.Spec.Metadata.Instance.Ips[1].Management, "expected ipv6 address to be a management address")
+	assert.Equal(i.Status.Address, hw.Spec.Interfaces[0].DHCP.IP.Address, "expected dhcp to serve the ipv4 address")
+
+	assert.Equal("fd00:1::10", GenerateWorkflow(inventoryCopy, c).Spec.HardwareMap["ipv6_address"])
+	assert.NotEqual(GenerateInstallConfigHash(i, c), GenerateInstallConfigHash(inventoryCopy, c), "expected ipv6 address to change the hash")
+
+	clusterCopy := c.DeepCopy()
+	clusterCopy.Status.ClusterIPv6Address = "fd00:1::100"
+	assert.NotEqual(GenerateInstallConfigHash(i, c), GenerateInstallConfigHash(i, clusterCopy), "expected ipv6 vip to change the hash")
+
+	hw, err = GenerateHWRequest(inventoryCopy, clusterCopy)
+	assert.NoError(err, "no error should occur during hardware generation")
+	assert.Contains(hw.Spec.Metadata.Instance.Userdata, "ipv6=fd00:1::10 ", "expected ipv6 address in kernel arguments")
+	assert.Contains(hw.Spec.Metadata.Instance.Userdata, "ipv6_prefix_length=64 ", "expected ipv6 prefix length in kernel arguments")
+	assert.Contains(hw.Spec.Metadata.Instance.Userdata, "harvester.install.vip_ipv6=fd00:1::100", "expected ipv6 vip in kernel arguments")
+
+	out, err := GenerateInstallConfig(inventoryCopy, clusterCopy)
+	assert.NoError(err, "no error should occur during install config generation")
+	config := &harvesterConfig{}
+	assert.NoError(yaml.Unmarshal(out, config), "expected install config to be valid yaml")
+	assert.Equal("fd00:1::100", config.Install.VIPv6, "expected ipv6 vip in install config")
+	mgmt := config.Install.Networks["harvester-mgmt"]
+	assert.Equal("fd00:1::10", mgmt.IPv6, "expected ipv6 address in install config")
+	assert.Equal(64, mgmt.IPv6PrefixLength, "expected ipv6 prefix length in install config")
+	assert.Equal("fd00:1::1", mgmt.IPv6Gateway, "expected ipv6 gateway in install config")
+}
+
+func Test_GenerateInstallConfig(t *testing.T) {
+	assert := require.New(t)
+	createNode := i.DeepCopy()
+	createNode.Status.Conditions = util.CreateOrUpdateCondition(nil, seederv1alpha1.HarvesterCreateNode, "")
+	out, err := GenerateInstallConfig(createNode, c)
+	assert.NoError(err, "no error should occur during install config generation")
+	config := &harvesterConfig{}
+	assert.NoError(yaml.Unmarshal(out, config), "expected install config to be valid yaml")
+	assert.True(config.Install.Automatic, "expected node to be installed automatically")
+	assert.Equal("create", config.Install.Mode, "expected create mode")
+	assert.Empty(config.ServerURL, "expected no server url for create mode")
+	assert.Equal(c.Status.ClusterAddress, config.Install.VIP, "expected cluster vip")
+	assert.Equal(c.Spec.ConfigURL, config.Install.ConfigURL, "expected config url")
+	assert.Equal(i.Status.GeneratedPassword, config.OS.Password, "expected generated password")
+	assert.Equal("xx:xx:xx:xx:xx", config.Install.Networks["harvester-mgmt"].Interfaces[0].HWAddr, "expected v1.0 management network")
+	assert.Nil(config.Install.ManagementInterface, "expected no v1.1 management interface")
+
+	inventoryCopy := i.DeepCopy()
+	inventoryCopy.Status.Conditions = nil
+	clusterCopy := c.DeepCopy()
+	clusterCopy.Spec.HarvesterVersion = "v1.1.0"
+	clusterCopy.Spec.ManagementNetwork.Method = seederv1alpha1.NetworkMethodStatic
+	out, err = GenerateInstallConfig(inventoryCopy, clusterCopy)
+	assert.NoError(err, "no error should occur during install config generation")
+	config = &harvesterConfig{}
+	assert.NoError(yaml.Unmarshal(out, config), "expected install config to be valid yaml")
+	assert.Equal("join", config.Install.Mode, "expected join mode")
+	assert.Equal(1, config.SchemeVersion, "expected scheme version for v1.1")
+	assert.Equal("https://192.168.1.100:443", config.ServerURL, "expected server url for join mode")
+	assert.NotNil(config.Install.ManagementInterface, "expected v1.1 management interface")
+	assert.Equal("static", config.Install.ManagementInterface.Method, "expected static method")
+	assert.Equal(i.Status.Address, config.Install.ManagementInterface.IP, "expected static address")
+}
+
+func Test_GeneratePostInstallWorkflow(t *testing.T) {
+	assert := require.New(t)
+	inventoryCopy := i.DeepCopy()
+	inventoryCopy.Spec.PostInstallWorkflowTemplate = &seederv1alpha1.WorkflowTemplateReference{
+		Library: seederv1alpha1.ObjectReference{
+			Name:      "templates",
+			Namespace: "default",
+		},
+		Name: "register",
+	}
+	hw, err := GenerateHWRequest(inventoryCopy, c)
+	assert.NoError(err, "no error should occur during hardware generation")
+	assert.True(*hw.Spec.Interfaces[0].Netboot.AllowWorkflow, "expected workflows to be allowed with a post-install template")
+
+	tmpl := GeneratePostInstallTemplate(inventoryCopy, "version: \"0.1\"")
+	assert.Equal("firstnode-post-install", tmpl.Name)
+	assert.Equal("version: \"0.1\"", *tmpl.Spec.Data)
+	wf := GeneratePostInstallWorkflow(inventoryCopy, c)
+	assert.Equal(tmpl.Name, wf.Name)
+	assert.Equal(tmpl.Name, wf.Spec.TemplateRef, "expected workflow to reference post-install template")
+	assert.Equal(GenerateWorkflow(inventoryCopy, c).Spec.HardwareMap, wf.Spec.HardwareMap, "expected same hardware map as pre-install workflow")
+}
@@ -0,0 +1,103 @@
+package tink
+
+import (
+	"fmt"
+
+	seederv1alpha1 "github.com/harvester/seeder/pkg/api/v1alpha1"
+	"github.com/harvester/seeder/pkg/util"
+	tinkv1alpha1 "github.com/tinkerbell/tink/pkg/apis/core/v1alpha1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const (
+	wipeImage   = "alpine:3.16"
+	wipeTimeout = 7200
+	// wipeTemplate is rendered by tink-server, so the worker reference is left for tink to substitute
+	wipeTemplate = `version: "0.1"
+name: %s
+global_timeout: %d
+tasks:
+  - name: "wipe-disks"
+    worker: "{{.device_1}}"
+    volumes:
+      - /dev:/dev
+    actions:
+      - name: "wipe-primary-disk"
+        image: %s
+        timeout: %d
+        command:
+          - /bin/sh
+          - -c
+          - "apk add --no-cache util-linux && (blkdiscard --secure %s || shred -n 1 -z %s) && wipefs -a %s"
+`
+)
+
+// WipeName is the name of the tink Template, Workflow and BMCJob used to wipe an inventory
+func WipeName(i *seederv1alpha1.Inventory) string {
+	return fmt.Sprintf("%s-wipe", i.Name)
+}
+
+// GenerateWipeHardware will generate a tinkerbell Hardware object which allows the node to
+// boot into the tinkerbell OSIE and run the wipe workflow
+func GenerateWipeHardware(i *seederv1alpha1.Inventory) *tinkv1alpha1.Hardware {
+	return &tinkv1alpha1.Hardware{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      i.Name,
+			Namespace: i.Namespace,
+		},
+		Spec: tinkv1alpha1.HardwareSpec{
+			Interfaces: []tinkv1alpha1.Interface{
+				{
+					Netboot: &tinkv1alpha1.Netboot{
+						AllowPXE:      &[]bool{true}[0],
+						AllowWorkflow: &[]bool{true}[0],
+					},
+					DHCP: &tinkv1alpha1.DHCP{
+						MAC:       i.Spec.ManagementInterfaceMacAddress,
+						Hostname:  fmt.Sprintf("%s-%s", i.Name, i.Namespace),
+						LeaseTime: defaultLeaseTime,
+						Arch:      dhcpArch(i),
+						UEFI:      util.IsUEFI(i),
+						IP: &tinkv1alpha1.IP{
+							Address: i.Status.Address,
+							Netmask: i.Status.Netmask,
+							Gateway: i.Status.Gateway,
+						},
+					},
+				},
+			},
+			Disks: []tinkv1alpha1.Disk{
+				{
+					Device: i.Spec.PrimaryDisk,
+				},
+			},
+			Metadata: &tinkv1alpha1.HardwareMetadata{
+				Facility: &tinkv1alpha1.MetadataFacility{
+					FacilityCode: defaultFacilityCode,
+				},
+			},
+		},
+	}
+}
+
+// GenerateWipeTemplate will generate the tinkerbell Template which securely erases the primary disk
+func GenerateWipeTemplate(i *seederv1alpha1.Inventory) *tinkv1alpha1.Template {
+	disk := i.Spec.PrimaryDisk
+	data := fmt.Sprintf(wipeTemplate, WipeName(i), wipeTimeout, wipeImage, wipeTimeout, disk, disk, disk)
+	return &tinkv1alpha1.Template{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      WipeName(i),
+			Namespace: i.Namespace,
+		},
+		Spec: tinkv1alpha1.TemplateSpec{
+			Data: &data,
+		},
+	}
+}
+
+// GenerateWipeWorkflow will generate the tinkerbell Workflow which runs the wipe template on the inventory
+func GenerateWipeWorkflow(i *seederv1alpha1.Inventory) *tinkv1alpha1.Workflow {
+	return generateWorkflow(i, WipeName(i), map[string]string{
+		"device_1": i.Spec.ManagementInterfaceMacAddress,
+	})
+}
@@ -0,0 +1,93 @@
+package tink
+
+import (
+	"fmt"
+
+	seederv1alpha1 "github.com/harvester/seeder/pkg/api/v1alpha1"
+	"github.com/harvester/seeder/pkg/util"
+	tinkv1alpha1 "github.com/tinkerbell/tink/pkg/apis/core/v1alpha1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// WorkflowName is the name of the tink Template and Workflow generated from the inventory workflow template
+func WorkflowName(i *seederv1alpha1.Inventory) string {
+	return fmt.Sprintf("%s-workflow", i.Name)
+}
+
+// PostInstallWorkflowName is the name of the tink Template, Workflow and BMCJob generated from the inventory
+// post-install workflow template
+func PostInstallWorkflowName(i *seederv1alpha1.Inventory) string {
+	return fmt.Sprintf("%s-post-install", i.Name)
+}
+
+// GenerateTemplate will generate a tinkerbell Template for the inventory using the template data from the library
+func GenerateTemplate(i *seederv1alpha1.Inventory, data string) *tinkv1alpha1.Template {
+	return generateTemplate(i, WorkflowName(i), data)
+}
+
+// GeneratePostInstallTemplate will generate a tinkerbell Template for the inventory using the post-install
+// template data from the library
+func GeneratePostInstallTemplate(i *seederv1alpha1.Inventory, data string) *tinkv1alpha1.Template {
+	return generateTemplate(i, PostInstallWorkflowName(i), data)
+}
+
+// GenerateWorkflow will generate a tinkerbell Workflow which runs the inventory template. Inventory and cluster
+// details are passed in the hardware map, and can be referenced in templates, for example {{.primary_disk}}
+func GenerateWorkflow(i *seederv1alpha1.Inventory, c *seederv1alpha1.Cluster) *tinkv1alpha1.Workflow {
+	return generateWorkflow(i, WorkflowName(i), workflowHardwareMap(i, c))
+}
+
+// GeneratePostInstallWorkflow will generate a tinkerbell Workflow which runs the inventory post-install template,
+// with the same hardware map as the workflow run before the install
+func GeneratePostInstallWorkflow(i *seederv1alpha1.Inventory, c *seederv1alpha1.Cluster) *tinkv1alpha1.Workflow {
+	return generateWorkflow(i, PostInstallWorkflowName(i), workflowHardwareMap(i, c))
+}
+
+// WorkflowMessage returns the message of the first failed action in a workflow
+func WorkflowMessage(wf *tinkv1alpha1.Workflow) string {
+	for _, task := range wf.Status.Tasks {
+		for _, action := range task.Actions {
+			if action.Status == tinkv1alpha1.WorkflowStateFailed || action.Status == tinkv1alpha1.WorkflowStateTimeout {
+				return fmt.Sprintf("action %s in state %s: %s", action.Name, action.Status, action.Message)
+			}
+		}
+	}
+	return fmt.Sprintf("workflow in state %s", wf.Status.State)
+}
+
+func workflowHardwareMap(i *seederv1alpha1.Inventory, c *seederv1alpha1.Cluster) map[string]string {
+	return map[string]string{
+		"device_1":          i.Spec.ManagementInterfaceMacAddress,
+		"primary_disk":      i.Spec.PrimaryDisk,
+		"address":           i.Status.Address,
+		"ipv6_address":      i.Status.IPv6Address,
+		"harvester_version": c.Spec.HarvesterVersion,
+		"iso_url":           util.GenerateISOURL(c.Spec.ImageURL, c.Spec.HarvesterVersion, util.Arch(i)),
+		"arch":              string(util.Arch(i)),
+	}
+}
+
+func generateTemplate(i *seederv1alpha1.Inventory, name string, data string) *tinkv1alpha1.Template {
+	return &tinkv1alpha1.Template{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: i.Namespace,
+		},
+		Spec: tinkv1alpha1.TemplateSpec{
+			Data: &data,
+		},
+	}
+}
+
+func generateWorkflow(i *seederv1alpha1.Inventory, name string, hardwareMap map[string]string) *tinkv1alpha1.Workflow {
+	return &tinkv1alpha1.Workflow{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: i.Namespace,
+		},
+		Spec: tinkv1alpha1.WorkflowSpec{
+			TemplateRef: name,
+			HardwareMap: hardwareMap,
+		},
+	}
+}
@@ -15,6 +15,26 @@ func ConditionExists(conditions []seederv1alpha1.Conditions, t seederv1alpha1.Co
 	return false
 }
 
+// ConditionMessage returns the message of the named condition, or an empty string if the condition does not exist
+func ConditionMessage(conditions []seederv1alpha1.Conditions, t seederv1alpha1.ConditionType) string {
+	for _, v := range conditions {
+		if v.Type == t {
+			return v.Message
+		}
+	}
+	return ""
+}
+
+// ConditionStartTime returns the start time of the named condition, and false if the condition does not exist
+func ConditionStartTime(conditions []seederv1alpha1.Conditions, t seederv1alpha1.ConditionType) (metav1.Time, bool) {
+	for _, v := range conditions {
+		if v.Type == t {
+			return v.StartTime, true
+		}
+	}
+	return metav1.Time{}, false
+}
+
 //RemoveCondition removes the named condition
 func RemoveCondition(conditions []seederv1alpha1.Conditions, t seederv1alpha1.ConditionType) []seederv1alpha1.Conditions {
 	var retConditions []seederv1alpha1.Conditions
@@ -30,6 +30,15 @@ func Test_ConditionsExist_False(t *testing.T) {
 	assert.False(ok, "expected condition to be not found")
 }
 
+func Test_ConditionStartTime(t *testing.T) {
+	assert := require.New(t)
+	startTime, ok := ConditionStartTime(testConditionData, seederv1alpha1.BMCObjectCreated)
+	assert.True(ok, "expected condition to be found")
+	assert.Equal(testConditionData[0].StartTime, startTime, "expected start time of condition")
+	_, ok = ConditionStartTime(testConditionData, seederv1alpha1.BMCJobSubmitted)
+	assert.False(ok, "expected condition to be not found")
+}
+
 func Test_RemoveCondition(t *testing.T) {
 	assert := require.New(t)
 	newConditions := RemoveCondition(testConditionData, seederv1alpha1.BMCObjectCreated)
@@ -109,3 +109,71 @@ func ListInventoryAllocatedtoCluster(ctx context.Context, c client.Client, clust
 
 	return retItems, nil
 }
+
+// BootMethod returns the method used to boot an inventory into the installer. The inventory
+// bootMethod takes precedence over the cluster bootMethod
+func BootMethod(i *seederv1alpha1.Inventory, c *seederv1alpha1.Cluster) seederv1alpha1.BootMethod {
+	if i.Spec.BootMethod != "" {
+		return i.Spec.BootMethod
+	}
+	if c != nil && c.Spec.BootMethod != "" {
+		return c.Spec.BootMethod
+	}
+	return seederv1alpha1.BootMethodPXE
+}
+
+// IsUEFI checks if the inventory boots using UEFI, which is the default boot mode
+func IsUEFI(i *seederv1alpha1.Inventory) bool {
+	return i.Spec.BootMode != seederv1alpha1.BootModeLegacy
+}
+
+// Arch returns the CPU architecture of the inventory, defaulting to amd64
+func Arch(i *seederv1alpha1.Inventory) seederv1alpha1.Architecture {
+	if i.Spec.Arch != "" {
+		return i.Spec.Arch
+	}
+	return seederv1alpha1.ArchitectureAMD64
+}
+
+// RedfishEndpoint returns the redfish endpoint of the inventory BMC. The port can be overridden
+// using the redfish port label
+func RedfishEndpoint(i *seederv1alpha1.Inventory) string {
+	if port, ok := i.Labels[seederv1alpha1.OverrideRedfishPortLabel]; ok {
+		return fmt.Sprintf("https://%s:%s", i.Spec.BaseboardManagementSpec.Connection.Host, port)
+	}
+	return fmt.Sprintf("https://%s", i.Spec.BaseboardManagementSpec.Connection.Host)
+}
+
+// FetchBMCCredentials looks up the username and password from the BMC secret of the inventory
+func FetchBMCCredentials(ctx context.Context, c client.Client, i *seederv1alpha1.Inventory) (username, password string, err error) {
+	s := &v1.Secret{}
+	err = c.Get(ctx, types.NamespacedName{Namespace: i.Spec.BaseboardManagementSpec.Connection.AuthSecretRef.Namespace,
+		Name: i.Spec.BaseboardManagementSpec.Connection.AuthSecretRef.Name}, s)
+	if err != nil {
+		return "", "", err
+	}
+
+	u, ok := s.Data["username"]
+	if !ok {
+		return "", "", fmt.Errorf("secret %s has no key username", s.Name)
+	}
+	p, ok := s.Data["password"]
+	if !ok {
+		return "", "", fmt.Errorf("secret %s has no key password", s.Name)
+	}
+	return string(u), string(p), nil
+}
+
+// FetchWorkflowTemplate looks up the data of a tinkerbell template in its template library
+func FetchWorkflowTemplate(ctx context.Context, c client.Client, ref *seederv1alpha1.WorkflowTemplateReference) (string, error) {
+	library := &v1.ConfigMap{}
+	if err := c.Get(ctx, types.NamespacedName{Namespace: ref.Library.Namespace, Name: ref.Library.Name}, library); err != nil {
+		return "", fmt.Errorf("error looking up template library %s/%s: %v", ref.Library.Namespace, ref.Library.Name, err)
+	}
+
+	data, ok := library.Data[ref.Name]
+	if !ok {
+		return "", fmt.Errorf("template %s not found in template library %s/%s", ref.Name, ref.Library.Namespace, ref.Library.Name)
+	}
+	return data, nil
+}
@@ -102,3 +102,52 @@ func Test_CheckAndCreateBaseBoardObjectFailure(t *testing.T) {
 	err = CheckAndCreateBaseBoardObject(ctx, c, l, i, c.Scheme())
 	assert.Equal(nil, err, "error creating baseboard object")
 }
+
+// Test_BootMethod tests the inventory bootMethod overrides the cluster bootMethod
+func Test_BootMethod(t *testing.T) {
+	assert := require.New(t)
+	i := &seederv1alpha1.Inventory{}
+	c := &seederv1alpha1.Cluster{}
+
+	assert.Equal(seederv1alpha1.BootMethodPXE, BootMethod(i, c), "expected pxe boot by default")
+	assert.Equal(seederv1alpha1.BootMethodPXE, BootMethod(i, nil), "expected pxe boot without a cluster")
+
+	c.Spec.BootMethod = seederv1alpha1.BootMethodVirtualMedia
+	assert.Equal(seederv1alpha1.BootMethodVirtualMedia, BootMethod(i, c), "expected cluster bootMethod to be used")
+
+	i.Spec.BootMethod = seederv1alpha1.BootMethodPXE
+	assert.Equal(seederv1alpha1.BootMethodPXE, BootMethod(i, c), "expected inventory bootMethod to take precedence")
+}
+
+// Test_RedfishEndpoint tests the redfish port override label
+func Test_RedfishEndpoint(t *testing.T) {
+	assert := require.New(t)
+	i := &seederv1alpha1.Inventory{
+		Spec: seederv1alpha1.InventorySpec{
+			BaseboardManagementSpec: rufio.BaseboardManagementSpec{
+				Connection: rufio.Connection{
+					Host: "172.16.1.52",
+				},
+			},
+		},
+	}
+
+	assert.Equal("https://172.16.1.52", RedfishEndpoint(i), "expected default redfish endpoint")
+	i.Labels = map[string]string{
+		seederv1alpha1.OverrideRedfishPortLabel: "8443",
+	}
+	assert.Equal("https://172.16.1.52:8443", RedfishEndpoint(i), "expected redfish port override")
+}
+
+// Test_BootModeAndArch tests the defaults for inventory boot mode and architecture
+func Test_BootModeAndArch(t *testing.T) {
+	assert := require.New(t)
+	i := &seederv1alpha1.Inventory{}
+	assert.True(IsUEFI(i), "expected uefi boot by default")
+	assert.Equal(seederv1alpha1.ArchitectureAMD64, Arch(i), "expected amd64 by default")
+
+	i.Spec.BootMode = seederv1alpha1.BootModeLegacy
+	i.Spec.Arch = seederv1alpha1.ArchitectureARM64
+	assert.False(IsUEFI(i), "expected legacy boot")
+	assert.Equal(seederv1alpha1.ArchitectureARM64, Arch(i), "expected arm64")
+}
@@ -3,8 +3,17 @@ package util
 import (
 	"context"
 	"fmt"
+	"math"
+	"math/big"
+	"net"
+	"sort"
+	"strings"
+	"time"
+
 	seederv1alpha1 "github.com/harvester/seeder/pkg/api/v1alpha1"
 	"inet.af/netaddr"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 )
 
@@ -16,18 +25,19 @@ func GenerateAddressPoolStatus(pool *seederv1alpha1.AddressPool) (poolStatus *se
 		return nil, err
 	}
 
-	ipRange := ipPrefix.Range()
-	len, err := availableAddresses(ipRange, pool.Spec.Gateway)
+	ipSet, ipRange, err := allocatableAddresses(pool)
 	if err != nil {
 		return nil, err
 	}
 	poolStatus.StartAddress = ipRange.From().String()
 	poolStatus.LastAddress = ipRange.To().String()
-	poolStatus.AvailableAddresses = len
+	poolStatus.AvailableAddresses = countAddresses(ipSet)
 	if pool.Spec.Netmask != "" {
 		poolStatus.Netmask = pool.Spec.Netmask
 	} else {
-		poolStatus.Netmask = ipPrefix.IPNet().Mask.String()
+		// netmask is rendered in dotted form, as expected by DHCP and the Harvester install config.
+		// IPv6 masks are rendered in their expanded form, as in the hardware metadata
+		poolStatus.Netmask = net.IP(ipPrefix.IPNet().Mask).String()
 	}
 
 	poolStatus.Status = seederv1alpha1.PoolReady
@@ -35,24 +45,132 @@ func GenerateAddressPoolStatus(pool *seederv1alpha1.AddressPool) (poolStatus *se
 	return poolStatus, nil
 }
 
-// availableAddresses finds all address available in ip range, and excludes gateway if needed
-func availableAddresses(ipRange netaddr.IPRange, gateway string) (len int, err error) {
-	gw, err := netaddr.ParseIP(gateway)
+// allocatableAddresses returns the set of addresses which can be allocated from the pool, along with the range
+// of the pool. The range defaults to the usable addresses of the CIDR, which excludes the network and broadcast
+// addresses of IPv4 subnets and the subnet-router anycast address of IPv6 subnets. The gateway, excluded
+// and reserved addresses are removed from the range
+func allocatableAddresses(pool *seederv1alpha1.AddressPool) (*netaddr.IPSet, netaddr.IPRange, error) {
+	ipPrefix, err := netaddr.ParseIPPrefix(pool.Spec.CIDR)
 	if err != nil {
-		return len, err
+		return nil, netaddr.IPRange{}, err
 	}
-	for ip := ipRange.From(); ipRange.Contains(ip); ip = ip.Next() {
-		if ip == gw {
-			continue
+	ipPrefix = ipPrefix.Masked()
+	cidrRange := ipPrefix.Range()
+
+	from, to := cidrRange.From(), cidrRange.To()
+	if ipPrefix.IP().Is4() && ipPrefix.Bits() < 31 {
+		from, to = from.Next(), to.Prior()
+	}
+	if ipPrefix.IP().Is6() && ipPrefix.Bits() < 127 {
+		from = from.Next()
+	}
+
+	if pool.Spec.StartAddress != "" {
+		if from, err = parseAddressInRange(pool.Spec.StartAddress, cidrRange); err != nil {
+			return nil, netaddr.IPRange{}, err
+		}
+	}
+	if pool.Spec.EndAddress != "" {
+		if to, err = parseAddressInRange(pool.Spec.EndAddress, cidrRange); err != nil {
+			return nil, netaddr.IPRange{}, err
+		}
+	}
+
+	ipRange, err := netaddr.ParseIPRange(fmt.Sprintf("%s-%s", from, to))
+	if err != nil {
+		return nil, netaddr.IPRange{}, fmt.Errorf("invalid address range for pool %s: %v", pool.Name, err)
+	}
+
+	gw, err := netaddr.ParseIP(pool.Spec.Gateway)
+	if err != nil {
+		return nil, netaddr.IPRange{}, err
+	}
+	if gw.Is4() != ipPrefix.IP().Is4() {
+		return nil, netaddr.IPRange{}, fmt.Errorf("gateway %s is not in the same address family as cidr %s", pool.Spec.Gateway, pool.Spec.CIDR)
+	}
+
+	var b netaddr.IPSetBuilder
+	b.AddRange(ipRange)
+	b.Remove(gw)
+
+	for _, v := range pool.Spec.ExcludedAddresses {
+		switch {
+		case strings.Contains(v, "/"):
+			p, err := netaddr.ParseIPPrefix(v)
+			if err != nil {
+				return nil, netaddr.IPRange{}, err
+			}
+			b.RemovePrefix(p)
+		case strings.Contains(v, "-"):
+			r, err := netaddr.ParseIPRange(v)
+			if err != nil {
+				return nil, netaddr.IPRange{}, err
+			}
+			b.RemoveRange(r)
+		default:
+			ip, err := netaddr.ParseIP(v)
+			if err != nil {
+				return nil, netaddr.IPRange{}, err
+			}
+			b.Remove(ip)
 		}
-		len++
 	}
 
-	return len, nil
+	for _, v := range pool.Spec.Reservations {
+		ip, err := parseAddressInRange(v.Address, cidrRange)
+		if err != nil {
+			return nil, netaddr.IPRange{}, fmt.Errorf("invalid reservation %s: %v", v.Name, err)
+		}
+		b.Remove(ip)
+	}
+
+	ipSet, err := b.IPSet()
+	if err != nil {
+		return nil, netaddr.IPRange{}, err
+	}
+	return ipSet, ipRange, nil
+}
+
+// parseAddressInRange parses address and ensures it is part of ipRange
+func parseAddressInRange(address string, ipRange netaddr.IPRange) (netaddr.IP, error) {
+	ip, err := netaddr.ParseIP(address)
+	if err != nil {
+		return ip, err
+	}
+	if !ipRange.Contains(ip) {
+		return ip, fmt.Errorf("address %s is not in range %s", address, ipRange)
+	}
+	return ip, nil
+}
+
+// countAddresses counts the addresses in the set. The count is computed rather than enumerated, as IPv6
+// ranges are too large to walk, and is capped at math.MaxInt32
+func countAddresses(ipSet *netaddr.IPSet) int {
+	size := new(big.Int)
+	for _, r := range ipSet.Ranges() {
+		from, to := r.From().As16(), r.To().As16()
+		size.Add(size, new(big.Int).Sub(new(big.Int).SetBytes(to[:]), new(big.Int).SetBytes(from[:])))
+		size.Add(size, big.NewInt(1))
+	}
+
+	if size.Cmp(big.NewInt(math.MaxInt32)) > 0 {
+		return math.MaxInt32
+	}
+	return int(size.Int64())
 }
 
-// AllocateAddress will allocate a custom Address or a dynamic address if address string is empty
-func AllocateAddress(poolStatus *seederv1alpha1.AddressStatus, address string) (string, error) {
+// AllocateAddress will allocate a custom Address or a dynamic address if address string is empty.
+// Dynamic allocation returns the first free address in the pool, so it only walks as many addresses
+// as have already been allocated
+func AllocateAddress(pool *seederv1alpha1.AddressPool, address string) (string, error) {
+	return AllocateAddressForConsumer(pool, address, nil)
+}
+
+// AllocateAddressForConsumer allocates an address to consumer. Unless a custom address is requested, inventory
+// is allocated the address pinned to it, or the address it last held while it is retained by the pool, before
+// a dynamic address. Addresses retained for other inventory are only allocated once the pool is otherwise exhausted
+func AllocateAddressForConsumer(pool *seederv1alpha1.AddressPool, address string, consumer *seederv1alpha1.ObjectReferenceWithKind) (string, error) {
+	poolStatus := pool.Status
 
 	if len(poolStatus.AddressAllocation) != 0 {
 		node, ok := poolStatus.AddressAllocation[address]
@@ -61,25 +179,195 @@ func AllocateAddress(poolStatus *seederv1alpha1.AddressStatus, address string) (
 		}
 	}
 
-	ipRange, err := netaddr.ParseIPRange(fmt.Sprintf("%s-%s", poolStatus.StartAddress, poolStatus.LastAddress))
+	ipSet, _, err := allocatableAddresses(pool)
 	if err != nil {
 		return "", err
 	}
-	for ip := ipRange.From(); ipRange.Contains(ip); ip = ip.Next() {
-		if len(poolStatus.AddressAllocation) != 0 {
-			_, ok := poolStatus.AddressAllocation[ip.String()]
-			if ok {
-				continue
+
+	pinned := pinnedAddress(pool, consumer)
+
+	if address != "" {
+		ip, err := netaddr.ParseIP(address)
+		if err != nil {
+			return "", err
+		}
+		if ip.String() == pinned {
+			return pinned, nil
+		}
+		for _, v := range pool.Spec.Reservations {
+			if reserved, _ := netaddr.ParseIP(v.Address); reserved == ip {
+				return "", fmt.Errorf("requested address %s is reserved for %s", address, v.Name)
 			}
 		}
-		// found an IP
+		if !ipSet.Contains(ip) {
+			return "", fmt.Errorf("requested address %s is not available in pool %s", address, pool.Name)
+		}
 		return ip.String(), nil
+	}
+
+	if _, ok := poolStatus.AddressAllocation[pinned]; pinned != "" && !ok {
+		return pinned, nil
+	}
+
+	retained, err := unexpiredRetainedAddresses(pool, time.Now())
+	if err != nil {
+		return "", err
+	}
+
+	for address, ref := range retained {
+		ip, err := netaddr.ParseIP(address)
+		if err != nil || !ipSet.Contains(ip) {
+			continue
+		}
+		if _, ok := poolStatus.AddressAllocation[address]; ok {
+			continue
+		}
+		if consumer != nil && consumer.Kind == seederv1alpha1.KindInventory && consumer.ObjectReference == ref.Inventory {
+			return address, nil
+		}
+	}
 
+	// addresses retained for other inventory are skipped on the first pass
+	for _, skipRetained := range []bool{true, false} {
+		for _, ipRange := range ipSet.Ranges() {
+			for ip := ipRange.From(); ipRange.Contains(ip); ip = ip.Next() {
+				if _, ok := poolStatus.AddressAllocation[ip.String()]; ok {
+					continue
+				}
+				if _, ok := retained[ip.String()]; ok && skipRetained {
+					continue
+				}
+				// found an IP
+				return ip.String(), nil
+			}
+		}
 	}
 
 	return "", fmt.Errorf("could not allocate an address as pool is already exhausted")
 }
 
+// pinnedAddress returns the reserved address pinned to consumer, if it is an inventory
+func pinnedAddress(pool *seederv1alpha1.AddressPool, consumer *seederv1alpha1.ObjectReferenceWithKind) string {
+	if consumer == nil || consumer.Kind != seederv1alpha1.KindInventory {
+		return ""
+	}
+
+	for _, v := range pool.Spec.Reservations {
+		if v.Inventory == nil || *v.Inventory != consumer.ObjectReference {
+			continue
+		}
+		if ip, err := netaddr.ParseIP(v.Address); err == nil {
+			return ip.String()
+		}
+	}
+	return ""
+}
+
+// unexpiredRetainedAddresses returns the addresses retained by the pool whose retention has not expired at now
+func unexpiredRetainedAddresses(pool *seederv1alpha1.AddressPool, now time.Time) (map[string]seederv1alpha1.RetainedAddress, error) {
+	retained := make(map[string]seederv1alpha1.RetainedAddress)
+	if pool.Spec.AddressRetention == "" {
+		return retained, nil
+	}
+
+	retention, err := time.ParseDuration(pool.Spec.AddressRetention)
+	if err != nil {
+		return nil, fmt.Errorf("invalid address retention for pool %s: %v", pool.Name, err)
+	}
+
+	for address, v := range pool.Status.RetainedAddresses {
+		if v.ReleasedAt.Add(retention).After(now) {
+			retained[address] = v
+		}
+	}
+	return retained, nil
+}
+
+// RetainAddresses returns the retained addresses of the pool once its allocation is updated to allocation.
+// Addresses released by inventory are retained, while addresses which have been allocated again or whose
+// retention has expired are dropped
+func RetainAddresses(pool *seederv1alpha1.AddressPool, allocation map[string]seederv1alpha1.ObjectReferenceWithKind, now time.Time) (map[string]seederv1alpha1.RetainedAddress, error) {
+	retained, err := unexpiredRetainedAddresses(pool, now)
+	if err != nil {
+		return nil, err
+	}
+
+	if pool.Spec.AddressRetention != "" {
+		for address, ref := range pool.Status.AddressAllocation {
+			if _, ok := allocation[address]; ok || ref.Kind != seederv1alpha1.KindInventory {
+				continue
+			}
+			retained[address] = seederv1alpha1.RetainedAddress{
+				Inventory:  ref.ObjectReference,
+				ReleasedAt: metav1.NewTime(now),
+			}
+		}
+	}
+
+	for address := range allocation {
+		delete(retained, address)
+	}
+
+	if len(retained) == 0 {
+		return nil, nil
+	}
+	return retained, nil
+}
+
+// AllocatedAddresses counts the allocations which use up the allocatable addresses of the pool. Allocations of
+// excluded or reserved addresses do not use up the capacity of the pool
+func AllocatedAddresses(pool *seederv1alpha1.AddressPool) (int, error) {
+	ipSet, _, err := allocatableAddresses(pool)
+	if err != nil {
+		return 0, err
+	}
+
+	var count int
+	for address := range pool.Status.AddressAllocation {
+		ip, err := netaddr.ParseIP(address)
+		if err == nil && ipSet.Contains(ip) {
+			count++
+		}
+	}
+	return count, nil
+}
+
+// AllocationsOutsidePool lists the allocations of the pool which are not allocatable with the current pool spec,
+// along with the object each address is allocated to
+func AllocationsOutsidePool(pool *seederv1alpha1.AddressPool) ([]string, error) {
+	ipSet, _, err := allocatableAddresses(pool)
+	if err != nil {
+		return nil, err
+	}
+
+	// external providers only need to allocate addresses from the CIDR of the pool
+	if pool.Spec.Provider != "" && pool.Spec.Provider != seederv1alpha1.IPAMProviderAddressPool {
+		ipPrefix, err := netaddr.ParseIPPrefix(pool.Spec.CIDR)
+		if err != nil {
+			return nil, err
+		}
+		var b netaddr.IPSetBuilder
+		b.AddPrefix(ipPrefix.Masked())
+		if ipSet, err = b.IPSet(); err != nil {
+			return nil, err
+		}
+	}
+
+	var outside []string
+	for address, ref := range pool.Status.AddressAllocation {
+		// addresses pinned to inventory are reserved, so they are not part of the allocatable addresses
+		if address == pinnedAddress(pool, &ref) {
+			continue
+		}
+		ip, err := netaddr.ParseIP(address)
+		if err != nil || !ipSet.Contains(ip) {
+			outside = append(outside, fmt.Sprintf("%s (%s %s/%s)", address, ref.Kind, ref.Namespace, ref.Name))
+		}
+	}
+	sort.Strings(outside)
+	return outside, nil
+}
+
 // DeallocateAddress will free up the address
 func DeallocateAddress(poolStatus *seederv1alpha1.AddressStatus, address string) error {
 	if _, ok := poolStatus.AddressAllocation[address]; !ok {
@@ -90,22 +378,101 @@ func DeallocateAddress(poolStatus *seederv1alpha1.AddressStatus, address string)
 	return nil
 }
 
-// FindIPInAddressPools finds the address pool and address is allocated from.
-func FindIPInAddressPools(ctx context.Context, c client.Client, name, namespace, address string) (*seederv1alpha1.AddressPool, error) {
-	poolList := &seederv1alpha1.AddressPoolList{}
-	err := c.List(ctx, poolList, &client.ListOptions{})
+// IsIPv6Pool returns true if the pool allocates IPv6 addresses
+func IsIPv6Pool(pool *seederv1alpha1.AddressPool) (bool, error) {
+	ipPrefix, err := netaddr.ParseIPPrefix(pool.Spec.CIDR)
+	if err != nil {
+		return false, err
+	}
+	return ipPrefix.IP().Is6(), nil
+}
+
+// ClaimName is the name of the IPClaim used to allocate an address to an inventory or cluster
+func ClaimName(kind, name string, ipv6 bool) string {
+	if ipv6 {
+		return fmt.Sprintf("%s-%s-ipv6", kind, name)
+	}
+	return fmt.Sprintf("%s-%s-ipv4", kind, name)
+}
+
+// IPAddressName is the name of the IPAddress recording the allocation of address from the pool. The name is
+// unique to the address, which ensures an address cannot be allocated twice
+func IPAddressName(poolName, address string) (string, error) {
+	ip, err := netaddr.ParseIP(address)
+	if err != nil {
+		return "", err
+	}
+	// IPv6 addresses are not valid object names, so they are rendered in hex
+	if ip.Is6() {
+		return fmt.Sprintf("%s-%x", poolName, ip.As16()), nil
+	}
+	return fmt.Sprintf("%s-%s", poolName, ip), nil
+}
+
+// GenerateIPAddress generates the IPAddress binding address in the pool to the claim
+func GenerateIPAddress(pool *seederv1alpha1.AddressPool, claim *seederv1alpha1.IPClaim, address string) (*seederv1alpha1.IPAddress, error) {
+	name, err := IPAddressName(pool.Name, address)
 	if err != nil {
 		return nil, err
 	}
 
-	for _, i := range poolList.Items {
-		obj, ok := i.Status.AddressAllocation[address]
-		if ok && obj.Name == name && obj.Namespace == namespace && obj.Kind == "inventory" {
-			return &i, nil
-		}
+	consumer := seederv1alpha1.ObjectReferenceWithKind{
+		Kind:            seederv1alpha1.KindIPClaim,
+		ObjectReference: seederv1alpha1.ObjectReference{Name: claim.Name, Namespace: claim.Namespace},
+	}
+	if claim.Spec.Consumer != nil {
+		consumer = *claim.Spec.Consumer
 	}
 
-	// address not found in any pool. nothing to be done
-	return nil, nil
+	return &seederv1alpha1.IPAddress{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: pool.Namespace,
+			Labels: map[string]string{
+				seederv1alpha1.AddressPoolLabel: pool.Name,
+			},
+		},
+		Spec: seederv1alpha1.IPAddressSpec{
+			Address:        address,
+			PoolReference:  seederv1alpha1.ObjectReference{Name: pool.Name, Namespace: pool.Namespace},
+			ClaimReference: seederv1alpha1.ObjectReference{Name: claim.Name, Namespace: claim.Namespace},
+			Consumer:       consumer,
+		},
+	}, nil
+}
 
+// ListPoolAddresses lists the IPAddresses allocated from the pool
+func ListPoolAddresses(ctx context.Context, c client.Client, pool *seederv1alpha1.AddressPool) ([]seederv1alpha1.IPAddress, error) {
+	addressList := &seederv1alpha1.IPAddressList{}
+	if err := c.List(ctx, addressList, client.InNamespace(pool.Namespace),
+		client.MatchingLabels{seederv1alpha1.AddressPoolLabel: pool.Name}); err != nil {
+		return nil, err
+	}
+	return addressList.Items, nil
+}
+
+// AddressAllocation generates the address allocation of a pool from its IPAddresses
+func AddressAllocation(addresses []seederv1alpha1.IPAddress) map[string]seederv1alpha1.ObjectReferenceWithKind {
+	allocation := make(map[string]seederv1alpha1.ObjectReferenceWithKind)
+	for _, v := range addresses {
+		allocation[v.Spec.Address] = v.Spec.Consumer
+	}
+	return allocation
+}
+
+// ReleaseAddresses deletes the IPv4 and IPv6 claims of an inventory or cluster. The addresses are released
+// once the claims are removed
+func ReleaseAddresses(ctx context.Context, c client.Client, kind, name, namespace string) error {
+	for _, ipv6 := range []bool{false, true} {
+		claim := &seederv1alpha1.IPClaim{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      ClaimName(kind, name, ipv6),
+				Namespace: namespace,
+			},
+		}
+		if err := c.Delete(ctx, claim); err != nil && !apierrors.IsNotFound(err) {
+			return err
+		}
+	}
+	return nil
 }
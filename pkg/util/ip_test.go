@@ -1,7 +1,9 @@
 package util
 
 import (
+	"math"
 	"testing"
+	"time"
 
 	seederv1alpha1 "github.com/harvester/seeder/pkg/api/v1alpha1"
 	"github.com/stretchr/testify/require"
@@ -25,31 +27,37 @@ func Test_GenerateAddressPoolStatus(t *testing.T) {
 	assert := require.New(t)
 	status, err := GenerateAddressPoolStatus(testPool)
 	assert.NoError(err, "expected no error to have occured during address pool status generation")
-	assert.Equal(status.AvailableAddresses, 7)
-	assert.Equal(status.StartAddress, "192.168.1.0")
-	assert.Equal(status.LastAddress, "192.168.1.7")
+	// network and broadcast addresses are not allocatable, and the gateway is the broadcast address
+	assert.Equal(status.AvailableAddresses, 6)
+	assert.Equal(status.StartAddress, "192.168.1.1")
+	assert.Equal(status.LastAddress, "192.168.1.6")
+	assert.Equal(status.Netmask, "255.255.255.248")
 	assert.Equal(status.Status, seederv1alpha1.PoolReady)
 }
 
 func Test_AllocateAddress(t *testing.T) {
 	assert := require.New(t)
-	status, err := GenerateAddressPoolStatus(testPool)
+	pool := testPool.DeepCopy()
+	status, err := GenerateAddressPoolStatus(pool)
 	assert.NoError(err, "expected no error to have occured during address pool status generation")
-	address, err := AllocateAddress(status, "")
+	pool.Status = *status
+	address, err := AllocateAddress(pool, "")
 	assert.NoError(err, "expected no error during address allocation")
 	assert.NotEmpty(address, "generated address should not have been empty")
-	status.AddressAllocation = map[string]seederv1alpha1.ObjectReferenceWithKind{
+	pool.Status.AddressAllocation = map[string]seederv1alpha1.ObjectReferenceWithKind{
 		address: {ObjectReference: seederv1alpha1.ObjectReference{Namespace: "default", Name: "demo"}, Kind: "inventory"},
 	}
-	_, err = AllocateAddress(status, address)
+	_, err = AllocateAddress(pool, address)
 	assert.Error(err, "expected error allocating same address twice")
 }
 
 func Test_DeallocateAddress(t *testing.T) {
 	assert := require.New(t)
-	status, err := GenerateAddressPoolStatus(testPool)
+	pool := testPool.DeepCopy()
+	status, err := GenerateAddressPoolStatus(pool)
 	assert.NoError(err, "expected no error to have occured during address pool status generation")
-	address, err := AllocateAddress(status, "")
+	pool.Status = *status
+	address, err := AllocateAddress(pool, "")
 	assert.NoError(err, "expected no error during address allocation")
 	assert.NotEmpty(address, "generated address should not have been empty")
 	status.AddressAllocation = map[string]seederv1alpha1.ObjectReferenceWithKind{
@@ -59,3 +67,226 @@ func Test_DeallocateAddress(t *testing.T) {
 	assert.NoError(err, "expected no error while removing ip address")
 	assert.Empty(len(status.AddressAllocation), "expected no addresses to be allocated")
 }
+
+func Test_GenerateAddressPoolStatusIPv6(t *testing.T) {
+	assert := require.New(t)
+	pool := &seederv1alpha1.AddressPool{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "testpoolv6",
+			Namespace: "default",
+		},
+		Spec: seederv1alpha1.AddressSpec{
+			CIDR:    "fd00:1::/64",
+			Gateway: "fd00:1::1",
+		},
+	}
+	status, err := GenerateAddressPoolStatus(pool)
+	assert.NoError(err, "expected no error to have occured during ipv6 address pool status generation")
+	assert.Equal(math.MaxInt32, status.AvailableAddresses, "expected available addresses to be capped")
+	assert.Equal("fd00:1::1", status.StartAddress)
+	assert.Equal("fd00:1::ffff:ffff:ffff:ffff", status.LastAddress)
+	assert.Equal("ffff:ffff:ffff:ffff::", status.Netmask)
+
+	pool.Status = *status
+
+	address, err := AllocateAddress(pool, "")
+	assert.NoError(err, "expected no error during ipv6 address allocation")
+	assert.Equal("fd00:1::2", address, "expected first address after the gateway to be allocated")
+	pool.Status.AddressAllocation[address] = seederv1alpha1.ObjectReferenceWithKind{ObjectReference: seederv1alpha1.ObjectReference{Namespace: "default", Name: "demo"}, Kind: "inventory"}
+	next, err := AllocateAddress(pool, "")
+	assert.NoError(err, "expected no error during ipv6 address allocation")
+	assert.NotEqual(address, next, "expected a different address to be allocated")
+
+	static, err := AllocateAddress(pool, "fd00:1::100")
+	assert.NoError(err, "expected no error during static ipv6 address allocation")
+	assert.Equal("fd00:1::100", static)
+	_, err = AllocateAddress(pool, "fd00:2::100")
+	assert.Error(err, "expected error allocating address outside of pool")
+
+	pool.Spec.CIDR = "fd00:1::/120"
+	status, err = GenerateAddressPoolStatus(pool)
+	assert.NoError(err, "expected no error to have occured during ipv6 address pool status generation")
+	assert.Equal(254, status.AvailableAddresses, "expected gateway to be excluded from available addresses")
+
+	pool.Spec.Gateway = "192.168.1.1"
+	_, err = GenerateAddressPoolStatus(pool)
+	assert.Error(err, "expected error for gateway in a different address family")
+}
+
+func Test_AddressPoolRangesAndReservations(t *testing.T) {
+	assert := require.New(t)
+	pool := &seederv1alpha1.AddressPool{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "testpool",
+			Namespace: "default",
+		},
+		Spec: seederv1alpha1.AddressSpec{
+			CIDR:              "192.168.1.0/24",
+			Gateway:           "192.168.1.1",
+			StartAddress:      "192.168.1.10",
+			EndAddress:        "192.168.1.29",
+			ExcludedAddresses: []string{"192.168.1.10", "192.168.1.12-192.168.1.13", "192.168.1.16/30"},
+			Reservations: []seederv1alpha1.AddressReservation{
+				{
+					Name:    "switch",
+					Address: "192.168.1.11",
+				},
+			},
+		},
+	}
+
+	status, err := GenerateAddressPoolStatus(pool)
+	assert.NoError(err, "expected no error to have occured during address pool status generation")
+	assert.Equal("192.168.1.10", status.StartAddress)
+	assert.Equal("192.168.1.29", status.LastAddress)
+	assert.Equal(12, status.AvailableAddresses, "expected excluded and reserved addresses to be removed")
+	pool.Status = *status
+
+	address, err := AllocateAddress(pool, "")
+	assert.NoError(err, "expected no error during address allocation")
+	assert.Equal("192.168.1.14", address, "expected excluded and reserved addresses to be skipped")
+
+	_, err = AllocateAddress(pool, "192.168.1.11")
+	assert.ErrorContains(err, "reserved for switch", "expected error allocating a reserved address")
+	_, err = AllocateAddress(pool, "192.168.1.17")
+	assert.Error(err, "expected error allocating an excluded address")
+	_, err = AllocateAddress(pool, "192.168.1.30")
+	assert.Error(err, "expected error allocating an address outside of the range")
+	static, err := AllocateAddress(pool, "192.168.1.20")
+	assert.NoError(err, "expected no error allocating a static address")
+	assert.Equal("192.168.1.20", static)
+
+	// allocations of excluded addresses do not use up the pool
+	pool.Status.AddressAllocation = map[string]seederv1alpha1.ObjectReferenceWithKind{
+		"192.168.1.14": {ObjectReference: seederv1alpha1.ObjectReference{Namespace: "default", Name: "demo"}, Kind: "inventory"},
+		"192.168.1.17": {ObjectReference: seederv1alpha1.ObjectReference{Namespace: "default", Name: "old"}, Kind: "inventory"},
+	}
+	allocated, err := AllocatedAddresses(pool)
+	assert.NoError(err, "expected no error counting allocated addresses")
+	assert.Equal(1, allocated)
+
+	pool.Spec.StartAddress = "192.168.2.10"
+	_, err = GenerateAddressPoolStatus(pool)
+	assert.Error(err, "expected error for start address outside of cidr")
+}
+
+func Test_IPAddressName(t *testing.T) {
+	assert := require.New(t)
+	name, err := IPAddressName("pool", "192.168.1.10")
+	assert.NoError(err, "expected no error generating ipv4 address name")
+	assert.Equal("pool-192.168.1.10", name)
+	name, err = IPAddressName("pool", "fd00:1::")
+	assert.NoError(err, "expected no error generating ipv6 address name")
+	assert.Equal("pool-fd000001000000000000000000000000", name)
+	_, err = IPAddressName("pool", "invalid")
+	assert.Error(err, "expected error for invalid address")
+	assert.Equal("inventory-node1-ipv6", ClaimName(seederv1alpha1.KindInventory, "node1", true))
+}
+
+func Test_AllocationsOutsidePool(t *testing.T) {
+	assert := require.New(t)
+	pool := testPool.DeepCopy()
+	pool.Spec.CIDR = "192.168.1.0/24"
+	pool.Spec.Gateway = "192.168.1.1"
+	pool.Status.AddressAllocation = map[string]seederv1alpha1.ObjectReferenceWithKind{
+		"192.168.1.10":  {ObjectReference: seederv1alpha1.ObjectReference{Namespace: "default", Name: "node1"}, Kind: "inventory"},
+		"192.168.1.200": {ObjectReference: seederv1alpha1.ObjectReference{Namespace: "default", Name: "node2"}, Kind: "inventory"},
+	}
+
+	outside, err := AllocationsOutsidePool(pool)
+	assert.NoError(err, "expected no error checking allocations")
+	assert.Empty(outside, "expected all allocations to be part of the pool")
+
+	pool.Spec.CIDR = "192.168.1.0/25"
+	outside, err = AllocationsOutsidePool(pool)
+	assert.NoError(err, "expected no error checking allocations")
+	assert.Equal([]string{"192.168.1.200 (inventory default/node2)"}, outside)
+
+	pool.Spec.ExcludedAddresses = []string{"192.168.1.10"}
+	outside, err = AllocationsOutsidePool(pool)
+	assert.NoError(err, "expected no error checking allocations")
+	assert.Len(outside, 2, "expected excluded allocation to be outside the pool")
+}
+
+func Test_StickyAddresses(t *testing.T) {
+	assert := require.New(t)
+	node1 := seederv1alpha1.ObjectReferenceWithKind{ObjectReference: seederv1alpha1.ObjectReference{Namespace: "default", Name: "node1"}, Kind: seederv1alpha1.KindInventory}
+	node2 := seederv1alpha1.ObjectReferenceWithKind{ObjectReference: seederv1alpha1.ObjectReference{Namespace: "default", Name: "node2"}, Kind: seederv1alpha1.KindInventory}
+	pool := &seederv1alpha1.AddressPool{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "testpool",
+			Namespace: "default",
+		},
+		Spec: seederv1alpha1.AddressSpec{
+			CIDR:             "192.168.1.0/29",
+			Gateway:          "192.168.1.1",
+			AddressRetention: "1h",
+			Reservations: []seederv1alpha1.AddressReservation{
+				{
+					Name:      "node2",
+					Address:   "192.168.1.6",
+					Inventory: &node2.ObjectReference,
+				},
+			},
+		},
+	}
+
+	status, err := GenerateAddressPoolStatus(pool)
+	assert.NoError(err, "expected no error to have occured during address pool status generation")
+	assert.Equal(4, status.AvailableAddresses, "expected pinned address to not be available")
+	pool.Status = *status
+
+	// pinned address is only allocated to its inventory
+	address, err := AllocateAddressForConsumer(pool, "", &node2)
+	assert.NoError(err, "expected no error allocating pinned address")
+	assert.Equal("192.168.1.6", address)
+	_, err = AllocateAddressForConsumer(pool, "192.168.1.6", &node1)
+	assert.ErrorContains(err, "reserved for node2", "expected error allocating address pinned to another inventory")
+
+	// address released by node1 is retained for it
+	now := time.Now()
+	pool.Status.AddressAllocation = map[string]seederv1alpha1.ObjectReferenceWithKind{
+		"192.168.1.2": node1,
+		"192.168.1.6": node2,
+	}
+	allocation := map[string]seederv1alpha1.ObjectReferenceWithKind{
+		"192.168.1.6": node2,
+	}
+	retained, err := RetainAddresses(pool, allocation, now)
+	assert.NoError(err, "expected no error retaining addresses")
+	assert.Len(retained, 1, "expected released address to be retained")
+	assert.Equal(node1.ObjectReference, retained["192.168.1.2"].Inventory)
+	pool.Status.AddressAllocation = allocation
+	pool.Status.RetainedAddresses = retained
+
+	address, err = AllocateAddressForConsumer(pool, "", nil)
+	assert.NoError(err, "expected no error allocating address")
+	assert.Equal("192.168.1.3", address, "expected retained address to be skipped")
+	address, err = AllocateAddressForConsumer(pool, "", &node1)
+	assert.NoError(err, "expected no error allocating retained address")
+	assert.Equal("192.168.1.2", address, "expected retained address to be handed back")
+
+	// retained addresses are used once the rest of the pool is exhausted
+	pool.Status.AddressAllocation = map[string]seederv1alpha1.ObjectReferenceWithKind{
+		"192.168.1.3": node2,
+		"192.168.1.4": node2,
+		"192.168.1.5": node2,
+		"192.168.1.6": node2,
+	}
+	address, err = AllocateAddressForConsumer(pool, "", nil)
+	assert.NoError(err, "expected no error allocating address")
+	assert.Equal("192.168.1.2", address, "expected retained address to be allocated from exhausted pool")
+
+	// retention is dropped once the address is allocated again or expires
+	pool.Status.AddressAllocation = allocation
+	retained, err = RetainAddresses(pool, map[string]seederv1alpha1.ObjectReferenceWithKind{"192.168.1.2": node2}, now)
+	assert.NoError(err, "expected no error retaining addresses")
+	assert.NotContains(retained, "192.168.1.2", "expected reallocated address to not be retained")
+	retained, err = RetainAddresses(pool, allocation, now.Add(2*time.Hour))
+	assert.NoError(err, "expected no error retaining addresses")
+	assert.Empty(retained, "expected retention to have expired")
+
+	outside, err := AllocationsOutsidePool(pool)
+	assert.NoError(err, "expected no error checking allocations")
+	assert.Empty(outside, "expected pinned allocation to be part of the pool")
+}
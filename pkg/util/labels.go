@@ -0,0 +1,43 @@
+package util
+
+// Keys of the hardware labels added to inventories and their nodes
+const (
+	HardwareManufacturer = "manufacturer"
+	HardwareModel        = "model"
+	HardwareSerialNumber = "serialNumber"
+	HardwareHealth       = "health"
+
+	// DefaultHardwareLabelPrefix namespaces the hardware labels
+	DefaultHardwareLabelPrefix = "hardware.harvesterhci.io/"
+)
+
+// DefaultHardwareLabelKeys are the hardware labels added by default
+var DefaultHardwareLabelKeys = []string{HardwareManufacturer, HardwareModel, HardwareSerialNumber, HardwareHealth}
+
+// HardwareLabels configures the labels describing the hardware of a node
+type HardwareLabels struct {
+	// Prefix is prepended to the key of each label
+	Prefix string
+	// Keys are the hardware labels added. No labels are added when empty
+	Keys []string
+}
+
+// Apply sets the selected hardware labels from values, which are keyed by the label key without the prefix. Hardware
+// labels which are not selected, and the labels added without a prefix by earlier versions, are removed
+func (h HardwareLabels) Apply(labels map[string]string, values map[string]string) {
+	if h.Prefix != "" {
+		for _, k := range []string{HardwareManufacturer, HardwareModel, HardwareSerialNumber} {
+			delete(labels, k)
+		}
+	}
+
+	for _, k := range DefaultHardwareLabelKeys {
+		delete(labels, h.Prefix+k)
+	}
+
+	for _, k := range h.Keys {
+		if v, ok := values[k]; ok && v != "" {
+			labels[h.Prefix+k] = v
+		}
+	}
+}
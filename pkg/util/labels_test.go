@@ -0,0 +1,34 @@
+package util
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func Test_HardwareLabels(t *testing.T) {
+	assert := require.New(t)
+	values := map[string]string{
+		HardwareManufacturer: "DellInc",
+		HardwareModel:        "PowerEdgeR630",
+		HardwareSerialNumber: "",
+		HardwareHealth:       "OK",
+	}
+
+	labels := map[string]string{
+		"manufacturer":                          "DellInc",
+		"model":                                 "PowerEdgeR630",
+		"hardware.harvesterhci.io/serialNumber": "stale",
+		"user":                                  "defined",
+	}
+
+	HardwareLabels{Prefix: DefaultHardwareLabelPrefix, Keys: []string{HardwareManufacturer, HardwareSerialNumber, HardwareHealth}}.Apply(labels, values)
+	assert.Equal(map[string]string{
+		"hardware.harvesterhci.io/manufacturer": "DellInc",
+		"hardware.harvesterhci.io/health":       "OK",
+		"user":                                  "defined",
+	}, labels, "expected selected labels to be namespaced, and legacy and empty labels to be removed")
+
+	HardwareLabels{Prefix: DefaultHardwareLabelPrefix}.Apply(labels, values)
+	assert.Equal(map[string]string{"user": "defined"}, labels, "expected hardware labels to be removed when none are selected")
+}
@@ -0,0 +1,114 @@
+package util
+
+import (
+	"strings"
+
+	seederv1alpha1 "github.com/harvester/seeder/pkg/api/v1alpha1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/util/validation"
+)
+
+// PropagatedKey returns the key of the node label or annotation propagated from an inventory key. Keys can only
+// have a single prefix, so the prefix of the inventory key is joined to its name using a dot
+func PropagatedKey(key string) string {
+	return seederv1alpha1.PropagationPrefix + strings.ReplaceAll(key, "/", ".")
+}
+
+// PropagateToNode sets the node labels and annotations propagated from the inventory labels and annotations selected
+// by the policy, and the labels for the selected hardware facts. Propagated labels and annotations whose source no
+// longer exists are removed. The inventory keys which cannot be propagated as their propagated key or value is not
+// valid are returned
+func PropagateToNode(node *corev1.Node, i *seederv1alpha1.Inventory, policy seederv1alpha1.NodePropagationPolicy, facts map[string]string) []string {
+	labels, skipped := propagated(i.Labels, policy.Labels, policy.LabelPrefixes, true)
+	annotations, skippedAnnotations := propagated(i.Annotations, policy.Annotations, policy.AnnotationPrefixes, false)
+	skipped = append(skipped, skippedAnnotations...)
+
+	for _, f := range policy.HardwareFacts {
+		if v := SanitizeLabelValue(facts[string(f)]); v != "" {
+			labels[seederv1alpha1.PropagationPrefix+string(f)] = v
+		}
+	}
+
+	if node.Labels == nil {
+		node.Labels = make(map[string]string)
+	}
+
+	if node.Annotations == nil {
+		node.Annotations = make(map[string]string)
+	}
+
+	syncPropagated(node.Labels, labels)
+	syncPropagated(node.Annotations, annotations)
+	return skipped
+}
+
+// SanitizeLabelValue replaces the characters which are not valid in a label value with underscores, and trims the
+// value to the maximum length of a label value
+func SanitizeLabelValue(value string) string {
+	var b strings.Builder
+	for _, c := range value {
+		valid := c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9' || c == '-' || c == '.' || c == '_'
+		switch {
+		case valid:
+			b.WriteRune(c)
+		case !strings.HasSuffix(b.String(), "_"):
+			b.WriteRune('_')
+		}
+	}
+
+	result := b.String()
+	if len(result) > validation.LabelValueMaxLength {
+		result = result[:validation.LabelValueMaxLength]
+	}
+
+	// values must start and end with an alphanumeric character
+	return strings.Trim(result, "-._")
+}
+
+func propagated(source map[string]string, keys []string, prefixes []string, label bool) (map[string]string, []string) {
+	result := make(map[string]string)
+	var skipped []string
+	for k, v := range source {
+		if !selected(k, keys, prefixes) {
+			continue
+		}
+
+		key := PropagatedKey(k)
+		if len(validation.IsQualifiedName(key)) != 0 || (label && len(validation.IsValidLabelValue(v)) != 0) {
+			skipped = append(skipped, k)
+			continue
+		}
+		result[key] = v
+	}
+
+	return result, skipped
+}
+
+func selected(key string, keys []string, prefixes []string) bool {
+	for _, k := range keys {
+		if k == key {
+			return true
+		}
+	}
+
+	for _, p := range prefixes {
+		if strings.HasPrefix(key, p) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// syncPropagated removes the propagated keys which are not desired, and sets the desired keys
+func syncPropagated(current map[string]string, desired map[string]string) {
+	for k := range current {
+		if _, ok := desired[k]; strings.HasPrefix(k, seederv1alpha1.PropagationPrefix) && !ok {
+			delete(current, k)
+		}
+	}
+
+	for k, v := range desired {
+		current[k] = v
+	}
+}
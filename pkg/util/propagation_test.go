@@ -0,0 +1,77 @@
+package util
+
+import (
+	"strings"
+	"testing"
+
+	seederv1alpha1 "github.com/harvester/seeder/pkg/api/v1alpha1"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func Test_PropagateToNode(t *testing.T) {
+	assert := require.New(t)
+	i := &seederv1alpha1.Inventory{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "propagation",
+			Namespace: "default",
+			Labels: map[string]string{
+				"rack":                        "r12",
+				"topology.example.com/zone":   "zone-a",
+				"topology.example.com/region": "region-1",
+				"ignored":                     "value",
+			},
+			Annotations: map[string]string{
+				"owner": "team-a",
+			},
+		},
+	}
+
+	policy := seederv1alpha1.NodePropagationPolicy{
+		Labels:        []string{"rack"},
+		LabelPrefixes: []string{"topology.example.com/"},
+		Annotations:   []string{"owner"},
+		HardwareFacts: []seederv1alpha1.HardwareFact{seederv1alpha1.HardwareFactCPUModel, seederv1alpha1.HardwareFactGPU},
+	}
+
+	facts := map[string]string{
+		"cpuModel": "Intel(R) Xeon(R) CPU E5-2676 v3 @ 2.40GHz",
+		"gpu":      "false",
+		"memory":   "512",
+	}
+
+	node := &corev1.Node{
+		ObjectMeta: metav1.ObjectMeta{
+			Labels: map[string]string{
+				"seeder.harvesterhci.io/stale": "value",
+				"kubernetes.io/hostname":       "node",
+			},
+		},
+	}
+
+	skipped := PropagateToNode(node, i, policy, facts)
+	assert.Empty(skipped, "expected all selected keys to be propagated")
+	assert.Equal(map[string]string{
+		"seeder.harvesterhci.io/rack":                        "r12",
+		"seeder.harvesterhci.io/topology.example.com.zone":   "zone-a",
+		"seeder.harvesterhci.io/topology.example.com.region": "region-1",
+		"seeder.harvesterhci.io/cpuModel":                    "Intel_R_Xeon_R_CPU_E5-2676_v3_2.40GHz",
+		"seeder.harvesterhci.io/gpu":                         "false",
+		"kubernetes.io/hostname":                             "node",
+	}, node.Labels, "expected selected labels and facts to be propagated, and stale labels to be removed")
+	assert.Equal(map[string]string{"seeder.harvesterhci.io/owner": "team-a"}, node.Annotations, "expected selected annotations to be propagated")
+
+	delete(i.Labels, "rack")
+	delete(i.Annotations, "owner")
+	PropagateToNode(node, i, policy, facts)
+	assert.NotContains(node.Labels, "seeder.harvesterhci.io/rack", "expected label to be removed with its source")
+	assert.Empty(node.Annotations, "expected annotation to be removed with its source")
+}
+
+func Test_SanitizeLabelValue(t *testing.T) {
+	assert := require.New(t)
+	assert.Equal("AMD_EPYC_7543_32-Core_Processor", SanitizeLabelValue("AMD EPYC 7543 32-Core Processor "))
+	assert.Equal("rack-1", SanitizeLabelValue("(rack-1)"))
+	assert.Len(SanitizeLabelValue(strings.Repeat("a", 100)), 63, "expected value to be truncated")
+}
@@ -0,0 +1,119 @@
+package util
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	seederv1alpha1 "github.com/harvester/seeder/pkg/api/v1alpha1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/util/version"
+)
+
+const (
+	HarvesterSystemNamespace = "harvester-system"
+	defaultISOURL            = "https://releases.rancher.com/harvester/"
+	upgradeCompleted         = "Completed"
+)
+
+var (
+	HarvesterVersionGVR = schema.GroupVersionResource{Group: "harvesterhci.io", Version: "v1beta1", Resource: "versions"}
+	HarvesterUpgradeGVR = schema.GroupVersionResource{Group: "harvesterhci.io", Version: "v1beta1", Resource: "upgrades"}
+	invalidNameChars    = regexp.MustCompile(`[^a-z0-9-]`)
+)
+
+// GenerateISOURL returns the location of the Harvester ISO for a version and architecture. Images are looked up from
+// the default release endpoint unless an imageURL is specified
+func GenerateISOURL(imageURL, version string, arch seederv1alpha1.Architecture) string {
+	endpoint := defaultISOURL
+	if imageURL != "" {
+		endpoint = imageURL
+	}
+	return fmt.Sprintf("%s/%s/harvester-%s-%s.iso", strings.TrimSuffix(endpoint, "/"), version, version, arch)
+}
+
+// CheckUpgradePath verifies that Harvester can be upgraded from current to target version.
+// Harvester does not support downgrades, or skipping minor versions during an upgrade
+func CheckUpgradePath(current, target string) error {
+	currentVersion, err := version.ParseSemantic(current)
+	if err != nil {
+		return fmt.Errorf("unable to parse current version %s: %v", current, err)
+	}
+
+	targetVersion, err := version.ParseSemantic(target)
+	if err != nil {
+		return fmt.Errorf("unable to parse target version %s: %v", target, err)
+	}
+
+	if !currentVersion.LessThan(targetVersion) {
+		return fmt.Errorf("target version %s must be newer than current version %s", target, current)
+	}
+
+	if currentVersion.Major() != targetVersion.Major() {
+		return fmt.Errorf("upgrade across major versions from %s to %s is not supported", current, target)
+	}
+
+	if targetVersion.Minor()-currentVersion.Minor() > 1 {
+		return fmt.Errorf("upgrade from %s to %s skips a minor version, upgrade to v%d.%d.x first", current, target,
+			currentVersion.Major(), currentVersion.Minor()+1)
+	}
+
+	return nil
+}
+
+// HarvesterUpgradeName generates the name of the upgrade object seeder creates for a version
+func HarvesterUpgradeName(version string) string {
+	return fmt.Sprintf("seeder-upgrade-%s", invalidNameChars.ReplaceAllString(strings.ToLower(version), "-"))
+}
+
+// GenerateHarvesterVersion generates the harvesterhci.io Version object needed to upgrade to a version
+func GenerateHarvesterVersion(version, isoURL string) *unstructured.Unstructured {
+	v := &unstructured.Unstructured{}
+	v.SetAPIVersion(HarvesterVersionGVR.GroupVersion().String())
+	v.SetKind("Version")
+	v.SetName(version)
+	v.SetNamespace(HarvesterSystemNamespace)
+	v.Object["spec"] = map[string]interface{}{
+		"isoURL": isoURL,
+	}
+	return v
+}
+
+// GenerateHarvesterUpgrade generates the harvesterhci.io Upgrade object which triggers upgrade to a version
+func GenerateHarvesterUpgrade(version string) *unstructured.Unstructured {
+	u := &unstructured.Unstructured{}
+	u.SetAPIVersion(HarvesterUpgradeGVR.GroupVersion().String())
+	u.SetKind("Upgrade")
+	u.SetName(HarvesterUpgradeName(version))
+	u.SetNamespace(HarvesterSystemNamespace)
+	u.Object["spec"] = map[string]interface{}{
+		"version": version,
+	}
+	return u
+}
+
+// HarvesterUpgradeStatus parses the Completed condition of a harvesterhci.io Upgrade object
+// and reports if the upgrade is done and if it failed
+func HarvesterUpgradeStatus(u *unstructured.Unstructured) (completed bool, failed bool, message string, err error) {
+	conditions, _, err := unstructured.NestedSlice(u.Object, "status", "conditions")
+	if err != nil {
+		return false, false, "", err
+	}
+
+	for _, v := range conditions {
+		condition, ok := v.(map[string]interface{})
+		if !ok || condition["type"] != upgradeCompleted {
+			continue
+		}
+		message, _ = condition["message"].(string)
+		switch condition["status"] {
+		case "True":
+			return true, false, message, nil
+		case "False":
+			return true, true, message, nil
+		}
+	}
+
+	return false, false, "", nil
+}
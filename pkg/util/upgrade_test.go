@@ -0,0 +1,70 @@
+package util
+
+import (
+	"testing"
+
+	seederv1alpha1 "github.com/harvester/seeder/pkg/api/v1alpha1"
+	"github.com/stretchr/testify/require"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+func Test_CheckUpgradePath(t *testing.T) {
+	assert := require.New(t)
+	assert.NoError(CheckUpgradePath("v1.0.2", "v1.0.3"), "expected patch upgrade to be allowed")
+	assert.NoError(CheckUpgradePath("v1.0.3", "v1.1.0"), "expected minor upgrade to be allowed")
+	assert.Error(CheckUpgradePath("v1.1.0", "v1.0.3"), "expected downgrade to be blocked")
+	assert.Error(CheckUpgradePath("v1.1.0", "v1.1.0"), "expected upgrade to same version to be blocked")
+	assert.Error(CheckUpgradePath("v1.0.3", "v1.2.0"), "expected skipping a minor version to be blocked")
+	assert.Error(CheckUpgradePath("v1.1.0", "v2.0.0"), "expected major version upgrade to be blocked")
+	assert.Error(CheckUpgradePath("harvester_1_0_2", "v1.0.3"), "expected invalid version to be blocked")
+}
+
+func Test_GenerateHarvesterUpgrade(t *testing.T) {
+	assert := require.New(t)
+	v := GenerateHarvesterVersion("v1.1.0", GenerateISOURL("http://localhost", "v1.1.0", seederv1alpha1.ArchitectureAMD64))
+	isoURL, _, err := unstructured.NestedString(v.Object, "spec", "isoURL")
+	assert.NoError(err, "expected no error looking up isoURL")
+	assert.Equal("http://localhost/v1.1.0/harvester-v1.1.0-amd64.iso", isoURL)
+	assert.Equal(HarvesterSystemNamespace, v.GetNamespace())
+
+	u := GenerateHarvesterUpgrade("v1.1.0-rc1")
+	assert.Equal("seeder-upgrade-v1-1-0-rc1", u.GetName())
+	version, _, err := unstructured.NestedString(u.Object, "spec", "version")
+	assert.NoError(err, "expected no error looking up version")
+	assert.Equal("v1.1.0-rc1", version)
+}
+
+func Test_GenerateISOURL(t *testing.T) {
+	assert := require.New(t)
+	assert.Equal("http://localhost/v1.1.0/harvester-v1.1.0-arm64.iso", GenerateISOURL("http://localhost", "v1.1.0", seederv1alpha1.ArchitectureARM64))
+	assert.Equal("https://releases.rancher.com/harvester/v1.1.0/harvester-v1.1.0-amd64.iso", GenerateISOURL("", "v1.1.0", seederv1alpha1.ArchitectureAMD64))
+	assert.Equal("http://localhost/v1.1.0/harvester-v1.1.0-amd64.iso", GenerateISOURL("http://localhost/", "v1.1.0", seederv1alpha1.ArchitectureAMD64))
+}
+
+func Test_HarvesterUpgradeStatus(t *testing.T) {
+	assert := require.New(t)
+	u := GenerateHarvesterUpgrade("v1.1.0")
+	completed, failed, _, err := HarvesterUpgradeStatus(u)
+	assert.NoError(err, "expected no error while parsing upgrade without status")
+	assert.False(completed, "expected upgrade without status to be in progress")
+	assert.False(failed, "expected upgrade without status to not have failed")
+
+	u.Object["status"] = map[string]interface{}{
+		"conditions": []interface{}{
+			map[string]interface{}{
+				"type":   "ImageReady",
+				"status": "True",
+			},
+			map[string]interface{}{
+				"type":    "Completed",
+				"status":  "False",
+				"message": "node upgrade failed",
+			},
+		},
+	}
+	completed, failed, message, err := HarvesterUpgradeStatus(u)
+	assert.NoError(err, "expected no error while parsing upgrade status")
+	assert.True(completed, "expected upgrade to be completed")
+	assert.True(failed, "expected upgrade to have failed")
+	assert.Equal("node upgrade failed", message)
+}